@@ -11,9 +11,11 @@ import (
 	"github.com/openshift/deadmanssnitch-operator/pkg/apis"
 	"github.com/openshift/deadmanssnitch-operator/pkg/controller"
 	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	"github.com/openshift/deadmanssnitch-operator/pkg/webhook"
 	"github.com/openshift/operator-custom-metrics/pkg/metrics"
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
 
 	//	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/operator-framework/operator-sdk/pkg/leader"
@@ -22,6 +24,8 @@ import (
 	routev1 "github.com/openshift/api/route/v1"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -34,6 +38,10 @@ var (
 	metricsPath = "/metrics"
 	// metricsPort the port on which metrics is hosted, don't pick one that's already used
 	metricsPort = "8081"
+	// webhookPort the port the admission webhooks are served on, the webhook service of deploy/webhook.yaml targets it
+	webhookPort = 9443
+	// webhookCertDir the directory the serving certificate of the webhook service is mounted in
+	webhookCertDir = "/etc/webhook/certs"
 )
 var log = logf.Log.WithName("cmd")
 
@@ -95,6 +103,8 @@ func main() {
 		Namespace: "",
 		// disable the controller-runtime metrics
 		MetricsBindAddress: "0",
+		Port:               webhookPort,
+		CertDir:            webhookCertDir,
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -114,6 +124,10 @@ func main() {
 		log.Error(err, "")
 		os.Exit(1)
 	}
+	if err := hiveintv1alpha1.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
 
 	if err := routev1.AddToScheme(mgr.GetScheme()); err != nil {
 		log.Error(err, "error registering route objects")
@@ -143,6 +157,45 @@ func main() {
 		log.Info("Running in fedramp environment.")
 	}
 
+	err = operatorconfig.SetHTTPClientConfig()
+	if err != nil {
+		log.Error(err, "Failed to get the heartbeat provider client settings")
+		os.Exit(1)
+	}
+
+	err = operatorconfig.SetResyncPeriod()
+	if err != nil {
+		log.Error(err, "Failed to get the resync period")
+		os.Exit(1)
+	}
+
+	// the UID of the kube-system namespace identifies the hub, unless HUB_ID says otherwise
+	clusterUID := ""
+	kubeSystem := &corev1.Namespace{}
+	if err := mgr.GetAPIReader().Get(context.TODO(), types.NamespacedName{Name: "kube-system"}, kubeSystem); err != nil {
+		log.Error(err, "Failed to get the kube-system namespace")
+	} else {
+		clusterUID = string(kubeSystem.UID)
+	}
+	err = operatorconfig.SetHubID(clusterUID)
+	if err != nil {
+		log.Error(err, "Failed to get the hub ID")
+		os.Exit(1)
+	}
+
+	err = operatorconfig.SetWebhooksEnabled()
+	if err != nil {
+		log.Error(err, "Failed to get whether the webhooks are enabled")
+		os.Exit(1)
+	}
+	if operatorconfig.WebhooksEnabled() {
+		log.Info("Registering the admission webhooks.")
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Setup all Controllers
 	if err := controller.AddToManager(mgr); err != nil {
 		log.Error(err, "")
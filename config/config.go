@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 const (
@@ -27,9 +28,18 @@ const (
 	RefSecretPostfix  string = "dms-secret"
 	KeySnitchURL      string = "SNITCH_URL"
 
+	// KeySnitchAuthType and KeySnitchAuthCredentials hold the Authorization header type and credentials
+	// the cluster checks in with, for heartbeat providers that require one
+	KeySnitchAuthType        string = "SNITCH_AUTH_TYPE"
+	KeySnitchAuthCredentials string = "SNITCH_AUTH_CREDENTIALS"
+
 	// ClusterDeploymentManagedLabel is the label the clusterdeployment will have that determines
 	// if the cluster is OSD (managed) or not
 	ClusterDeploymentManagedLabel string = "api.openshift.com/managed"
+
+	// DefaultResyncPeriod is how often every ClusterDeployment of a DeadmansSnitchIntegration is reconciled
+	// when neither RESYNC_PERIOD nor spec.resyncPeriod say otherwise
+	DefaultResyncPeriod = time.Hour
 )
 
 var isFedramp = false
@@ -54,3 +64,123 @@ func SetIsFedramp() error {
 func IsFedramp() bool {
 	return isFedramp
 }
+
+var webhooksEnabled = false
+
+// SetWebhooksEnabled gets whether the admission webhooks are served from the ENABLE_WEBHOOKS environment variable.
+// They are off by default, as serving them needs the certificate mounted by deploy/operator.yaml
+func SetWebhooksEnabled() error {
+	enabled, ok := os.LookupEnv("ENABLE_WEBHOOKS")
+	if !ok {
+		enabled = "false"
+	}
+
+	enabledBool, err := strconv.ParseBool(enabled)
+	if err != nil {
+		return fmt.Errorf("Invalid value for ENABLE_WEBHOOKS environment variable. %w", err)
+	}
+
+	webhooksEnabled = enabledBool
+	return nil
+}
+
+// WebhooksEnabled returns value of webhooksEnabled var
+func WebhooksEnabled() bool {
+	return webhooksEnabled
+}
+
+// HTTPClientConfig holds the operator level settings of the clients talking to the heartbeat providers.
+// Proxies are taken from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+type HTTPClientConfig struct {
+	// DMSBaseURL replaces https://api.deadmanssnitch.com, i.e. with a local stand-in
+	DMSBaseURL string
+	// Timeout bounds a request to a provider, the client default applies when 0
+	Timeout time.Duration
+	// CABundleFile is the path of a PEM encoded CA bundle trusted in addition to the system roots
+	CABundleFile string
+	// TLSMinVersion is the minimum TLS version accepted, i.e. "1.2"
+	TLSMinVersion string
+}
+
+var httpClientConfig = HTTPClientConfig{}
+
+// SetHTTPClientConfig gets the settings of the heartbeat provider clients from the
+// DMS_API_BASE_URL, HTTP_CLIENT_TIMEOUT, CA_BUNDLE_FILE and TLS_MIN_VERSION environment variables
+func SetHTTPClientConfig() error {
+	c := HTTPClientConfig{
+		DMSBaseURL:    os.Getenv("DMS_API_BASE_URL"),
+		CABundleFile:  os.Getenv("CA_BUNDLE_FILE"),
+		TLSMinVersion: os.Getenv("TLS_MIN_VERSION"),
+	}
+
+	if timeout, ok := os.LookupEnv("HTTP_CLIENT_TIMEOUT"); ok && timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("Invalid value for HTTP_CLIENT_TIMEOUT environment variable. %w", err)
+		}
+		c.Timeout = d
+	}
+
+	switch c.TLSMinVersion {
+	case "", "1.0", "1.1", "1.2", "1.3":
+	default:
+		return fmt.Errorf("Invalid value for TLS_MIN_VERSION environment variable: %q", c.TLSMinVersion)
+	}
+
+	httpClientConfig = c
+	return nil
+}
+
+// GetHTTPClientConfig returns the operator level settings of the heartbeat provider clients
+func GetHTTPClientConfig() HTTPClientConfig {
+	return httpClientConfig
+}
+
+var resyncPeriod = DefaultResyncPeriod
+
+// SetResyncPeriod gets the operator level resync period of the DeadmansSnitchIntegrations from the RESYNC_PERIOD environment variable
+func SetResyncPeriod() error {
+	period, ok := os.LookupEnv("RESYNC_PERIOD")
+	if !ok || period == "" {
+		resyncPeriod = DefaultResyncPeriod
+		return nil
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return fmt.Errorf("Invalid value for RESYNC_PERIOD environment variable. %w", err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("Invalid value for RESYNC_PERIOD environment variable: %q is not positive", period)
+	}
+
+	resyncPeriod = d
+	return nil
+}
+
+// GetResyncPeriod returns the operator level resync period of the DeadmansSnitchIntegrations
+func GetResyncPeriod() time.Duration {
+	return resyncPeriod
+}
+
+var hubID = ""
+
+// SetHubID gets the ID of the hub stamped on the snitches it creates from the HUB_ID environment variable,
+// falling back to clusterUID, the UID of the kube-system namespace of the hub
+func SetHubID(clusterUID string) error {
+	id, ok := os.LookupEnv("HUB_ID")
+	if !ok || id == "" {
+		id = clusterUID
+	}
+	if id == "" {
+		return fmt.Errorf("Missing hub ID: the HUB_ID environment variable is empty and the hub has no cluster UID")
+	}
+
+	hubID = id
+	return nil
+}
+
+// GetHubID returns the ID of the hub stamped on the snitches it creates
+func GetHubID() string {
+	return hubID
+}
@@ -0,0 +1,128 @@
+// Package apierror holds the error the clients of the heartbeat providers return when a provider answers a call
+// with a status code outside of 2xx, so callers can tell what went wrong the same way for every provider.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize bounds how much of an error response is read
+const maxErrorBodySize = 64 * 1024
+
+// Error is returned when a provider answers a call with a status code outside of 2xx
+type Error struct {
+	// Provider is the provider that answered, i.e. "deadmanssnitch"
+	Provider string
+	// Operation is the client call that failed, i.e. "create"
+	Operation  string
+	StatusCode int
+	// Type and Message are taken from the error body the provider sends, i.e. {"type": "resource_not_found", "error": "Not Found"}
+	Type    string
+	Message string
+	// RetryAfter is how long to wait before calling the provider again when it rate limits or is unavailable
+	RetryAfter time.Duration
+}
+
+// errorBody holds the fields providers describe an error with. DMS and Healthchecks send "error", Opsgenie "message".
+type errorBody struct {
+	Type    string `json:"type"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("Error calling the API endpoint: %s returned status code %d", e.Operation, e.StatusCode)
+	if e.Type != "" {
+		msg += fmt.Sprintf(" (%s)", e.Type)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.StatusCode == http.StatusUnauthorized {
+		if e.Provider != "" {
+			msg += fmt.Sprintf(": please check the %s credentials", e.Provider)
+		} else {
+			msg += ": please check the credentials"
+		}
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %s", e.RetryAfter)
+	}
+	return msg
+}
+
+// New reads the error provider sent in the body of resp, and closes it
+func New(provider string, operation string, resp *http.Response) *Error {
+	defer resp.Body.Close()
+	apiErr := &Error{Provider: provider, Operation: operation, StatusCode: resp.StatusCode}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+	var eb errorBody
+	if json.Unmarshal(body, &eb) == nil {
+		apiErr.Type = eb.Type
+		apiErr.Message = eb.Error
+		if apiErr.Message == "" {
+			apiErr.Message = eb.Message
+		}
+	} else if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// As returns the Error err holds, if any
+func As(err error) (*Error, bool) {
+	var apiErr *Error
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// IsNotFound reports whether err is the provider answering that the heartbeat doesn't exist
+func IsNotFound(err error) bool {
+	apiErr, ok := As(err)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsRateLimited reports whether err is the provider rejecting a call for its rate limit, or asking to come back later
+func IsRateLimited(err error) bool {
+	apiErr, ok := As(err)
+	return ok && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.RetryAfter > 0)
+}
+
+// IsUnauthorized reports whether err is the provider rejecting the API key
+func IsUnauthorized(err error) bool {
+	apiErr, ok := As(err)
+	return ok && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsQuotaExceeded reports whether err is the provider refusing a heartbeat beyond the limit of the plan of the account
+func IsQuotaExceeded(err error) bool {
+	apiErr, ok := As(err)
+	if !ok {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusPaymentRequired {
+		return true
+	}
+	errType := strings.ToLower(apiErr.Type)
+	return strings.Contains(errType, "quota") || strings.Contains(errType, "plan_limit")
+}
+
+// RetryAfter returns how long to wait before calling the provider again if err is caused by throttling
+func RetryAfter(err error) (time.Duration, bool) {
+	if !IsRateLimited(err) {
+		return 0, false
+	}
+	apiErr, _ := As(err)
+	return apiErr.RetryAfter, true
+}
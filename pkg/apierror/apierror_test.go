@@ -0,0 +1,105 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func respond(statusCode int, body string, header ...string) *http.Response {
+	w := httptest.NewRecorder()
+	for i := 0; i+1 < len(header); i += 2 {
+		w.Header().Set(header[i], header[i+1])
+	}
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(body))
+	return w.Result()
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           *Error
+		expectedError string
+		notFound      bool
+		rateLimited   bool
+		unauthorized  bool
+		quotaExceeded bool
+	}{
+		{
+			name:          "not found",
+			err:           New("deadmanssnitch", "delete", respond(http.StatusNotFound, `{"type":"resource_not_found","error":"Not Found"}`)),
+			expectedError: "Error calling the API endpoint: delete returned status code 404 (resource_not_found): Not Found",
+			notFound:      true,
+		},
+		{
+			name:          "opsgenie message",
+			err:           New("opsgenie", "describe", respond(http.StatusNotFound, `{"message":"Heartbeat with name [abc] does not exist","took":0.001}`)),
+			expectedError: "Error calling the API endpoint: describe returned status code 404: Heartbeat with name [abc] does not exist",
+			notFound:      true,
+		},
+		{
+			name:          "unauthorized",
+			err:           New("healthchecks", "list_all", respond(http.StatusUnauthorized, "")),
+			expectedError: "Error calling the API endpoint: list_all returned status code 401: please check the healthchecks credentials",
+			unauthorized:  true,
+		},
+		{
+			name:          "forbidden",
+			err:           New("opsgenie", "list_all", respond(http.StatusForbidden, `{"message":"You are not authorized"}`)),
+			expectedError: "Error calling the API endpoint: list_all returned status code 403: You are not authorized",
+			unauthorized:  true,
+		},
+		{
+			name:          "quota exceeded",
+			err:           New("deadmanssnitch", "create", respond(http.StatusPaymentRequired, `{"type":"plan_limit_reached","error":"Your plan doesn't allow more snitches"}`)),
+			expectedError: "Error calling the API endpoint: create returned status code 402 (plan_limit_reached): Your plan doesn't allow more snitches",
+			quotaExceeded: true,
+		},
+		{
+			name:          "rate limited",
+			err:           New("healthchecks", "update", respond(http.StatusTooManyRequests, "")),
+			expectedError: "Error calling the API endpoint: update returned status code 429",
+			rateLimited:   true,
+		},
+		{
+			name:          "invalid",
+			err:           New("healthchecks", "create", respond(http.StatusBadRequest, "timeout is invalid", "Content-Type", "text/plain")),
+			expectedError: "Error calling the API endpoint: create returned status code 400: timeout is invalid",
+		},
+		{
+			name:          "html page",
+			err:           New("healthchecks", "create", respond(http.StatusBadGateway, "<html>Bad Gateway</html>", "Content-Type", "text/html")),
+			expectedError: "Error calling the API endpoint: create returned status code 502",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.EqualError(t, test.err, test.expectedError)
+			// the helpers see through wrapping
+			wrapped := fmt.Errorf("Error reconciling: %w", test.err)
+			assert.Equal(t, test.notFound, IsNotFound(wrapped))
+			assert.Equal(t, test.rateLimited, IsRateLimited(wrapped))
+			assert.Equal(t, test.unauthorized, IsUnauthorized(wrapped))
+			assert.Equal(t, test.quotaExceeded, IsQuotaExceeded(wrapped))
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	retryAfter, throttled := RetryAfter(&Error{Operation: "create", StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute})
+	assert.True(t, throttled)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	_, throttled = RetryAfter(&Error{Operation: "create", StatusCode: http.StatusServiceUnavailable})
+	assert.False(t, throttled)
+
+	_, throttled = RetryAfter(errors.New("Error calling the API endpoint: connection refused"))
+	assert.False(t, throttled)
+}
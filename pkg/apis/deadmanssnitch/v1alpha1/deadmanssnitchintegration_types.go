@@ -22,25 +22,202 @@ type DeadmansSnitchIntegrationSpec struct {
 	//name and namespace in the target cluster where the secret is synced
 	TargetSecretRef corev1.SecretReference `json:"targetSecretRef"`
 
-	//Array of strings that are applied to the service created in DMS
+	//Array of strings that are applied to the service created in DMS.
+	//Each tag is a Go template like snitchNameTemplate, tags rendering empty are left out.
+	//Tags can't contain whitespace.
 	Tags []string `json:"tags,omitempty"`
 
 	//The postfix to append to any snitches managed by this integration.  I.e. "osd" or "rhmi"
 	SnitchNamePostFix string `json:"snitchNamePostFix,omitempty"`
+
+	//Go template the snitch names are rendered from, i.e. "{{.ClusterName}}.{{.BaseDomain}}-{{.SnitchNamePostFix}}".
+	//It can read the Name, Namespace, ClusterName, BaseDomain, ClusterID, InternalClusterID, InfraID, Region, Platform,
+	//Labels and Annotations of the clusterdeployment and the SnitchNamePostFix.
+	//Defaults to "(clusterName).(baseDomain)-(snitchNamePostFix)", or the internal cluster ID on FedRAMP
+	// +optional
+	SnitchNameTemplate string `json:"snitchNameTemplate,omitempty"`
+
+	//Go template the snitch notes are rendered from, reading the same fields as snitchNameTemplate.
+	//Defaults to the cluster ID and the OSD runbook of a missing cluster
+	// +optional
+	NotesTemplate string `json:"notesTemplate,omitempty"`
+
+	//How often the snitches are expected to check in. Defaults to "15_minute"
+	// +kubebuilder:validation:Enum=15_minute;30_minute;hourly;daily;weekly;monthly
+	// +kubebuilder:default=15_minute
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	//How DMS decides to alert on a missed check in, "basic" or "smart". Defaults to "basic"
+	// +kubebuilder:validation:Enum=basic;smart
+	// +kubebuilder:default=basic
+	// +optional
+	AlertType string `json:"alertType,omitempty"`
+
+	//The heartbeat provider the snitches are created in, "deadmanssnitch", "healthchecks" or "opsgenie". Defaults to "deadmanssnitch"
+	// +kubebuilder:validation:Enum=deadmanssnitch;healthchecks;opsgenie
+	// +kubebuilder:default=deadmanssnitch
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	//Base URL of the heartbeat provider, i.e. "https://healthchecks.example.com" for a self-hosted Healthchecks instance
+	//or "https://api.eu.opsgenie.com" for the EU region of Opsgenie.
+	//Defaults to the public endpoint of the provider
+	// +optional
+	BaseURL string `json:"baseURL,omitempty"`
+
+	//Settings of the HTTP client talking to the heartbeat provider. Unset fields fall back to the operator level settings
+	// +optional
+	HTTPClient *HTTPClientConfig `json:"httpClient,omitempty"`
+
+	//What happens to the snitch of a hibernating cluster. "Delete" removes the snitch, secret and syncset, and creates new ones
+	//when the cluster resumes. "Pause" pauses the snitch, keeping its check-in URL and history, and unpauses it once the cluster
+	//is running again. Defaults to "Delete"
+	// +kubebuilder:validation:Enum=Delete;Pause
+	// +kubebuilder:default=Delete
+	// +optional
+	HibernationPolicy string `json:"hibernationPolicy,omitempty"`
+
+	//What happens to the snitches of the integration when it is deleted. "Delete" removes the snitches, secrets and syncsets.
+	//"Orphan" removes the secrets, syncsets and finalizers but leaves the snitches running, tagged so a later integration
+	//adopts them with their check-in URLs, the secrets are left on the clusters so they keep checking in. "Pause" orphans
+	//the snitches paused and removes the secrets from the clusters, as a check-in unpauses a snitch. Defaults to "Delete"
+	// +kubebuilder:validation:Enum=Delete;Orphan;Pause
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	//Whether existing snitches named like the snitch of a cluster but lacking the owner marker of the operator are adopted.
+	//Snitches created before the operator stamped the marker, or by hand, are only used with it set. Defaults to false
+	// +optional
+	AdoptUnmarkedSnitches bool `json:"adoptUnmarkedSnitches,omitempty"`
+
+	//How often every clusterdeployment of the integration is reconciled and its snitch verified against the heartbeat provider,
+	//even when nothing changed on the hub, i.e. "1h". Up to 10% of jitter is added so integrations don't resync all at once.
+	//Defaults to the operator level resync period
+	// +optional
+	ResyncPeriod *metav1.Duration `json:"resyncPeriod,omitempty"`
+}
+
+// HTTPClientConfig configures the HTTP client talking to the heartbeat provider
+type HTTPClientConfig struct {
+	//How long a request to the provider may take, i.e. "30s". Defaults to the operator level timeout
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	//URL of the proxy the requests to the provider go through. Defaults to the proxy environment variables of the operator
+	// +optional
+	ProxyURL string `json:"proxyURL,omitempty"`
+
+	//PEM encoded certificates trusted in addition to the system roots and the operator level CA bundle
+	// +optional
+	CABundle string `json:"caBundle,omitempty"`
+
+	//The minimum TLS version accepted from the provider. Defaults to the operator level minimum
+	// +kubebuilder:validation:Enum="1.0";"1.1";"1.2";"1.3"
+	// +optional
+	TLSMinVersion string `json:"tlsMinVersion,omitempty"`
 }
 
+const (
+	// DefaultInterval is the snitch interval used when spec.interval is not set
+	DefaultInterval = "15_minute"
+	// DefaultAlertType is the snitch alert type used when spec.alertType is not set
+	DefaultAlertType = "basic"
+
+	// ProviderDeadMansSnitch selects deadmanssnitch.com as the heartbeat provider, the default
+	ProviderDeadMansSnitch = "deadmanssnitch"
+	// ProviderHealthchecks selects Healthchecks.io, or a self-hosted Healthchecks instance, as the heartbeat provider
+	ProviderHealthchecks = "healthchecks"
+	// ProviderOpsgenie selects the heartbeats of Opsgenie as the heartbeat provider
+	ProviderOpsgenie = "opsgenie"
+
+	// HibernationPolicyDelete deletes the snitch of a hibernating cluster, the default
+	HibernationPolicyDelete = "Delete"
+	// HibernationPolicyPause pauses the snitch of a hibernating cluster
+	HibernationPolicyPause = "Pause"
+
+	// DeletionPolicyDelete deletes the snitches of a deleted integration, the default
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyOrphan leaves the snitches of a deleted integration for another integration to adopt
+	DeletionPolicyOrphan = "Orphan"
+	// DeletionPolicyPause pauses the snitches of a deleted integration and leaves them for another integration to adopt,
+	// removing the secrets from the clusters so they don't check in
+	DeletionPolicyPause = "Pause"
+)
+
 // DeadmansSnitchIntegrationStatus defines the observed state of DeadmansSnitchIntegration
 type DeadmansSnitchIntegrationStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
-	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+	//conditions describing the state of the integration, see the Condition* constants for the types in use
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	//the most recent generation of the DeadmansSnitchIntegration observed by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	//number of clusterdeployments matching the clusterDeploymentSelector and not skipped by annotation
+	MatchedClusterDeployments int `json:"matchedClusterDeployments"`
+
+	//number of matched clusterdeployments that have a snitch, secret and syncset in place
+	ManagedClusterDeployments int `json:"managedClusterDeployments"`
+
+	//number of matched clusterdeployments that failed to reconcile
+	FailedClusterDeployments int `json:"failedClusterDeployments"`
+
+	//per clusterdeployment inventory of the snitches managed by this integration
+	// +optional
+	Snitches []SnitchStatus `json:"snitches,omitempty"`
+
+	//truncated SHA-256 hash of the API key the snitches were last reconciled with, a different key is validated before it is used
+	// +optional
+	APIKeyHash string `json:"apiKeyHash,omitempty"`
 }
 
+// SnitchStatus records the snitch managed for a single ClusterDeployment
+type SnitchStatus struct {
+	//name of the clusterdeployment the snitch belongs to
+	ClusterDeploymentName string `json:"clusterDeploymentName"`
+
+	//namespace of the clusterdeployment the snitch belongs to
+	ClusterDeploymentNamespace string `json:"clusterDeploymentNamespace"`
+
+	//name of the snitch in DMS
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	//token identifying the snitch in DMS
+	// +optional
+	Token string `json:"token,omitempty"`
+
+	//status of the snitch as reported by DMS, i.e. "pending" or "healthy"
+	// +optional
+	Status string `json:"status,omitempty"`
+
+	//the last error encountered while reconciling this clusterdeployment, empty when the last reconcile succeeded
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+const (
+	// ConditionReady is True when every matched ClusterDeployment has been reconciled successfully
+	ConditionReady = "Ready"
+	// ConditionAPIKeyValid is True when the DMS API key referenced by dmsAPIKeySecretRef could be loaded and used
+	ConditionAPIKeyValid = "APIKeyValid"
+	// ConditionDegraded is True when at least one matched ClusterDeployment failed to reconcile
+	ConditionDegraded = "Degraded"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // DeadmansSnitchIntegration is the Schema for the deadmanssnitchintegrations API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=deadmanssnitchintegrations,shortName=dmsi,scope=Namespaced
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Matched",type="integer",JSONPath=".status.matchedClusterDeployments"
+// +kubebuilder:printcolumn:name="Managed",type="integer",JSONPath=".status.managedClusterDeployments"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedClusterDeployments"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type DeadmansSnitchIntegration struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,110 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeadmansSnitchMaintenanceWindowSpec defines when the snitches of which clusterdeployments are paused
+type DeadmansSnitchMaintenanceWindowSpec struct {
+	//Cron expression of when a recurring window opens, i.e. "0 2 * * 6" for every Saturday at 02:00.
+	//Evaluated in UTC unless prefixed with a time zone, i.e. "CRON_TZ=Europe/Berlin 0 2 * * 6".
+	//Exactly one of schedule and startTime is set
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	//When a one-off window opens. Exactly one of schedule and startTime is set
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	//How long the window stays open once it opened, i.e. "2h"
+	Duration metav1.Duration `json:"duration"`
+
+	//a label selector used to find the clusterdeployments whose snitches are paused while the window is open
+	ClusterDeploymentSelector metav1.LabelSelector `json:"clusterDeploymentSelector"`
+}
+
+// DeadmansSnitchMaintenanceWindowStatus defines the observed state of DeadmansSnitchMaintenanceWindow
+type DeadmansSnitchMaintenanceWindowStatus struct {
+	//conditions describing the state of the window, see ConditionActive
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	//the most recent generation of the DeadmansSnitchMaintenanceWindow observed by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	//where the window stands, one of the MaintenanceWindowPhase* constants
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	//when the window last opened
+	// +optional
+	LastStartTime *metav1.Time `json:"lastStartTime,omitempty"`
+
+	//when the window last closed
+	// +optional
+	LastEndTime *metav1.Time `json:"lastEndTime,omitempty"`
+
+	//when the open window closes
+	// +optional
+	ActiveUntil *metav1.Time `json:"activeUntil,omitempty"`
+
+	//when the window opens next, unset while it is open and once it won't open again
+	// +optional
+	NextStartTime *metav1.Time `json:"nextStartTime,omitempty"`
+
+	//the clusterdeployments whose snitches are paused by this window
+	// +optional
+	PausedClusterDeployments []ClusterDeploymentReference `json:"pausedClusterDeployments,omitempty"`
+}
+
+// ClusterDeploymentReference identifies a ClusterDeployment
+type ClusterDeploymentReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+const (
+	// MaintenanceWindowPhaseScheduled is the phase of a window waiting to open
+	MaintenanceWindowPhaseScheduled = "Scheduled"
+	// MaintenanceWindowPhaseActive is the phase of an open window
+	MaintenanceWindowPhaseActive = "Active"
+	// MaintenanceWindowPhaseCompleted is the phase of a window that won't open again
+	MaintenanceWindowPhaseCompleted = "Completed"
+	// MaintenanceWindowPhaseInvalid is the phase of a window whose schedule can't be evaluated
+	MaintenanceWindowPhaseInvalid = "Invalid"
+
+	// ConditionActive is True while a maintenance window is open
+	ConditionActive = "Active"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DeadmansSnitchMaintenanceWindow is the Schema for the deadmanssnitchmaintenancewindows API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=deadmanssnitchmaintenancewindows,shortName=dmsmw,scope=Namespaced
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule"
+// +kubebuilder:printcolumn:name="Duration",type="string",JSONPath=".spec.duration"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Next",type="date",JSONPath=".status.nextStartTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type DeadmansSnitchMaintenanceWindow struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeadmansSnitchMaintenanceWindowSpec   `json:"spec"`
+	Status DeadmansSnitchMaintenanceWindowStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DeadmansSnitchMaintenanceWindowList contains a list of DeadmansSnitchMaintenanceWindow
+type DeadmansSnitchMaintenanceWindowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeadmansSnitchMaintenanceWindow `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeadmansSnitchMaintenanceWindow{}, &DeadmansSnitchMaintenanceWindowList{})
+}
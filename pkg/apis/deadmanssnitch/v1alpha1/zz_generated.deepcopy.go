@@ -5,6 +5,7 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -23,13 +24,28 @@ func (in *ClusterDeploymentAnnotationsToSkip) DeepCopy() *ClusterDeploymentAnnot
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDeploymentReference) DeepCopyInto(out *ClusterDeploymentReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDeploymentReference.
+func (in *ClusterDeploymentReference) DeepCopy() *ClusterDeploymentReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDeploymentReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeadmansSnitchIntegration) DeepCopyInto(out *DeadmansSnitchIntegration) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadmansSnitchIntegration.
@@ -98,6 +114,16 @@ func (in *DeadmansSnitchIntegrationSpec) DeepCopyInto(out *DeadmansSnitchIntegra
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.HTTPClient != nil {
+		in, out := &in.HTTPClient, &out.HTTPClient
+		*out = new(HTTPClientConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResyncPeriod != nil {
+		in, out := &in.ResyncPeriod, &out.ResyncPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadmansSnitchIntegrationSpec.
@@ -113,6 +139,18 @@ func (in *DeadmansSnitchIntegrationSpec) DeepCopy() *DeadmansSnitchIntegrationSp
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeadmansSnitchIntegrationStatus) DeepCopyInto(out *DeadmansSnitchIntegrationStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Snitches != nil {
+		in, out := &in.Snitches, &out.Snitches
+		*out = make([]SnitchStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadmansSnitchIntegrationStatus.
@@ -124,3 +162,161 @@ func (in *DeadmansSnitchIntegrationStatus) DeepCopy() *DeadmansSnitchIntegration
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeadmansSnitchMaintenanceWindow) DeepCopyInto(out *DeadmansSnitchMaintenanceWindow) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadmansSnitchMaintenanceWindow.
+func (in *DeadmansSnitchMaintenanceWindow) DeepCopy() *DeadmansSnitchMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(DeadmansSnitchMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeadmansSnitchMaintenanceWindow) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeadmansSnitchMaintenanceWindowList) DeepCopyInto(out *DeadmansSnitchMaintenanceWindowList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DeadmansSnitchMaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadmansSnitchMaintenanceWindowList.
+func (in *DeadmansSnitchMaintenanceWindowList) DeepCopy() *DeadmansSnitchMaintenanceWindowList {
+	if in == nil {
+		return nil
+	}
+	out := new(DeadmansSnitchMaintenanceWindowList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeadmansSnitchMaintenanceWindowList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeadmansSnitchMaintenanceWindowSpec) DeepCopyInto(out *DeadmansSnitchMaintenanceWindowSpec) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	out.Duration = in.Duration
+	in.ClusterDeploymentSelector.DeepCopyInto(&out.ClusterDeploymentSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadmansSnitchMaintenanceWindowSpec.
+func (in *DeadmansSnitchMaintenanceWindowSpec) DeepCopy() *DeadmansSnitchMaintenanceWindowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeadmansSnitchMaintenanceWindowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeadmansSnitchMaintenanceWindowStatus) DeepCopyInto(out *DeadmansSnitchMaintenanceWindowStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastStartTime != nil {
+		in, out := &in.LastStartTime, &out.LastStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastEndTime != nil {
+		in, out := &in.LastEndTime, &out.LastEndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ActiveUntil != nil {
+		in, out := &in.ActiveUntil, &out.ActiveUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.NextStartTime != nil {
+		in, out := &in.NextStartTime, &out.NextStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PausedClusterDeployments != nil {
+		in, out := &in.PausedClusterDeployments, &out.PausedClusterDeployments
+		*out = make([]ClusterDeploymentReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadmansSnitchMaintenanceWindowStatus.
+func (in *DeadmansSnitchMaintenanceWindowStatus) DeepCopy() *DeadmansSnitchMaintenanceWindowStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeadmansSnitchMaintenanceWindowStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPClientConfig) DeepCopyInto(out *HTTPClientConfig) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPClientConfig.
+func (in *HTTPClientConfig) DeepCopy() *HTTPClientConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPClientConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnitchStatus) DeepCopyInto(out *SnitchStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnitchStatus.
+func (in *SnitchStatus) DeepCopy() *SnitchStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnitchStatus)
+	in.DeepCopyInto(out)
+	return out
+}
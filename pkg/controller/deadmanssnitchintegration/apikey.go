@@ -0,0 +1,135 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// apiKeySecretIndex indexes DeadmansSnitchIntegrations by the namespace and name of their API key secret
+	apiKeySecretIndex = "dms.spec.dmsAPIKeySecretRef"
+	// apiKeyHashLength is the number of hex characters of the API key hash kept in the status
+	apiKeyHashLength = 16
+)
+
+// errAPIKeyMismatch is returned by validateAPIKey for a key that sees none of the snitches of the dmsi
+var errAPIKeyMismatch = errors.New("the API key belongs to another account")
+
+// apiKeyHash identifies the API key in the dmsi status without revealing it
+func apiKeyHash(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])[:apiKeyHashLength]
+}
+
+// apiKeySecretRefs extracts the values of apiKeySecretIndex
+func apiKeySecretRefs(obj runtime.Object) []string {
+	dmsi, ok := obj.(*deadmanssnitchv1alpha1.DeadmansSnitchIntegration)
+	if !ok {
+		return nil
+	}
+	return []string{dmsi.Spec.DmsAPIKeySecretRef.Namespace + "/" + dmsi.Spec.DmsAPIKeySecretRef.Name}
+}
+
+// checkAPIKey validates the API key against the provider unless it is the key the snitches were last reconciled with,
+// and records it in the status once it passed. It returns false when the key was rejected: no ClusterDeployment may be
+// reconciled with it, as a key of another account finds none of the snitches and would have them all recreated or deleted.
+// Errors that say nothing about the key, like an unreachable or throttled provider, are returned for the reconcile to retry.
+func (r *ReconcileDeadmansSnitchIntegration) checkAPIKey(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, apiKey string, dmsc heartbeat.Provider) (bool, error) {
+	hash := apiKeyHash(apiKey)
+	if dmsi.Status.APIKeyHash == hash {
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionAPIKeyValid, metav1.ConditionTrue, "APIKeyValidated", "")
+		return true, nil
+	}
+
+	// the first key seen is validated too, a dmsi whose status was lost may still have snitches of another account
+	err := validateAPIKey(dmsi, dmsc)
+	if err != nil && !apierror.IsUnauthorized(err) && !errors.Is(err, errAPIKeyMismatch) {
+		return false, err
+	}
+	if err != nil {
+		log.Error(err, "API key failed validation", "DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name)
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionAPIKeyValid, metav1.ConditionFalse, "APIKeyValidationFailed", err.Error())
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionReady, metav1.ConditionFalse, "APIKeyValidationFailed", err.Error())
+		r.recorder.Eventf(dmsi, corev1.EventTypeWarning, "APIKeyValidationFailed",
+			"The API key in secret %s/%s failed validation, no snitch is changed until it is fixed: %v",
+			dmsi.Spec.DmsAPIKeySecretRef.Namespace, dmsi.Spec.DmsAPIKeySecretRef.Name, err)
+		return false, nil
+	}
+	setCondition(dmsi, deadmanssnitchv1alpha1.ConditionAPIKeyValid, metav1.ConditionTrue, "APIKeyValidated", "")
+	if dmsi.Status.APIKeyHash != "" {
+		r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "APIKeyRotated",
+			"The rotated API key in secret %s/%s was validated", dmsi.Spec.DmsAPIKeySecretRef.Namespace, dmsi.Spec.DmsAPIKeySecretRef.Name)
+	}
+
+	dmsi.Status.APIKeyHash = hash
+	return true, nil
+}
+
+// validateAPIKey requires the key of the provider to list the heartbeats, including at least one of the snitches
+// in the dmsi status, so a key of another account is rejected
+func validateAPIKey(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, dmsc heartbeat.Provider) error {
+	heartbeats, err := dmsc.ListAll()
+	if err != nil {
+		return err
+	}
+
+	tokens := sets.NewString()
+	for _, h := range heartbeats {
+		tokens.Insert(h.Token)
+	}
+	known := 0
+	for _, snitchStatus := range dmsi.Status.Snitches {
+		if snitchStatus.Token == "" {
+			continue
+		}
+		if tokens.Has(snitchStatus.Token) {
+			return nil
+		}
+		known++
+	}
+	if known > 0 {
+		return fmt.Errorf("None of the %d snitches of the integration are visible with the API key: %w", known, errAPIKeyMismatch)
+	}
+	return nil
+}
+
+// apiKeySecretToDeadMansSnitchIntegrationsMapper queues every DeadMansSnitchIntegration using the API key in a Secret,
+// so a rotated key is validated and used right away
+type apiKeySecretToDeadMansSnitchIntegrationsMapper struct {
+	Client client.Client
+}
+
+func (m apiKeySecretToDeadMansSnitchIntegrationsMapper) Map(mo handler.MapObject) []reconcile.Request {
+	secretRef := types.NamespacedName{Name: mo.Meta.GetName(), Namespace: mo.Meta.GetNamespace()}.String()
+
+	dmsilist := &deadmanssnitchv1alpha1.DeadmansSnitchIntegrationList{}
+	err := m.Client.List(context.TODO(), dmsilist, client.MatchingFields{apiKeySecretIndex: secretRef})
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for i := range dmsilist.Items {
+		dmsi := &dmsilist.Items[i]
+		if apiKeySecretRefs(dmsi)[0] == secretRef {
+			requests = append(requests, dmsiRequest(dmsi))
+		}
+	}
+	return requests
+}
@@ -0,0 +1,270 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	mockdms "github.com/openshift/deadmanssnitch-operator/pkg/dmsclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hiveapis "github.com/openshift/hive/apis"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// return a dmsi last reconciled with another API key, managing the snitch of testClusterDeployment
+func testRotatedAPIKeyDeadMansSnitchIntegration() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Status.APIKeyHash = apiKeyHash("previous-key")
+	dmsi.Status.Snitches = []deadmanssnitchv1alpha1.SnitchStatus{
+		{ClusterDeploymentName: testClusterName, ClusterDeploymentNamespace: testNamespace, Token: testSnitchToken},
+	}
+	return dmsi
+}
+
+// return a dmsi no API key was validated for yet
+func testFirstAPIKeyDeadMansSnitchIntegration() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Status.APIKeyHash = ""
+	return dmsi
+}
+
+func TestAPIKeyHash(t *testing.T) {
+	assert.Len(t, apiKeyHash(testAPIKey), apiKeyHashLength)
+	assert.Equal(t, apiKeyHash(testAPIKey), apiKeyHash(testAPIKey))
+	assert.NotEqual(t, apiKeyHash(testAPIKey), apiKeyHash("previous-key"))
+	assert.NotContains(t, apiKeyHash(testAPIKey), testAPIKey)
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	tests := []struct {
+		name             string
+		dmsi             *deadmanssnitchv1alpha1.DeadmansSnitchIntegration
+		setupDMSMock     func(r *mockdms.MockClientMockRecorder)
+		expectedValid    bool
+		expectedErr      bool
+		expectedHash     string
+		expectedReason   string
+		expectedEventLen int
+	}{
+		{
+			name: "first key",
+			dmsi: testFirstAPIKeyDeadMansSnitchIntegration(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{}, nil).Times(1)
+			},
+			expectedValid:  true,
+			expectedHash:   apiKeyHash(testAPIKey),
+			expectedReason: "APIKeyValidated",
+		},
+		{
+			name: "first key rejected",
+			dmsi: testFirstAPIKeyDeadMansSnitchIntegration(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return(nil, &dmsclient.APIError{Operation: "list_all", StatusCode: 401}).Times(1)
+			},
+			expectedHash:     "",
+			expectedReason:   "APIKeyValidationFailed",
+			expectedEventLen: 1,
+		},
+		{
+			name: "first key with the provider down",
+			dmsi: testFirstAPIKeyDeadMansSnitchIntegration(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return(nil, &dmsclient.APIError{Operation: "list_all", StatusCode: 503}).Times(1)
+			},
+			expectedErr:  true,
+			expectedHash: "",
+		},
+		{
+			name:           "unchanged key",
+			dmsi:           testDeadMansSnitchIntegration(),
+			setupDMSMock:   func(r *mockdms.MockClientMockRecorder) {},
+			expectedValid:  true,
+			expectedHash:   apiKeyHash(testAPIKey),
+			expectedReason: "APIKeyValidated",
+		},
+		{
+			name: "rotated key seeing the snitches",
+			dmsi: testRotatedAPIKeyDeadMansSnitchIntegration(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{testLiveSnitch(testClusterName)}, nil).Times(1)
+			},
+			expectedValid:    true,
+			expectedHash:     apiKeyHash(testAPIKey),
+			expectedReason:   "APIKeyValidated",
+			expectedEventLen: 1,
+		},
+		{
+			name: "rotated key of another account",
+			dmsi: testRotatedAPIKeyDeadMansSnitchIntegration(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{}, nil).Times(1)
+			},
+			expectedHash:     apiKeyHash("previous-key"),
+			expectedReason:   "APIKeyValidationFailed",
+			expectedEventLen: 1,
+		},
+		{
+			name: "rotated key rejected",
+			dmsi: testRotatedAPIKeyDeadMansSnitchIntegration(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return(nil, &dmsclient.APIError{Operation: "list_all", StatusCode: 401}).Times(1)
+			},
+			expectedHash:     apiKeyHash("previous-key"),
+			expectedReason:   "APIKeyValidationFailed",
+			expectedEventLen: 1,
+		},
+		{
+			name: "rotated key throttled",
+			dmsi: testRotatedAPIKeyDeadMansSnitchIntegration(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return(nil, &dmsclient.APIError{Operation: "list_all", StatusCode: 429}).Times(1)
+			},
+			expectedErr:  true,
+			expectedHash: apiKeyHash("previous-key"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			defer mocks.mockCtrl.Finish()
+			test.setupDMSMock(mocks.mockDMSClient.EXPECT())
+
+			recorder := record.NewFakeRecorder(100)
+			r := &ReconcileDeadmansSnitchIntegration{
+				client:   mocks.fakeKubeClient,
+				scheme:   scheme.Scheme,
+				recorder: recorder,
+			}
+
+			valid, err := r.checkAPIKey(test.dmsi, testAPIKey, heartbeat.NewDMSProvider(mocks.mockDMSClient))
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expectedValid, valid)
+			assert.Equal(t, test.expectedHash, test.dmsi.Status.APIKeyHash)
+			assert.Len(t, recorder.Events, test.expectedEventLen)
+			condition := meta.FindStatusCondition(test.dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionAPIKeyValid)
+			if test.expectedReason == "" {
+				assert.Nil(t, condition)
+			} else if assert.NotNil(t, condition) {
+				assert.Equal(t, test.expectedReason, condition.Reason)
+			}
+		})
+	}
+}
+
+func TestValidateAPIKeyWithoutKnownSnitches(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	defer mocks.mockCtrl.Finish()
+	mocks.mockDMSClient.EXPECT().ListAll().Return([]dmsclient.Snitch{}, nil).Times(1)
+
+	err := validateAPIKey(testDeadMansSnitchIntegration(), heartbeat.NewDMSProvider(mocks.mockDMSClient))
+	assert.NoError(t, err)
+}
+
+func TestReconcileRotatedAPIKeyFailingValidation(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// the hibernating cluster would have its snitch, secret and syncset deleted if the key was used
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		hibernatingClusterDeployment(),
+		testSecret(),
+		testRotatedAPIKeyDeadMansSnitchIntegration(),
+	}, testExistingSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	r := mocks.mockDMSClient.EXPECT()
+	r.ListAll().Return([]dmsclient.Snitch{}, nil).Times(1)
+	r.Delete(gomock.Any()).Times(0)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	result, err := rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+	assert.NoError(t, err)
+	assert.Equal(t, apiErrorRequeueDelay, result.RequeueAfter)
+
+	name := testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix
+	assert.True(t, verifySecretExists(mocks.fakeKubeClient, &SecretEntry{name: name, snitchURL: testSnitchURL, clusterDeploymentRefName: testClusterName}))
+	assert.True(t, verifySyncSetExists(mocks.fakeKubeClient, &SyncSetEntry{name: name, referencedSecretName: name, clusterDeploymentRefName: testClusterName}))
+}
+
+func TestReconcileRotatedAPIKeyProviderDown(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testRotatedAPIKeyDeadMansSnitchIntegration(),
+	}, testExistingSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	// an outage of the provider says nothing about the key, the reconcile is retried with backoff
+	r := mocks.mockDMSClient.EXPECT()
+	r.ListAll().Return(nil, &dmsclient.APIError{Operation: "list_all", StatusCode: 503}).Times(1)
+	r.Delete(gomock.Any()).Times(0)
+
+	recorder := record.NewFakeRecorder(100)
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}}
+	_, err = rdms.Reconcile(request)
+	assert.Error(t, err)
+	assert.Len(t, recorder.Events, 0)
+
+	dmsi := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), request.NamespacedName, dmsi)
+	assert.NoError(t, err)
+	assert.Equal(t, apiKeyHash("previous-key"), dmsi.Status.APIKeyHash)
+	assert.Nil(t, meta.FindStatusCondition(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionAPIKeyValid))
+}
+
+func TestAPIKeySecretToDeadMansSnitchIntegrationsMapper(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	other := testDeadMansSnitchIntegration()
+	other.Name = "other"
+	other.Spec.DmsAPIKeySecretRef.Name = "other-api-key"
+	mocks := setupDefaultMocks(t, []runtime.Object{testDeadMansSnitchIntegration(), other})
+	defer mocks.mockCtrl.Finish()
+
+	mapper := apiKeySecretToDeadMansSnitchIntegrationsMapper{Client: mocks.fakeKubeClient}
+	secret := testSecret()
+	secret.Name = deadMansSnitchAPISecretKey
+	secret.Namespace = config.OperatorNamespace
+
+	assert.Equal(t, []reconcile.Request{dmsiRequest(testDeadMansSnitchIntegration())}, mapper.Map(handler.MapObject{Meta: secret, Object: secret}))
+	assert.Empty(t, mapper.Map(handler.MapObject{Meta: testSecretRef(), Object: testSecretRef()}))
+}
@@ -4,24 +4,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/openshift/deadmanssnitch-operator/config"
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
 	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
 	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	"github.com/openshift/deadmanssnitch-operator/pkg/healthchecksclient"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
 	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	"github.com/openshift/deadmanssnitch-operator/pkg/maintenance"
+	"github.com/openshift/deadmanssnitch-operator/pkg/opsgenieclient"
+	"github.com/openshift/deadmanssnitch-operator/pkg/snitchtemplate"
 	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -32,11 +51,17 @@ import (
 var log = logf.Log.WithName("controller_deadmanssnitchintegration")
 
 const (
-	deadMansSnitchAPISecretKey    = "deadmanssnitch-api-key"
-	DeadMansSnitchFinalizerPrefix = "dms.managed.openshift.io/deadmanssnitch-"
+	deadMansSnitchAPISecretKey = "deadmanssnitch-api-key"
+	opsgeniePingAPISecretKey   = "opsgenie-ping-api-key"
 	// This can be removed once Hive is promoted past f73ed3e in all environments
 	// Support for this condition was removed in https://github.com/openshift/hive/pull/1604
 	legacyHivev1RunningHibernationReason = "Running"
+	// snitchPausedAnnotation marks the hub secret of a cluster whose snitch was paused for hibernation
+	snitchPausedAnnotation = "dms.managed.openshift.io/paused-for-hibernation"
+	// apiErrorRequeueDelay is how long to wait before retrying a reconcile failed by an error retrying won't fix soon
+	apiErrorRequeueDelay = 5 * time.Minute
+	// syncSetApplyRequeueDelay is how long to wait before checking again if hive applied the syncset of an orphaned cluster
+	syncSetApplyRequeueDelay = 30 * time.Second
 )
 
 // Add creates a new DeadmansSnitchIntegration Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -45,26 +70,37 @@ func Add(mgr manager.Manager) error {
 	return add(mgr, newReconciler(mgr))
 }
 
-// newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+// newReconciler returns a new ReconcileDeadmansSnitchIntegration
+func newReconciler(mgr manager.Manager) *ReconcileDeadmansSnitchIntegration {
 	return &ReconcileDeadmansSnitchIntegration{
 		//client:    mgr.GetClient(),
-		client:    mgr.GetClient(),
-		scheme:    mgr.GetScheme(),
-		dmsclient: dmsclient.NewClient,
+		client:             mgr.GetClient(),
+		scheme:             mgr.GetScheme(),
+		recorder:           mgr.GetEventRecorderFor(config.OperatorName),
+		dmsclient:          dmsclient.NewClientWithOptions,
+		healthchecksclient: healthchecksclient.NewClient,
+		opsgenieclient:     opsgenieclient.NewClient,
+		clusterRetries:     make(chan event.GenericEvent),
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r *ReconcileDeadmansSnitchIntegration) error {
 	// Create a new controller
 	c, err := controller.New("deadmanssnitchintegration-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
 	}
 
-	// Watch for changes to primary resource DeadmansSnitchIntegration
-	err = c.Watch(&source.Kind{Type: &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}}, &handler.EnqueueRequestForObject{})
+	err = addIndexes(mgr)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource DeadmansSnitchIntegration. Only spec changes and deletions
+	// need every ClusterDeployment reconciled, so status and finalizer updates are filtered out.
+	err = c.Watch(&source.Kind{Type: &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}}, &handler.EnqueueRequestForObject{},
+		dmsiSpecChangedPredicate)
 	if err != nil {
 		return err
 	}
@@ -94,6 +130,19 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to maintenance windows. The window controller updates their status
+	// when they open and close, so the snitches are paused and unpaused on time.
+	err = c.Watch(&source.Kind{Type: &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: maintenanceWindowToDeadMansSnitchIntegrationsMapper{
+				Client: mgr.GetClient(),
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes to Secrets. If one has any ClusterDeployment owner
 	// references, queue a request for all DeadMansSnitchIntegration CR that
 	// select those ClusterDeployments.
@@ -108,6 +157,24 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to the API key Secrets, queueing every DeadMansSnitchIntegration using the changed key.
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: apiKeySecretToDeadMansSnitchIntegrationsMapper{
+				Client: mgr.GetClient(),
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Queue the pair requests retrying the ClusterDeployments that failed in a full reconcile, see retryClusterDeployment
+	err = c.Watch(&source.Channel{Source: r.clusterRetries}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -118,9 +185,16 @@ var _ reconcile.Reconciler = &ReconcileDeadmansSnitchIntegration{}
 type ReconcileDeadmansSnitchIntegration struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client    client.Client
-	scheme    *runtime.Scheme
-	dmsclient func(authToken string, collector *localmetrics.MetricsCollector) dmsclient.Client
+	client             client.Client
+	scheme             *runtime.Scheme
+	recorder           record.EventRecorder
+	dmsclient          func(authToken string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error)
+	healthchecksclient func(apiKey string, baseURL string, httpClient *http.Client, collector *localmetrics.MetricsCollector) (healthchecksclient.Client, error)
+	opsgenieclient     func(apiKey string, baseURL string, httpClient *http.Client, collector *localmetrics.MetricsCollector) (opsgenieclient.Client, error)
+	// clusterBackoff spaces out the retries of the ClusterDeployments failing to reconcile, see clusterFailureBackoff
+	clusterBackoff workqueue.RateLimiter
+	// clusterRetries feeds the pair requests retrying the ClusterDeployments failed in a full reconcile to the controller
+	clusterRetries chan event.GenericEvent
 }
 
 // Reconcile reads that state of the cluster for a DeadmansSnitchIntegration object and makes changes based on the state read
@@ -133,10 +207,13 @@ func (r *ReconcileDeadmansSnitchIntegration) Reconcile(request reconcile.Request
 		reqLogger.Info("Running in FedRAMP mode")
 	}
 
+	// Pair requests only reconcile their ClusterDeployment, see clusterDeploymentRequest
+	dmsiName, clusterDeploymentName := parseRequest(request)
+
 	// Fetch the DeadmansSnitchIntegration dmsi
 	dmsi := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
 
-	err := r.client.Get(context.TODO(), request.NamespacedName, dmsi)
+	err := r.client.Get(context.TODO(), dmsiName, dmsi)
 	if err != nil {
 		if k8errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
@@ -149,36 +226,80 @@ func (r *ReconcileDeadmansSnitchIntegration) Reconcile(request reconcile.Request
 	}
 
 	// set the DMS finalizer variable
-	deadMansSnitchFinalizer := DeadMansSnitchFinalizerPrefix + dmsi.Name
+	deadMansSnitchFinalizer := finalizerName(dmsi)
+
+	err = r.migrateDMSIFinalizer(dmsi)
+	if err != nil {
+		reqLogger.Error(err, "Error migrating legacy finalizer of dmsi")
+		return reconcile.Result{}, err
+	}
+
+	originalStatus := dmsi.Status.DeepCopy()
 
 	dmsAPIKey, err := utils.LoadSecretData(r.client, dmsi.Spec.DmsAPIKeySecretRef.Name,
 		dmsi.Spec.DmsAPIKeySecretRef.Namespace, deadMansSnitchAPISecretKey)
 	if err != nil {
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionAPIKeyValid, metav1.ConditionFalse, "APIKeySecretError", err.Error())
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionReady, metav1.ConditionFalse, "APIKeySecretError", err.Error())
+		if statusErr := r.updateStatus(dmsi, originalStatus); statusErr != nil {
+			reqLogger.Error(statusErr, "Error updating dmsi status")
+		}
 		return reconcile.Result{}, err
 	}
-	dmsc := r.dmsclient(dmsAPIKey, localmetrics.Collector)
 
-	matchingClusterDeployments, err := r.getMatchingClusterDeployment(dmsi)
+	dmsc, err := r.heartbeatProvider(dmsi, dmsAPIKey)
 	if err != nil {
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionReady, metav1.ConditionFalse, "ProviderError", err.Error())
+		if statusErr := r.updateStatus(dmsi, originalStatus); statusErr != nil {
+			reqLogger.Error(statusErr, "Error updating dmsi status")
+		}
 		return reconcile.Result{}, err
 	}
 
-	allClusterDeployments, err := r.getAllClusterDeployment()
+	valid, err := r.checkAPIKey(dmsi, dmsAPIKey, dmsc)
 	if err != nil {
-		return reconcile.Result{}, err
+		reqLogger.Error(err, "Error validating the API key")
+		result, err := apiErrorResult(dmsi, err)
+		if statusErr := r.updateStatus(dmsi, originalStatus); statusErr != nil {
+			reqLogger.Error(statusErr, "Error updating dmsi status")
+		}
+		return result, err
+	}
+	if !valid {
+		if statusErr := r.updateStatus(dmsi, originalStatus); statusErr != nil {
+			reqLogger.Error(statusErr, "Error updating dmsi status")
+		}
+		// the secret watch queues the dmsi again when the key is fixed
+		return reconcile.Result{RequeueAfter: apiErrorRequeueDelay}, nil
 	}
 
 	if dmsi.DeletionTimestamp != nil {
-		for _, clusterdeployment := range allClusterDeployments.Items {
-			if utils.HasFinalizer(&clusterdeployment, deadMansSnitchFinalizer) {
-				err = r.deleteDMSClusterDeployment(dmsi, &clusterdeployment, dmsc)
-				if err != nil {
-					return reconcile.Result{}, err
-				}
+		finalizedClusterDeployments, err := listFinalizedClusterDeployments(r.client, dmsi)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		// the dmsi keeps its finalizer until the syncsets of every orphaned clusterdeployment are deleted
+		syncSetsPending := false
+		for _, clusterdeployment := range finalizedClusterDeployments {
+			err = r.migrateClusterDeploymentFinalizer(dmsi, &clusterdeployment)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			err = r.releaseDMSClusterDeployment(dmsi, &clusterdeployment, dmsc)
+			if errors.Is(err, errSyncSetNotApplied) {
+				syncSetsPending = true
+				continue
+			}
+			if err != nil {
+				return apiErrorResult(dmsi, err)
 			}
 		}
-		if utils.HasFinalizer(dmsi, deadMansSnitchFinalizer) {
+		if syncSetsPending {
+			return reconcile.Result{RequeueAfter: syncSetApplyRequeueDelay}, nil
+		}
+		if hasFinalizer(dmsi, dmsi) {
 			utils.DeleteFinalizer(dmsi, deadMansSnitchFinalizer)
+			utils.DeleteFinalizer(dmsi, legacyFinalizerName(dmsi))
 			reqLogger.Info("Deleting DMSI finalizer from dmsi", "DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name)
 			err = r.client.Update(context.TODO(), dmsi)
 			if err != nil {
@@ -189,131 +310,427 @@ func (r *ReconcileDeadmansSnitchIntegration) Reconcile(request reconcile.Request
 		return reconcile.Result{}, nil
 	}
 
-	for _, clusterdeployment := range allClusterDeployments.Items {
+	if clusterDeploymentName != nil {
+		return r.reconcileClusterDeploymentRequest(dmsi, *clusterDeploymentName, dmsc, originalStatus)
+	}
+
+	matchingClusterDeployments, err := r.getMatchingClusterDeployments(dmsi)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// besides the matching ClusterDeployments, those still carrying the finalizer of the dmsi need their snitch torn down
+	candidateClusterDeployments, err := r.getCandidateClusterDeployments(dmsi, matchingClusterDeployments)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// index the previous snitch inventory so entries are carried over for
+	// clusters that don't need any DMS calls during this reconcile
+	previousSnitches := map[types.NamespacedName]deadmanssnitchv1alpha1.SnitchStatus{}
+	for _, snitchStatus := range dmsi.Status.Snitches {
+		previousSnitches[types.NamespacedName{Name: snitchStatus.ClusterDeploymentName, Namespace: snitchStatus.ClusterDeploymentNamespace}] = snitchStatus
+	}
+	snitches := []deadmanssnitchv1alpha1.SnitchStatus{}
+	managed := 0
+	// a ClusterDeployment failing to reconcile doesn't hold up the others, it is retried on its own with its own backoff
+	clusterErrs := []error{}
+
+	matched := map[types.UID]bool{}
+	for _, matchingClusterDeployment := range matchingClusterDeployments {
+		matched[matchingClusterDeployment.UID] = true
+	}
+
+	for i, clusterdeployment := range candidateClusterDeployments {
 
 		// Check if the cluster matches the requirements for needing DMS setup
-		clusterMatched := false
-		for _, matchingClusterDeployment := range matchingClusterDeployments {
-			if clusterdeployment.UID == matchingClusterDeployment.UID {
-				clusterMatched = true
-				break
-			}
-		}
+		clusterMatched := matched[clusterdeployment.UID]
 
-		if !clusterMatched || clusterdeployment.DeletionTimestamp != nil {
-			// The cluster does not match the criteria for needing DMS setup
-			if utils.HasFinalizer(&clusterdeployment, deadMansSnitchFinalizer) {
-				// The cluster has an existing DMS setup, so remove it
-				err = r.deleteDMSClusterDeployment(dmsi, &clusterdeployment, dmsc)
-				if err != nil {
-					return reconcile.Result{}, err
+		snitchStatus := clusterSnitchStatus(dmsi, previousSnitches[types.NamespacedName{Name: clusterdeployment.Name, Namespace: clusterdeployment.Namespace}], clusterdeployment)
+
+		isManaged, snitch, err := r.reconcileClusterDeployment(dmsi, &clusterdeployment, clusterMatched, dmsc, true)
+		if snitch != nil {
+			snitchStatus.Token = snitch.Token
+			snitchStatus.Status = snitch.Status
+		}
+		if err != nil && isProviderError(err) {
+			snitchStatus.LastError = err.Error()
+			snitches = append(snitches, snitchStatus)
+			// the ClusterDeployments the reconcile didn't get to keep their entries until the next one does
+			for _, unvisited := range candidateClusterDeployments[i+1:] {
+				previous, ok := previousSnitches[types.NamespacedName{Name: unvisited.Name, Namespace: unvisited.Namespace}]
+				if !ok {
+					continue
+				}
+				snitches = append(snitches, previous)
+				if previous.LastError == "" {
+					managed++
 				}
 			}
+			setClusterDeploymentStatus(dmsi, len(matchingClusterDeployments), managed, snitches, err)
+			localmetrics.Collector.SetFailedClusterDeployments(dmsiKey(dmsi), dmsi.Status.FailedClusterDeployments)
+			result, err := apiErrorResult(dmsi, err)
+			if statusErr := r.updateStatus(dmsi, originalStatus); statusErr != nil {
+				reqLogger.Error(statusErr, "Error updating dmsi status")
+			}
+			return result, err
+		}
+		if err != nil {
+			snitchStatus.LastError = err.Error()
+			snitches = append(snitches, snitchStatus)
+			clusterErrs = append(clusterErrs, fmt.Errorf("clusterdeployment %s/%s: %w", clusterdeployment.Namespace, clusterdeployment.Name, err))
+			r.retryClusterDeployment(dmsi, &clusterdeployment, r.clusterFailed(dmsi, &clusterdeployment, err))
 			continue
 		}
+		r.clusterSucceeded(dmsi, &clusterdeployment)
+		if isManaged {
+			snitchStatus.LastError = ""
+			snitches = append(snitches, snitchStatus)
+			managed++
+		}
+	}
 
-		if !clusterdeployment.Spec.Installed {
-			// The cluster isn't installed yet, so don't setup DMS yet either
-			continue
+	reconcileErr := utilerrors.NewAggregate(clusterErrs)
+	setClusterDeploymentStatus(dmsi, len(matchingClusterDeployments), managed, snitches, reconcileErr)
+	localmetrics.Collector.SetFailedClusterDeployments(dmsiKey(dmsi), dmsi.Status.FailedClusterDeployments)
+	err = r.updateStatus(dmsi, originalStatus)
+	if err != nil {
+		reqLogger.Error(err, "Error updating dmsi status")
+		return reconcile.Result{}, err
+	}
+
+	if reconcileErr != nil {
+		// only the failed ClusterDeployments need the retry, they were queued on their own by retryClusterDeployment
+		reqLogger.Info(fmt.Sprintf("%d cluster deployments failed to reconcile, retrying them on their own", len(clusterErrs)))
+	}
+
+	// nothing on the hub changes when a snitch is edited at the provider, so every snitch is verified again on the next resync
+	result := resyncResult(dmsi)
+	reqLogger.Info(fmt.Sprintf("Reconcile of deadmanssnitch integration complete, resyncing after %s", result.RequeueAfter))
+
+	return result, nil
+}
+
+// apiErrorResult decides how a reconcile that failed with err is retried.
+// A throttled reconcile is requeued after the wait the provider asked for, instead of having the work queue retry right away.
+// A rejected API key or a used up quota won't go away by retrying, so they are reported in the conditions of the dmsi
+// and retried after apiErrorRequeueDelay. Other errors are returned for the work queue to retry with backoff.
+func apiErrorResult(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, err error) (reconcile.Result, error) {
+	switch {
+	case apierror.IsRateLimited(err):
+		retryAfter, _ := apierror.RetryAfter(err)
+		log.Info(fmt.Sprintf("Heartbeat provider API throttled, requeueing after %s", retryAfter))
+		return reconcile.Result{Requeue: true, RequeueAfter: retryAfter}, nil
+	case apierror.IsUnauthorized(err):
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionAPIKeyValid, metav1.ConditionFalse, "Unauthorized", err.Error())
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionReady, metav1.ConditionFalse, "Unauthorized", err.Error())
+		return reconcile.Result{RequeueAfter: apiErrorRequeueDelay}, nil
+	case apierror.IsQuotaExceeded(err):
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionDegraded, metav1.ConditionTrue, "QuotaExceeded", err.Error())
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionReady, metav1.ConditionFalse, "QuotaExceeded", err.Error())
+		return reconcile.Result{RequeueAfter: apiErrorRequeueDelay}, nil
+	}
+	return reconcile.Result{}, err
+}
+
+// reconcileClusterDeployment sets up or tears down the DMS resources of a single ClusterDeployment.
+// It returns whether the cluster is left with a managed snitch, and the snitch if DMS was queried.
+// Full resyncs of the dmsi also look for duplicates of snitches found by their token.
+func (r *ReconcileDeadmansSnitchIntegration) reconcileClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment, clusterMatched bool, dmsc heartbeat.Provider, fullResync bool) (bool, *heartbeat.Heartbeat, error) {
+	err := r.migrateClusterDeploymentFinalizer(dmsi, clusterdeployment)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if !clusterMatched || clusterdeployment.DeletionTimestamp != nil {
+		// The cluster does not match the criteria for needing DMS setup
+		if hasFinalizer(dmsi, clusterdeployment) {
+			// The cluster has an existing DMS setup, so remove it
+			err := r.deleteDMSClusterDeployment(dmsi, clusterdeployment, dmsc)
+			if err != nil {
+				return false, nil, err
+			}
 		}
+		return false, nil, nil
+	}
+
+	if !clusterdeployment.Spec.Installed {
+		// The cluster isn't installed yet, so don't setup DMS yet either
+		return false, nil, nil
+	}
+
+	err = r.dmsAddFinalizer(dmsi, clusterdeployment)
+	if err != nil {
+		return false, nil, err
+	}
 
-		err = r.dmsAddFinalizer(dmsi, &clusterdeployment)
+	secretExist, syncSetExist, err := r.snitchResourcesExist(dmsi, clusterdeployment)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Check if the cluster is hibernating
+	specIsHibernating := clusterdeployment.Spec.PowerState == hivev1.HibernatingClusterPowerState
+	if specIsHibernating {
+		if dmsi.Spec.HibernationPolicy == deadmanssnitchv1alpha1.HibernationPolicyPause && secretExist && syncSetExist {
+			// keep the snitch, secret and syncset so the cluster checks in to the same URL once it resumes
+			snitch, err := r.pauseSnitch(dmsi, clusterdeployment, dmsc)
+			return true, snitch, err
+		}
+		if secretExist || syncSetExist {
+			err := r.deleteDMSClusterDeployment(dmsi, clusterdeployment, dmsc)
+			if err != nil {
+				return false, nil, err
+			}
+
+		}
+		return false, nil, nil
+	}
+
+	// If the cluster is a new install or if the cluster is not hibernating
+	// create DMS resources
+	var snitch *heartbeat.Heartbeat
+	if !secretExist || !syncSetExist {
+		snitch, err = r.createSnitch(dmsi, clusterdeployment, dmsc)
 		if err != nil {
-			return reconcile.Result{}, err
+			return false, snitch, err
 		}
 
-		secretExist, syncSetExist, err := r.snitchResourcesExist(dmsi, &clusterdeployment)
+		err = r.createSecret(dmsi, dmsc, *clusterdeployment)
 		if err != nil {
-			return reconcile.Result{}, err
+			return false, snitch, err
 		}
 
-		// Check if the cluster is hibernating
-		specIsHibernating := clusterdeployment.Spec.PowerState == hivev1.HibernatingClusterPowerState
-		if specIsHibernating {
-			if secretExist || syncSetExist {
-				err := r.deleteDMSClusterDeployment(dmsi, &clusterdeployment, dmsc)
-				if err != nil {
-					return reconcile.Result{}, err
-				}
+		err = r.syncSyncSet(dmsi, clusterdeployment)
+		if err != nil {
+			return false, snitch, err
+		}
+	} else {
+		snitch, err = r.updateSnitch(dmsi, clusterdeployment, dmsc, fullResync)
+		if err != nil {
+			return true, snitch, err
+		}
 
-			}
-		} else {
-			// If the cluster is a new install or if the cluster is not hibernating
-			// create DMS resources
-			if !secretExist || !syncSetExist {
-				err = r.createSnitch(dmsi, &clusterdeployment, dmsc)
-				if err != nil {
-					return reconcile.Result{}, err
-				}
+		snitch, err = r.reconcileMaintenance(dmsi, clusterdeployment, dmsc, snitch)
+		if err != nil {
+			return true, snitch, err
+		}
 
-				err = r.createSecret(dmsi, dmsc, clusterdeployment)
-				if err != nil {
-					return reconcile.Result{}, err
-				}
+		// patch the secret and syncset in place when the dmsi or the clusterdeployment changed since they were created
+		err = r.syncSecret(dmsi, clusterdeployment)
+		if err != nil {
+			return true, snitch, err
+		}
 
-				err = r.createSyncset(dmsi, clusterdeployment)
-				if err != nil {
-					return reconcile.Result{}, err
-				}
-			}
+		err = r.syncSyncSet(dmsi, clusterdeployment)
+		if err != nil {
+			return true, snitch, err
+		}
+	}
+
+	return true, snitch, nil
+}
+
+// setCondition sets a status condition on the dmsi for its current generation
+func setCondition(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&dmsi.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: dmsi.Generation,
+	})
+}
+
+// setClusterDeploymentStatus records the outcome of reconciling the matched ClusterDeployments in the dmsi status
+func setClusterDeploymentStatus(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, matched, managed int, snitches []deadmanssnitchv1alpha1.SnitchStatus, reconcileErr error) {
+	dmsi.Status.MatchedClusterDeployments = matched
+	dmsi.Status.ManagedClusterDeployments = managed
+	dmsi.Status.Snitches = snitches
+
+	failed := 0
+	for _, snitchStatus := range snitches {
+		if snitchStatus.LastError != "" {
+			failed++
 		}
 	}
+	dmsi.Status.FailedClusterDeployments = failed
 
-	log.Info("Reconcile of deadmanssnitch integration complete")
+	if reconcileErr != nil {
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionDegraded, metav1.ConditionTrue, "ClusterDeploymentReconcileFailed", reconcileErr.Error())
+		setCondition(dmsi, deadmanssnitchv1alpha1.ConditionReady, metav1.ConditionFalse, "ClusterDeploymentReconcileFailed", reconcileErr.Error())
+		return
+	}
+	setCondition(dmsi, deadmanssnitchv1alpha1.ConditionDegraded, metav1.ConditionFalse, "ReconcileSucceeded", "")
+	setCondition(dmsi, deadmanssnitchv1alpha1.ConditionReady, metav1.ConditionTrue, "ReconcileSucceeded",
+		fmt.Sprintf("%d of %d matched clusterdeployments have a snitch", managed, matched))
+}
 
-	return reconcile.Result{}, nil
+// updateStatus writes the dmsi status if it changed since the start of the reconcile
+func (r *ReconcileDeadmansSnitchIntegration) updateStatus(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, originalStatus *deadmanssnitchv1alpha1.DeadmansSnitchIntegrationStatus) error {
+	dmsi.Status.ObservedGeneration = dmsi.Generation
+	if equality.Semantic.DeepEqual(originalStatus, &dmsi.Status) {
+		return nil
+	}
+	return r.client.Status().Update(context.TODO(), dmsi)
 }
 
-// getMatchingClusterDeployment gets all ClusterDeployments matching the DMSI selector
-func (r *ReconcileDeadmansSnitchIntegration) getMatchingClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) ([]hivev1.ClusterDeployment, error) {
-	selector, err := metav1.LabelSelectorAsSelector(&dmsi.Spec.ClusterDeploymentSelector)
+// reconcileClusterDeploymentRequest reconciles the single ClusterDeployment of a pair request, and replaces its entry in the
+// dmsi status. The matched count is left to the full reconciles of the dmsi.
+func (r *ReconcileDeadmansSnitchIntegration) reconcileClusterDeploymentRequest(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeploymentName types.NamespacedName, dmsc heartbeat.Provider, originalStatus *deadmanssnitchv1alpha1.DeadmansSnitchIntegrationStatus) (reconcile.Result, error) {
+	reqLogger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", clusterDeploymentName.Name, "cluster-deployment.Namespace:", clusterDeploymentName.Namespace)
+
+	clusterdeployment := &hivev1.ClusterDeployment{}
+	err := r.client.Get(context.TODO(), clusterDeploymentName, clusterdeployment)
 	if err != nil {
-		return nil, err
+		if k8errors.IsNotFound(err) {
+			// the ClusterDeployment is gone along with its finalizer, so there is nothing left but its status entry
+			setSnitchStatus(dmsi, clusterDeploymentName, nil)
+			return reconcile.Result{}, r.updateStatus(dmsi, originalStatus)
+		}
+		return reconcile.Result{}, err
 	}
 
-	matchingClusterDeployments := &hivev1.ClusterDeploymentList{}
-	listOpts := &client.ListOptions{LabelSelector: selector}
-	err = r.client.List(context.TODO(), matchingClusterDeployments, listOpts)
+	clusterMatched, err := selectsClusterDeployment(dmsi, clusterdeployment)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
 
-	matchedClusterDeployments := []hivev1.ClusterDeployment{}
+	var previous deadmanssnitchv1alpha1.SnitchStatus
+	for _, snitchStatus := range dmsi.Status.Snitches {
+		if snitchStatus.ClusterDeploymentName == clusterdeployment.Name && snitchStatus.ClusterDeploymentNamespace == clusterdeployment.Namespace {
+			previous = snitchStatus
+		}
+	}
+	snitchStatus := clusterSnitchStatus(dmsi, previous, *clusterdeployment)
 
-	// If the ClusterDeploymentAnnotationsToSkip set in the DMS integration
-	// Check the cluster deployment and skip it if the annotation has the same
-	// key and value
-	if len(dmsi.Spec.ClusterDeploymentAnnotationsToSkip) > 0 {
-		for _, cd := range matchingClusterDeployments.Items {
-			if !r.shouldSkipClusterDeployment(dmsi.Spec.ClusterDeploymentAnnotationsToSkip, cd) {
-				matchedClusterDeployments = append(matchedClusterDeployments, cd)
+	isManaged, snitch, err := r.reconcileClusterDeployment(dmsi, clusterdeployment, clusterMatched, dmsc, false)
+	if snitch != nil {
+		snitchStatus.Token = snitch.Token
+		snitchStatus.Status = snitch.Status
+	}
+	var result reconcile.Result
+	switch {
+	case err != nil && isProviderError(err):
+		snitchStatus.LastError = err.Error()
+		setSnitchStatus(dmsi, clusterDeploymentName, &snitchStatus)
+		result, err = apiErrorResult(dmsi, err)
+	case err != nil:
+		snitchStatus.LastError = err.Error()
+		setSnitchStatus(dmsi, clusterDeploymentName, &snitchStatus)
+		result = reconcile.Result{RequeueAfter: r.clusterFailed(dmsi, clusterdeployment, err)}
+		err = nil
+	case isManaged:
+		r.clusterSucceeded(dmsi, clusterdeployment)
+		snitchStatus.LastError = ""
+		setSnitchStatus(dmsi, clusterDeploymentName, &snitchStatus)
+	default:
+		r.clusterSucceeded(dmsi, clusterdeployment)
+		setSnitchStatus(dmsi, clusterDeploymentName, nil)
+	}
+
+	localmetrics.Collector.SetFailedClusterDeployments(dmsiKey(dmsi), dmsi.Status.FailedClusterDeployments)
+	if statusErr := r.updateStatus(dmsi, originalStatus); statusErr != nil {
+		reqLogger.Error(statusErr, "Error updating dmsi status")
+		if err == nil {
+			return reconcile.Result{}, statusErr
+		}
+	}
+	return result, err
+}
+
+// setSnitchStatus replaces the status entry of the clusterdeployment with snitchStatus, or removes it when snitchStatus is nil,
+// and recounts the managed and failed ClusterDeployments from the entries
+func setSnitchStatus(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeploymentName types.NamespacedName, snitchStatus *deadmanssnitchv1alpha1.SnitchStatus) {
+	snitches := []deadmanssnitchv1alpha1.SnitchStatus{}
+	for _, existing := range dmsi.Status.Snitches {
+		if existing.ClusterDeploymentName == clusterDeploymentName.Name && existing.ClusterDeploymentNamespace == clusterDeploymentName.Namespace {
+			continue
+		}
+		snitches = append(snitches, existing)
+	}
+	if snitchStatus != nil {
+		snitches = append(snitches, *snitchStatus)
+		sort.Slice(snitches, func(i, j int) bool {
+			if snitches[i].ClusterDeploymentNamespace != snitches[j].ClusterDeploymentNamespace {
+				return snitches[i].ClusterDeploymentNamespace < snitches[j].ClusterDeploymentNamespace
 			}
+			return snitches[i].ClusterDeploymentName < snitches[j].ClusterDeploymentName
+		})
+	}
+
+	managed := 0
+	clusterErrs := []error{}
+	for _, existing := range snitches {
+		if existing.LastError != "" {
+			clusterErrs = append(clusterErrs, fmt.Errorf("clusterdeployment %s/%s: %s", existing.ClusterDeploymentNamespace, existing.ClusterDeploymentName, existing.LastError))
+			continue
 		}
-	} else {
-		matchedClusterDeployments = matchingClusterDeployments.Items
+		managed++
 	}
+	setClusterDeploymentStatus(dmsi, dmsi.Status.MatchedClusterDeployments, managed, snitches, utilerrors.NewAggregate(clusterErrs))
+}
 
-	return matchedClusterDeployments, err
+// clusterSnitchStatus returns the status entry of the clusterdeployment, carrying over the previous entry
+// for the fields that need DMS calls
+func clusterSnitchStatus(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, previous deadmanssnitchv1alpha1.SnitchStatus, clusterdeployment hivev1.ClusterDeployment) deadmanssnitchv1alpha1.SnitchStatus {
+	snitchStatus := previous
+	snitchStatus.ClusterDeploymentName = clusterdeployment.Name
+	snitchStatus.ClusterDeploymentNamespace = clusterdeployment.Namespace
+	if snitchName, err := getSnitchName(dmsi, clusterdeployment); err == nil {
+		snitchStatus.Name = snitchName
+	}
+	return snitchStatus
 }
 
-func (r *ReconcileDeadmansSnitchIntegration) shouldSkipClusterDeployment(clusterDeploymentAnnotationsToSkip []deadmanssnitchv1alpha1.ClusterDeploymentAnnotationsToSkip, cd hivev1.ClusterDeployment) bool {
-	for annoKey, annoVal := range cd.GetAnnotations() {
-		for _, skipper := range clusterDeploymentAnnotationsToSkip {
-			if annoKey == skipper.Name && annoVal == skipper.Value {
-				return true
-			}
+// getMatchingClusterDeployments gets all ClusterDeployments matching the DMSI selector
+func (r *ReconcileDeadmansSnitchIntegration) getMatchingClusterDeployments(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) ([]hivev1.ClusterDeployment, error) {
+	selectedClusterDeployments, err := listSelectedClusterDeployments(r.client, &dmsi.Spec.ClusterDeploymentSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the ClusterDeploymentAnnotationsToSkip set in the DMS integration
+	// Check the cluster deployment and skip it if the annotation has the same
+	// key and value
+	matchedClusterDeployments := []hivev1.ClusterDeployment{}
+	for _, cd := range selectedClusterDeployments {
+		if !shouldSkipClusterDeployment(dmsi.Spec.ClusterDeploymentAnnotationsToSkip, &cd) {
+			matchedClusterDeployments = append(matchedClusterDeployments, cd)
 		}
 	}
-	return false
+	return matchedClusterDeployments, nil
 }
 
-// getAllClusterDeployment retrives all ClusterDeployments in the shard
-func (r *ReconcileDeadmansSnitchIntegration) getAllClusterDeployment() (*hivev1.ClusterDeploymentList, error) {
-	matchingClusterDeployments := &hivev1.ClusterDeploymentList{}
-	err := r.client.List(context.TODO(), matchingClusterDeployments, &client.ListOptions{})
-	return matchingClusterDeployments, err
+// getCandidateClusterDeployments returns the matching ClusterDeployments along with those carrying a finalizer of the dmsi,
+// sorted by namespace and name
+func (r *ReconcileDeadmansSnitchIntegration) getCandidateClusterDeployments(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, matchingClusterDeployments []hivev1.ClusterDeployment) ([]hivev1.ClusterDeployment, error) {
+	finalizedClusterDeployments, err := listFinalizedClusterDeployments(r.client, dmsi)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []hivev1.ClusterDeployment{}
+	seen := map[types.UID]bool{}
+	for _, cd := range append(append([]hivev1.ClusterDeployment{}, matchingClusterDeployments...), finalizedClusterDeployments...) {
+		if seen[cd.UID] {
+			continue
+		}
+		seen[cd.UID] = true
+		candidates = append(candidates, cd)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Namespace != candidates[j].Namespace {
+			return candidates[i].Namespace < candidates[j].Namespace
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	return candidates, nil
 }
 
 // Add finalizers to both the deadmanssnitch integration and the matching cluster deployment
 func (r *ReconcileDeadmansSnitchIntegration) dmsAddFinalizer(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment) error {
-	deadMansSnitchFinalizer := DeadMansSnitchFinalizerPrefix + dmsi.Name
+	deadMansSnitchFinalizer := finalizerName(dmsi)
 	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", clusterdeployment.Name, "cluster-deployment.Namespace:", clusterdeployment.Namespace)
 	//checking i finalizers exits in the clusterdeployment adding if they dont
 	logger.Info("Checking for finalizers")
@@ -345,68 +762,365 @@ func (r *ReconcileDeadmansSnitchIntegration) dmsAddFinalizer(dmsi *deadmanssnitc
 }
 
 // create snitch in deadmanssnitch.com with information retrived from dmsi cr as well as the matching cluster deployment
-func (r *ReconcileDeadmansSnitchIntegration) createSnitch(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc dmsclient.Client) error {
+func (r *ReconcileDeadmansSnitchIntegration) createSnitch(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc heartbeat.Provider) (*heartbeat.Heartbeat, error) {
 	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
 
-	clusterID, err := getClusterID(*cd, config.IsFedramp())
+	desiredSnitch, err := getDesiredSnitch(dmsi, *cd)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	snitchName := getSnitchName(*cd, dmsi.Spec.SnitchNamePostFix, config.IsFedramp())
+	snitchName := desiredSnitch.Name
 	ssName := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
 
 	err = r.client.Get(context.TODO(), types.NamespacedName{Name: ssName, Namespace: cd.Namespace}, &hivev1.SyncSet{})
 	if err != nil {
 		if k8errors.IsNotFound(err) {
 			logger.Info(fmt.Sprint("Checking if snitch already exists SnitchName:", snitchName))
-			snitches, err := dmsc.FindSnitchesByName(snitchName)
+			snitches, err := dmsc.FindByName(snitchName)
 			if err != nil {
-				return err
+				return nil, err
+			}
+
+			// only snitches carrying the owner marker of the dmsi and clusterdeployment, or adoptable ones, are used,
+			// unless the secret left from before the syncset went missing points to one
+			pointedToBySecret, err := r.secretSnitchMatcher(dmsi, cd)
+			if err != nil {
+				return nil, err
+			}
+			selected, conflicts := selectSnitch(dmsi, *cd, snitches)
+			for i := range snitches {
+				if pointedToBySecret(snitches[i]) {
+					selected = &snitches[i]
+					conflicts = withoutSnitch(conflicts, snitches[i].Token)
+					break
+				}
 			}
+			r.reportOwnershipConflicts(dmsi, cd, conflicts)
 
-			var snitch dmsclient.Snitch
-			if len(snitches) <= 0 {
-				newSnitch := dmsclient.NewSnitch(snitchName, dmsi.Spec.Tags, "15_minute", "basic")
-				newSnitch.Notes = fmt.Sprintf(`cluster_id: %s\nrunbook: https://github.com/openshift/ops-sop/blob/master/v4/alerts/cluster_has_gone_missing.md`, clusterID)
-				// add escaping since _ is not being recognized otherwise.
-				newSnitch.Notes = "```" + newSnitch.Notes + "```"
+			var snitch heartbeat.Heartbeat
+			if selected == nil {
 				logger.Info(fmt.Sprint("Creating snitch:", snitchName))
-				snitch, err = dmsc.Create(newSnitch)
+				snitch, err = dmsc.Create(desiredSnitch)
 				if err != nil {
-					return err
+					return nil, err
+				}
+			} else {
+				snitch = *selected
+				if getSnitchOwnership(dmsi, *cd, snitch) != snitchOwned {
+					snitch, err = r.adoptSnitch(dmsi, cd, dmsc, snitch)
+					if err != nil {
+						return &snitch, err
+					}
 				}
-			}
-			if len(snitches) > 0 {
-				snitch = snitches[0]
 			}
 
-			ReSnitches, err := dmsc.FindSnitchesByName(snitchName)
+			ReSnitches, err := dmsc.FindByName(snitchName)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			if len(ReSnitches) <= 0 {
+			reSnitch := snitchWithToken(ReSnitches, snitch.Token)
+			if reSnitch == nil {
+				err = fmt.Errorf("Unable to get Snitch %s by name", snitchName)
 				logger.Error(err, "Unable to get Snitch by name")
-				return err
+				return nil, err
 			}
 
-			if ReSnitches[0].Status == "pending" {
+			if reSnitch.Status == heartbeat.StatusPending {
 				logger.Info("Checking in Snitch ...")
 				// CheckIn snitch
 				err = dmsc.CheckIn(snitch)
 				if err != nil {
 					logger.Error(err, "Unable to check in deadman's snitch", "CheckInURL", snitch.CheckInURL)
-					return err
+					return reSnitch, err
 				}
 			}
 
+			logger.Info("Snitch created nothing to do here.... ")
+			return reSnitch, nil
 		}
 	}
 
 	logger.Info("Snitch created nothing to do here.... ")
+	return nil, nil
+}
+
+// updateSnitch corrects drift between an existing snitch and the settings called for by the dmsi and
+// the clusterdeployment. Only the fields that differ are sent to DMS.
+func (r *ReconcileDeadmansSnitchIntegration) updateSnitch(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc heartbeat.Provider, fullResync bool) (*heartbeat.Heartbeat, error) {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
+
+	desiredSnitch, err := getDesiredSnitch(dmsi, *cd)
+	if err != nil {
+		return nil, err
+	}
+	snitchName := desiredSnitch.Name
+	dmsSecret := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+
+	secret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: dmsSecret, Namespace: cd.Namespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	_, paused := secret.Annotations[snitchPausedAnnotation]
+	if paused && !clusterIsRunning(cd) {
+		// a resuming cluster can't check in yet, leave its snitch paused
+		logger.Info(fmt.Sprint("Waiting for the cluster to run before unpausing snitch:", snitchName))
+		return nil, nil
+	}
+
+	snitch, snitches, err := findSnitch(dmsc, secret, snitchName)
+	if err != nil {
+		return nil, err
+	}
+	if snitch == nil {
+		// the snitch was deleted in DMS while the secret and syncset stayed behind
+		logger.Info(fmt.Sprint("Snitch not found in DMS, recreating SnitchName:", snitchName))
+		snitch, err = recreateSnitch(dmsc, desiredSnitch)
+		if err != nil {
+			return snitch, err
+		}
+		r.recorder.Eventf(dmsi, corev1.EventTypeWarning, "SnitchRecreated",
+			"Snitch %s for clusterdeployment %s/%s was missing in DMS and has been recreated", snitchName, cd.Namespace, cd.Name)
+	} else if snitch.Status == heartbeat.StatusPending && !paused && clusterIsRunning(cd) {
+		// a snitch only alerts once it was checked in, so one still pending since its creation is checked in again
+		logger.Info(fmt.Sprint("Checking in pending snitch:", snitchName))
+		err = dmsc.CheckIn(*snitch)
+		if err != nil {
+			return snitch, err
+		}
+	}
+
+	if paused {
+		// a snitch also held paused by maintenance windows is unpaused once the last of them closed
+		heldForMaintenance := len(maintenance.Holders(secret.Annotations)) > 0
+		if !heldForMaintenance {
+			logger.Info(fmt.Sprint("Unpausing snitch of resumed cluster:", snitchName))
+			err = dmsc.Unpause(snitch.Token)
+			if err != nil {
+				return snitch, err
+			}
+		}
+		delete(secret.Annotations, snitchPausedAnnotation)
+		err = r.client.Update(context.TODO(), secret)
+		if err != nil {
+			return snitch, err
+		}
+		if !heldForMaintenance {
+			r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "SnitchUnpaused",
+				"Unpaused snitch %s of resumed clusterdeployment %s/%s", snitchName, cd.Namespace, cd.Name)
+		}
+	}
+
+	if !secretHasCheckIn(secret, *snitch) || !secretHasSnitch(secret, *snitch) {
+		// the syncset pushes the new check-in data to the cluster once the secret changes,
+		// a secret created before its snitch was recorded is backfilled
+		logger.Info(fmt.Sprint("Updating check-in data of secret:", dmsSecret))
+		setSecretCheckIn(secret, *snitch)
+		setSecretSnitch(secret, *snitch)
+		err = r.client.Update(context.TODO(), secret)
+		if err != nil {
+			return snitch, err
+		}
+	}
+
+	if snitches == nil && fullResync {
+		// a snitch looked up by its token doesn't reveal the snitches created next to it later, by hand or by another operator,
+		// so full resyncs look for them by name in the cached listing
+		snitches, err = dmsc.FindByName(snitchName)
+		if err != nil {
+			return snitch, err
+		}
+	}
+	// the snitch the secret points to is canonical, extras left behind by racing creates are collapsed into it
+	// when the snitches named like it were listed
+	err = r.collapseDuplicateSnitches(dmsi, cd, dmsc, *snitch, snitches, snitchName)
+	if err != nil {
+		return snitch, err
+	}
+
+	if !dmsc.CanRename() {
+		// the snitch is still found by its token under the name it was created with
+		desiredSnitch.Name = snitch.Name
+	}
+	patch, drift := snitchDrift(*snitch, desiredSnitch)
+	if len(drift) == 0 {
+		return snitch, nil
+	}
+
+	logger.Info(fmt.Sprint("Correcting drift of snitch:", snitchName), "Fields", drift)
+	updatedSnitch, err := dmsc.Update(patch)
+	if err != nil {
+		return snitch, err
+	}
+
+	for _, field := range drift {
+		localmetrics.Collector.ObserveSnitchDrift(field)
+	}
+	r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "SnitchDriftCorrected",
+		"Updated %s of snitch %s for clusterdeployment %s/%s", strings.Join(drift, ", "), snitchName, cd.Namespace, cd.Name)
+
+	return &updatedSnitch, nil
+}
+
+// pauseSnitch pauses the snitch of a hibernating cluster and marks its secret, so the snitch is unpaused once the cluster runs again
+func (r *ReconcileDeadmansSnitchIntegration) pauseSnitch(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc heartbeat.Provider) (*heartbeat.Heartbeat, error) {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
+	snitchName, err := getSnitchName(dmsi, *cd)
+	if err != nil {
+		return nil, err
+	}
+	dmsSecret := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+
+	secret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: dmsSecret, Namespace: cd.Namespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, paused := secret.Annotations[snitchPausedAnnotation]; paused {
+		return nil, nil
+	}
+
+	snitch, _, err := findSnitch(dmsc, secret, snitchName)
+	if err != nil {
+		return nil, err
+	}
+	// a snitch deleted in DMS meanwhile is recreated when the cluster resumes
+	if snitch != nil && snitch.Status != heartbeat.StatusPaused {
+		logger.Info(fmt.Sprint("Pausing snitch of hibernating cluster:", snitchName))
+		err = dmsc.Pause(snitch.Token)
+		if err != nil {
+			return snitch, err
+		}
+		snitch.Status = heartbeat.StatusPaused
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[snitchPausedAnnotation] = "true"
+	if snitch != nil {
+		setSecretSnitch(secret, *snitch)
+	}
+	err = r.client.Update(context.TODO(), secret)
+	if err != nil {
+		return snitch, err
+	}
+	r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "SnitchPaused",
+		"Paused snitch %s of hibernating clusterdeployment %s/%s", snitchName, cd.Namespace, cd.Name)
+
+	return snitch, nil
+}
+
+// clusterIsRunning reports whether a cluster resuming from hibernation is running again, and able to check in
+func clusterIsRunning(cd *hivev1.ClusterDeployment) bool {
+	if cd.Spec.PowerState == hivev1.HibernatingClusterPowerState {
+		return false
+	}
+	if cd.Status.PowerState != "" {
+		return cd.Status.PowerState == hivev1.RunningReadyReason
+	}
+	// older hive versions only report the hibernating condition
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == hivev1.ClusterHibernatingCondition {
+			return condition.Status == corev1.ConditionFalse
+		}
+	}
+	return true
+}
+
+// recreateSnitch creates a snitch again after it was deleted in DMS and checks it in
+func recreateSnitch(dmsc heartbeat.Provider, desiredSnitch heartbeat.Heartbeat) (*heartbeat.Heartbeat, error) {
+	snitch, err := dmsc.Create(desiredSnitch)
+	if err != nil {
+		return nil, err
+	}
+
+	if snitch.Status == heartbeat.StatusPending {
+		err = dmsc.CheckIn(snitch)
+		if err != nil {
+			return &snitch, err
+		}
+	}
+	return &snitch, nil
+}
+
+// snitchWithToken returns the snitch identified by token, or the first snitch when token is empty
+func snitchWithToken(snitches []heartbeat.Heartbeat, token string) *heartbeat.Heartbeat {
+	for i := range snitches {
+		if token == "" || snitches[i].Token == token {
+			return &snitches[i]
+		}
+	}
+	return nil
+}
+
+// findSnitch looks up the snitch of a cluster in DMS by the token recorded on its secret. Secrets created before
+// the token was recorded fall back to searching the listing of DMS, which is returned along with the snitch.
+func findSnitch(dmsc heartbeat.Provider, secret *corev1.Secret, snitchName string) (*heartbeat.Heartbeat, []heartbeat.Heartbeat, error) {
+	if token := secret.Annotations[snitchTokenAnnotation]; token != "" {
+		snitch, err := dmsc.FindByToken(token)
+		return snitch, nil, err
+	}
+
+	snitches, err := dmsc.ListAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	return findSnitchIn(snitches, snitchName, string(secret.Data[config.KeySnitchURL])), snitches, nil
+}
+
+// findSnitchIn looks up the snitch of a cluster in a listing of DMS. The snitch behind the check-in URL synced to the
+// cluster is preferred over a name match, so snitches renamed in DMS are still found.
+func findSnitchIn(snitches []heartbeat.Heartbeat, snitchName string, checkInURL string) *heartbeat.Heartbeat {
+	if checkInURL != "" {
+		for i := range snitches {
+			if snitches[i].CheckInURL == checkInURL {
+				return &snitches[i]
+			}
+		}
+	}
+	for i := range snitches {
+		if snitches[i].Name == snitchName {
+			return &snitches[i]
+		}
+	}
 	return nil
 }
 
+// snitchDrift compares a snitch read from DMS with the desired snitch. It returns a snitch holding the
+// token and only the fields that differ, along with the names of those fields.
+// An empty list of desired tags leaves the tags of the snitch alone, as an omitted field can't clear them.
+// The alert type is only compared when the provider reports one.
+func snitchDrift(current heartbeat.Heartbeat, desired heartbeat.Heartbeat) (heartbeat.Heartbeat, []string) {
+	patch := heartbeat.Heartbeat{Token: current.Token}
+	drift := []string{}
+
+	if current.Name != desired.Name {
+		patch.Name = desired.Name
+		drift = append(drift, "name")
+	}
+	if len(desired.Tags) > 0 && !sets.NewString(current.Tags...).Equal(sets.NewString(desired.Tags...)) {
+		patch.Tags = desired.Tags
+		drift = append(drift, "tags")
+	}
+	if current.Interval != desired.Interval {
+		patch.Interval = desired.Interval
+		drift = append(drift, "interval")
+	}
+	if current.AlertType != "" && current.AlertType != desired.AlertType {
+		patch.AlertType = desired.AlertType
+		drift = append(drift, "alert_type")
+	}
+	if current.Notes != desired.Notes {
+		patch.Notes = desired.Notes
+		drift = append(drift, "notes")
+	}
+
+	return patch, drift
+}
+
 // snitchResourcesExist checks if the associated cluster resources for a snitch exist
 func (r *ReconcileDeadmansSnitchIntegration) snitchResourcesExist(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment) (bool, bool, error) {
 	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
@@ -434,7 +1148,7 @@ func (r *ReconcileDeadmansSnitchIntegration) snitchResourcesExist(dmsi *deadmans
 }
 
 //Create secret containing the snitch url
-func (r *ReconcileDeadmansSnitchIntegration) createSecret(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, dmsc dmsclient.Client, cd hivev1.ClusterDeployment) error {
+func (r *ReconcileDeadmansSnitchIntegration) createSecret(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, dmsc heartbeat.Provider, cd hivev1.ClusterDeployment) error {
 	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
 	dmsSecret := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
 	logger.Info("Checking if secret already exits")
@@ -449,60 +1163,65 @@ func (r *ReconcileDeadmansSnitchIntegration) createSecret(dmsi *deadmanssnitchv1
 
 	if k8errors.IsNotFound(err) {
 		logger.Info("Secret not found creating secret")
-		snitchName := getSnitchName(cd, dmsi.Spec.SnitchNamePostFix, config.IsFedramp())
-		ReSnitches, err := dmsc.FindSnitchesByName(snitchName)
-
+		snitchName, err := getSnitchName(dmsi, cd)
 		if err != nil {
 			return err
 		}
-		for _, CheckInURL := range ReSnitches {
-
-			newdmsSecret := newDMSSecret(cd.Namespace, dmsSecret, CheckInURL.CheckInURL)
-
-			// set the owner reference about the secret for gabage collection
-			if err := controllerutil.SetControllerReference(&cd, newdmsSecret, r.scheme); err != nil {
-				logger.Error(err, "Error setting controller reference on secret")
-				return err
-			}
-			// Create the secret
-			if err := r.client.Create(context.TODO(), newdmsSecret); err != nil {
-				logger.Error(err, "Failed to create secret")
-				return err
-			}
+		ReSnitches, err := dmsc.FindByName(snitchName)
 
+		if err != nil {
+			return err
 		}
-	}
-	logger.Info("Secret created, nothing to do here...")
-	return nil
-}
-
-//creating the syncset which contain the secret with the snitch url
-func (r *ReconcileDeadmansSnitchIntegration) createSyncset(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment) error {
-	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
-	ssName := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
-	err := r.client.Get(context.TODO(), types.NamespacedName{Name: ssName, Namespace: cd.Namespace}, &hivev1.SyncSet{})
-
-	if k8errors.IsNotFound(err) {
-		logger.Info("SyncSet not found, Creating a new SyncSet")
-
-		newSS := newSyncSet(cd.Namespace, ssName, cd.Name, dmsi)
-		if err := controllerutil.SetControllerReference(&cd, newSS, r.scheme); err != nil {
-			logger.Error(err, "Error setting controller reference on syncset")
+		selected, _ := selectSnitch(dmsi, cd, ReSnitches)
+		if selected == nil {
+			return fmt.Errorf("Unable to find a snitch %s owned by the dmsi", snitchName)
+		}
+		// later reconciles look the snitch up by token, so duplicates are only listed now
+		if err := r.collapseDuplicateSnitches(dmsi, &cd, dmsc, *selected, ReSnitches, snitchName); err != nil {
+			return err
+		}
+		newdmsSecret := newDMSSecret(cd.Namespace, dmsSecret, selected.CheckInURL)
+		newdmsSecret.Labels = resourceLabels(dmsi)
+		setSecretCheckIn(newdmsSecret, *selected)
+		setSecretSnitch(newdmsSecret, *selected)
+
+		// set the owner reference about the secret for gabage collection
+		if err := controllerutil.SetControllerReference(&cd, newdmsSecret, r.scheme); err != nil {
+			logger.Error(err, "Error setting controller reference on secret")
 			return err
 		}
-		if err := r.client.Create(context.TODO(), newSS); err != nil {
-			logger.Error(err, "Error creating syncset")
+		// Create the secret
+		if err := r.client.Create(context.TODO(), newdmsSecret); err != nil {
+			logger.Error(err, "Failed to create secret")
 			return err
 		}
-		logger.Info("Done creating a new SyncSet")
+	}
+	logger.Info("Secret created, nothing to do here...")
+	return nil
+}
 
-	} else {
-		logger.Info("SyncSet Already Present, nothing to do here...")
-		// return directly if the syscset already existed
+// secretHasCheckIn checks if the secret of a cluster holds the check-in data of the snitch
+func secretHasCheckIn(secret *corev1.Secret, snitch heartbeat.Heartbeat) bool {
+	return string(secret.Data[config.KeySnitchURL]) == snitch.CheckInURL &&
+		string(secret.Data[config.KeySnitchAuthType]) == snitch.CheckInAuthType &&
+		string(secret.Data[config.KeySnitchAuthCredentials]) == snitch.CheckInCredentials
+}
 
+// setSecretCheckIn writes the check-in URL of the snitch to the secret of a cluster, along with the
+// Authorization header data for providers that require one to check in
+func setSecretCheckIn(secret *corev1.Secret, snitch heartbeat.Heartbeat) {
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
 	}
-	return nil
+	secret.Data[config.KeySnitchURL] = []byte(snitch.CheckInURL)
 
+	if snitch.CheckInAuthType == "" {
+		delete(secret.Data, config.KeySnitchAuthType)
+		delete(secret.Data, config.KeySnitchAuthCredentials)
+		return
+	}
+	secret.Data[config.KeySnitchAuthType] = []byte(snitch.CheckInAuthType)
+	secret.Data[config.KeySnitchAuthCredentials] = []byte(snitch.CheckInCredentials)
 }
 
 func newDMSSecret(namespace string, name string, snitchURL string) *corev1.Secret {
@@ -532,6 +1251,7 @@ func newSyncSet(namespace string, dmsSecret string, clusterDeploymentName string
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      dmsSecret,
 			Namespace: namespace,
+			Labels:    resourceLabels(dmsi),
 		},
 		Spec: hivev1.SyncSetSpec{
 			ClusterDeploymentRefs: []corev1.LocalObjectReference{
@@ -562,30 +1282,39 @@ func newSyncSet(namespace string, dmsSecret string, clusterDeploymentName string
 }
 
 // delete snitches,secrets and syncset associated with the cluster deployment that has been deleted
-func (r *ReconcileDeadmansSnitchIntegration) deleteDMSClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeployment *hivev1.ClusterDeployment, dmsc dmsclient.Client) error {
-	deadMansSnitchFinalizer := DeadMansSnitchFinalizerPrefix + dmsi.Name
+func (r *ReconcileDeadmansSnitchIntegration) deleteDMSClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeployment *hivev1.ClusterDeployment, dmsc heartbeat.Provider) error {
 	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", clusterDeployment.Name, "cluster-deployment.Namespace:", clusterDeployment.Namespace)
 
 	// Delete the dms
 	logger.Info("Deleting the DMS from api.deadmanssnitch.com")
-	snitchName := getSnitchName(*clusterDeployment, dmsi.Spec.SnitchNamePostFix, config.IsFedramp())
-	snitches, err := dmsc.FindSnitchesByName(snitchName)
+	snitches, err := r.recordedSnitches(dmsi, clusterDeployment, dmsc)
 	if err != nil {
 		return err
 	}
 	for _, s := range snitches {
-		delStatus, err := dmsc.Delete(s.Token)
-		if !delStatus || err != nil {
+		err := dmsc.Delete(s.Token)
+		if apierror.IsNotFound(err) {
+			logger.Info("DMS already deleted from api.deadmanssnitch.com")
+			continue
+		}
+		if err != nil {
 			logger.Error(err, "Failed to delete the DMS from api.deadmanssnitch.com")
 			return err
 		}
 		logger.Info("Deleted the DMS from api.deadmanssnitch.com")
 	}
 
+	return r.deleteSnitchResources(dmsi, clusterDeployment)
+}
+
+// deleteSnitchResources deletes the syncset and secret of the clusterdeployment, and the finalizer of the dmsi on it
+func (r *ReconcileDeadmansSnitchIntegration) deleteSnitchResources(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeployment *hivev1.ClusterDeployment) error {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", clusterDeployment.Name, "cluster-deployment.Namespace:", clusterDeployment.Namespace)
+
 	// Delete the SyncSet
 	logger.Info("Deleting DMS SyncSet")
 	dmsSecret := utils.SecretName(clusterDeployment.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
-	err = utils.DeleteSyncSet(dmsSecret, clusterDeployment.Namespace, r.client)
+	err := utils.DeleteSyncSet(dmsSecret, clusterDeployment.Namespace, r.client)
 	if err != nil {
 		logger.Error(err, "Error deleting SyncSet")
 		return err
@@ -599,11 +1328,9 @@ func (r *ReconcileDeadmansSnitchIntegration) deleteDMSClusterDeployment(dmsi *de
 		return err
 	}
 
-	if utils.HasFinalizer(clusterDeployment, deadMansSnitchFinalizer) {
+	if hasFinalizer(dmsi, clusterDeployment) {
 		logger.Info("Deleting DMSI finalizer from cluster deployment")
-		baseToPatch := client.MergeFrom(clusterDeployment.DeepCopy())
-		utils.DeleteFinalizer(clusterDeployment, deadMansSnitchFinalizer)
-		if err := r.client.Patch(context.TODO(), clusterDeployment, baseToPatch); err != nil {
+		if err := r.removeClusterDeploymentFinalizer(dmsi, clusterDeployment); err != nil {
 			logger.Error(err, "Error deleting Finalizer from cluster deployment")
 			return err
 		}
@@ -613,6 +1340,162 @@ func (r *ReconcileDeadmansSnitchIntegration) deleteDMSClusterDeployment(dmsi *de
 
 }
 
+// heartbeatProvider returns a client for the heartbeat provider selected by the dmsi
+func (r *ReconcileDeadmansSnitchIntegration) heartbeatProvider(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, apiKey string) (heartbeat.Provider, error) {
+	opts, err := clientOptions(dmsi, config.GetHTTPClientConfig())
+	if err != nil {
+		return nil, err
+	}
+	// the clients of every provider share the transport built for the same settings
+	httpClient, err := dmsclient.SharedHTTPClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dmsi.Spec.Provider {
+	case "", deadmanssnitchv1alpha1.ProviderDeadMansSnitch:
+		baseURL := dmsi.Spec.BaseURL
+		if baseURL == "" {
+			baseURL = config.GetHTTPClientConfig().DMSBaseURL
+		}
+		if baseURL != "" {
+			opts = append(opts, dmsclient.WithBaseURL(baseURL))
+		}
+		// share the snitch listings across reconciles and dmsis using the same API key at the same endpoint
+		opts = append(opts, dmsclient.WithSnitchCache(dmsclient.SharedSnitchCache(apiKey, baseURL, dmsclient.DefaultCacheTTL)))
+		opts = append(opts, dmsclient.WithHTTPClient(httpClient))
+		dmsc, err := r.dmsclient(apiKey, localmetrics.Collector, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return heartbeat.NewDMSProvider(dmsc), nil
+	}
+
+	switch dmsi.Spec.Provider {
+	case deadmanssnitchv1alpha1.ProviderHealthchecks:
+		hcc, err := r.healthchecksclient(apiKey, dmsi.Spec.BaseURL, httpClient, localmetrics.Collector)
+		if err != nil {
+			return nil, err
+		}
+		return heartbeat.NewHealthchecksProvider(hcc), nil
+	case deadmanssnitchv1alpha1.ProviderOpsgenie:
+		ogc, err := r.opsgenieclient(apiKey, dmsi.Spec.BaseURL, httpClient, localmetrics.Collector)
+		if err != nil {
+			return nil, err
+		}
+		pingAPIKey, err := r.opsgeniePingAPIKey(dmsi, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		return heartbeat.NewOpsgenieProvider(ogc, pingAPIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown heartbeat provider %q", dmsi.Spec.Provider)
+	}
+}
+
+// opsgeniePingAPIKey returns the key the clusters ping Opsgenie with. Clusters ping with the dedicated key of the API key
+// secret when there is one, so the API key stays on the hub. Only a secret without the dedicated key falls back to the API key,
+// which is reported as it ends up in the secret of every cluster.
+func (r *ReconcileDeadmansSnitchIntegration) opsgeniePingAPIKey(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, apiKey string) (string, error) {
+	secret := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: dmsi.Spec.DmsAPIKeySecretRef.Name, Namespace: dmsi.Spec.DmsAPIKeySecretRef.Namespace}, secret)
+	if err != nil && !k8errors.IsNotFound(err) {
+		return "", err
+	}
+	if pingAPIKey := secret.Data[opsgeniePingAPISecretKey]; len(pingAPIKey) > 0 {
+		return string(pingAPIKey), nil
+	}
+
+	log.Info(fmt.Sprintf("No %s in the API key secret, the clusters ping Opsgenie with the API key", opsgeniePingAPISecretKey),
+		"DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name)
+	r.recorder.Eventf(dmsi, corev1.EventTypeWarning, "OpsgenieAPIKeyShared",
+		"Secret %s/%s has no %s, the Opsgenie API key is synced to the clusters to ping their heartbeats",
+		dmsi.Spec.DmsAPIKeySecretRef.Namespace, dmsi.Spec.DmsAPIKeySecretRef.Name, opsgeniePingAPISecretKey)
+	return apiKey, nil
+}
+
+// clientOptions merges the operator level settings of the heartbeat provider clients with the ones of the dmsi.
+// The CA bundles of both are trusted.
+func clientOptions(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, operatorConfig config.HTTPClientConfig) ([]dmsclient.Option, error) {
+	opts := []dmsclient.Option{}
+	timeout := operatorConfig.Timeout
+	tlsMinVersion := operatorConfig.TLSMinVersion
+	caBundle := []byte{}
+
+	if operatorConfig.CABundleFile != "" {
+		fileBundle, err := readCABundleFile(operatorConfig.CABundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA bundle: %v", err)
+		}
+		caBundle = append(caBundle, fileBundle...)
+	}
+
+	if httpClient := dmsi.Spec.HTTPClient; httpClient != nil {
+		if httpClient.Timeout != nil {
+			timeout = httpClient.Timeout.Duration
+		}
+		if httpClient.ProxyURL != "" {
+			opts = append(opts, dmsclient.WithProxy(httpClient.ProxyURL))
+		}
+		if httpClient.CABundle != "" {
+			caBundle = append(caBundle, '\n')
+			caBundle = append(caBundle, httpClient.CABundle...)
+		}
+		if httpClient.TLSMinVersion != "" {
+			tlsMinVersion = httpClient.TLSMinVersion
+		}
+	}
+
+	if timeout != 0 {
+		opts = append(opts, dmsclient.WithTimeout(timeout))
+	}
+	if len(caBundle) > 0 {
+		opts = append(opts, dmsclient.WithCABundle(caBundle))
+	}
+	version, err := dmsclient.ParseTLSVersion(tlsMinVersion)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 {
+		opts = append(opts, dmsclient.WithTLSMinVersion(version))
+	}
+
+	return opts, nil
+}
+
+// caBundleFile holds the operator level CA bundle as last read
+var caBundleFile struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+// readCABundleFile returns the CA bundle at path, only reading it again once the file changed,
+// so a rotated bundle is picked up without reading it on every reconcile
+func readCABundleFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	caBundleFile.Lock()
+	defer caBundleFile.Unlock()
+	if caBundleFile.path == path && caBundleFile.modTime.Equal(info.ModTime()) && caBundleFile.size == info.Size() {
+		return caBundleFile.data, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	caBundleFile.path = path
+	caBundleFile.modTime = info.ModTime()
+	caBundleFile.size = info.Size()
+	caBundleFile.data = data
+	return data, nil
+}
+
 // getClusterID determines if fedramp or not
 // Returns internal clusterID for fedramp and external clusterID if not
 func getClusterID(cd hivev1.ClusterDeployment, isFedramp bool) (string, error) {
@@ -628,9 +1511,94 @@ func getClusterID(cd hivev1.ClusterDeployment, isFedramp bool) (string, error) {
 	return clusterID, nil
 }
 
-// getSnitchName determines if fedramp or not
+// getSnitchName renders the snitchNameTemplate of the dmsi for cd, or returns the default snitch name without a template
+func getSnitchName(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment) (string, error) {
+	if dmsi.Spec.SnitchNameTemplate == "" {
+		return defaultSnitchName(cd, dmsi.Spec.SnitchNamePostFix, config.IsFedramp()), nil
+	}
+	snitchName, err := snitchtemplate.Render(dmsi.Spec.SnitchNameTemplate, snitchTemplateData(dmsi, cd))
+	if err != nil {
+		return "", fmt.Errorf("Unable to render snitchNameTemplate: %w", err)
+	}
+	if snitchName == "" {
+		return "", fmt.Errorf("snitchNameTemplate rendered an empty snitch name for clusterdeployment %s/%s", cd.Namespace, cd.Name)
+	}
+	return snitchName, nil
+}
+
+// getDesiredSnitch returns the snitch the dmsi calls for cd, with its name, tags and notes rendered from the templates of the dmsi
+func getDesiredSnitch(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment) (heartbeat.Heartbeat, error) {
+	snitchName, err := getSnitchName(dmsi, cd)
+	if err != nil {
+		return heartbeat.Heartbeat{}, err
+	}
+	data := snitchTemplateData(dmsi, cd)
+	tags, err := snitchtemplate.RenderTags(dmsi.Spec.Tags, data)
+	if err != nil {
+		return heartbeat.Heartbeat{}, fmt.Errorf("Unable to render tags: %w", err)
+	}
+
+	tags = append(tags, ownerMarker(dmsi, cd))
+
+	desired := heartbeat.NewHeartbeat(snitchName, tags, snitchInterval(dmsi), snitchAlertType(dmsi))
+	if dmsi.Spec.NotesTemplate == "" {
+		clusterID, err := getClusterID(cd, config.IsFedramp())
+		if err != nil {
+			return heartbeat.Heartbeat{}, err
+		}
+		desired.Notes = snitchNotes(clusterID)
+		return desired, nil
+	}
+	desired.Notes, err = snitchtemplate.Render(dmsi.Spec.NotesTemplate, data)
+	if err != nil {
+		return heartbeat.Heartbeat{}, fmt.Errorf("Unable to render notesTemplate: %w", err)
+	}
+	return desired, nil
+}
+
+// snitchTemplateData returns what the snitch name, notes and tags templates of the dmsi can read about cd
+func snitchTemplateData(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment) snitchtemplate.Data {
+	data := snitchtemplate.Data{
+		Name:              cd.Name,
+		Namespace:         cd.Namespace,
+		ClusterName:       cd.Spec.ClusterName,
+		BaseDomain:        cd.Spec.BaseDomain,
+		InternalClusterID: getInternalClusterID(cd),
+		Region:            cd.Labels[hivev1.HiveClusterRegionLabel],
+		Platform:          cd.Labels[hivev1.HiveClusterPlatformLabel],
+		SnitchNamePostFix: dmsi.Spec.SnitchNamePostFix,
+		Labels:            cd.Labels,
+		Annotations:       cd.Annotations,
+	}
+	if cd.Spec.ClusterMetadata != nil {
+		data.ClusterID = cd.Spec.ClusterMetadata.ClusterID
+		data.InfraID = cd.Spec.ClusterMetadata.InfraID
+	}
+
+	// fall back to the platform of the spec for clusterdeployments hive didn't label
+	platform := cd.Spec.Platform
+	switch {
+	case platform.AWS != nil:
+		data.Platform, data.Region = orDefault(data.Platform, "aws"), orDefault(data.Region, platform.AWS.Region)
+	case platform.GCP != nil:
+		data.Platform, data.Region = orDefault(data.Platform, "gcp"), orDefault(data.Region, platform.GCP.Region)
+	case platform.Azure != nil:
+		data.Platform, data.Region = orDefault(data.Platform, "azure"), orDefault(data.Region, platform.Azure.Region)
+	}
+	return data
+}
+
+// orDefault returns value, or def if value is empty
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// defaultSnitchName determines if fedramp or not
 // Returns internal clusterID for fedramp and "(cd.Spec.ClusterName).(cd.Spec.BaseDomain)" if not
-func getSnitchName(cd hivev1.ClusterDeployment, optionalPostFix string, isFedramp bool) string {
+func defaultSnitchName(cd hivev1.ClusterDeployment, optionalPostFix string, isFedramp bool) string {
 	snitchName := cd.Spec.ClusterName + "." + cd.Spec.BaseDomain
 	if optionalPostFix != "" {
 		snitchName += "-" + optionalPostFix
@@ -642,6 +1610,29 @@ func getSnitchName(cd hivev1.ClusterDeployment, optionalPostFix string, isFedram
 	return snitchName
 }
 
+// snitchNotes returns the notes set on the snitch of a cluster
+func snitchNotes(clusterID string) string {
+	notes := fmt.Sprintf(`cluster_id: %s\nrunbook: https://github.com/openshift/ops-sop/blob/master/v4/alerts/cluster_has_gone_missing.md`, clusterID)
+	// add escaping since _ is not being recognized otherwise.
+	return "```" + notes + "```"
+}
+
+// snitchInterval returns the snitch interval set in the dmsi, or the default interval
+func snitchInterval(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) string {
+	if dmsi.Spec.Interval == "" {
+		return deadmanssnitchv1alpha1.DefaultInterval
+	}
+	return dmsi.Spec.Interval
+}
+
+// snitchAlertType returns the snitch alert type set in the dmsi, or the default alert type
+func snitchAlertType(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) string {
+	if dmsi.Spec.AlertType == "" {
+		return deadmanssnitchv1alpha1.DefaultAlertType
+	}
+	return dmsi.Spec.AlertType
+}
+
 func getInternalClusterID(cd hivev1.ClusterDeployment) string {
 	cns := strings.Split(cd.Namespace, "-")
 	return cns[len(cns)-1]
@@ -3,9 +3,14 @@ package deadmanssnitchintegration
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 
 	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
 	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
@@ -18,6 +23,10 @@ import (
 	"github.com/openshift/deadmanssnitch-operator/config"
 	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
 	mockdms "github.com/openshift/deadmanssnitch-operator/pkg/dmsclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/healthchecksclient"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/opsgenieclient"
+	mockog "github.com/openshift/deadmanssnitch-operator/pkg/opsgenieclient/mock"
 
 	hiveapis "github.com/openshift/hive/apis"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
@@ -25,7 +34,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"k8s.io/apimachinery/pkg/types"
@@ -36,6 +48,7 @@ const (
 	testClusterName                   = "testClusterName"
 	testNamespace                     = "testNamespace"
 	testSnitchURL                     = "https://deadmanssnitch.com/12345"
+	testRecreatedSnitchURL            = "https://deadmanssnitch.com/67890"
 	testSnitchToken                   = "abcdefg"
 	testTag                           = "test"
 	testAPIKey                        = "abc123"
@@ -43,7 +56,7 @@ const (
 	testUID                           = "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
 	snitchNamePostFix                 = "test-postfix"
 	deadMansSnitchTagKey              = "testTag"
-	deadMansSnitchFinalizer           = DeadMansSnitchFinalizerPrefix + testDeadMansSnitchintegrationName
+	legacyDeadMansSnitchFinalizer     = DeadMansSnitchFinalizerPrefix + testDeadMansSnitchintegrationName
 	deadMansSnitchOperatorNamespace   = "deadmanssnitch-operator"
 	deadMansSnitchAPISecretName       = "deadmanssnitch-api-key"
 	testFakeClusterKey                = "hive.openshift.io/fake-cluster"
@@ -51,6 +64,8 @@ const (
 	testExternalID                    = "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
 )
 
+var deadMansSnitchFinalizer = finalizerName(testDeadMansSnitchIntegration())
+
 type SyncSetEntry struct {
 	name                     string
 	referencedSecretName     string
@@ -77,6 +92,7 @@ func setupDefaultMocks(t *testing.T, localObjects []runtime.Object) *mocks {
 	}
 
 	mocks.mockDMSClient = mockdms.NewMockClient(mocks.mockCtrl)
+	localmetrics.Collector = localmetrics.NewMetricsCollector()
 
 	return mocks
 }
@@ -225,6 +241,10 @@ func testDeadMansSnitchIntegration() *deadmanssnitchv1alpha1.DeadmansSnitchInteg
 			Tags:              []string{testTag},
 			SnitchNamePostFix: snitchNamePostFix,
 		},
+		// the API key of testSecret was validated by an earlier reconcile
+		Status: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationStatus{
+			APIKeyHash: apiKeyHash(testAPIKey),
+		},
 	}
 
 }
@@ -260,6 +280,9 @@ func testDeadMansSnitchIntegrationWithSkips() *deadmanssnitchv1alpha1.DeadmansSn
 				},
 			},
 		},
+		Status: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationStatus{
+			APIKeyHash: apiKeyHash(testAPIKey),
+		},
 	}
 
 }
@@ -284,6 +307,83 @@ func testDeadMansSnitchIntegrationEmptyPostfix() *deadmanssnitchv1alpha1.Deadman
 			},
 			Tags: []string{testTag},
 		},
+		Status: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationStatus{
+			APIKeyHash: apiKeyHash(testAPIKey),
+		},
+	}
+}
+
+func testDeadMansSnitchIntegrationHourlySmart() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Spec.Interval = "hourly"
+	dmsi.Spec.AlertType = "smart"
+
+	return dmsi
+}
+
+func testDeadMansSnitchIntegrationTemplated() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Spec.SnitchNameTemplate = "{{.ClusterName}}-{{.Region}}"
+	dmsi.Spec.NotesTemplate = "cluster {{.ClusterID}} on {{.Platform}}"
+	dmsi.Spec.Tags = []string{testTag, `{{index .Labels "api.openshift.com/managed" | printf "managed-%s"}}`, `{{index .Labels "missing"}}`}
+
+	return dmsi
+}
+
+// return a ClusterDeployment labelled by hive with its region and platform
+func testLabelledClusterDeployment() *hivev1.ClusterDeployment {
+	cd := testClusterDeployment()
+	cd.Labels[hivev1.HiveClusterRegionLabel] = "us-east-1"
+	cd.Labels[hivev1.HiveClusterPlatformLabel] = "aws"
+
+	return cd
+}
+
+func testDeadMansSnitchIntegrationPauseOnHibernation() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Spec.HibernationPolicy = deadmanssnitchv1alpha1.HibernationPolicyPause
+
+	return dmsi
+}
+
+// return the secret and syncset of testClusterDeployment after its snitch was paused for hibernation
+func testPausedSnitchResources() []runtime.Object {
+	resources := testExistingSnitchResources()
+	secret := resources[0].(*corev1.Secret)
+	secret.Annotations = map[string]string{snitchPausedAnnotation: "true"}
+	return resources
+}
+
+// return the secret and syncset the operator creates for testClusterDeployment
+func testExistingSnitchResources() []runtime.Object {
+	name := testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix
+	return []runtime.Object{
+		newDMSSecret(testNamespace, name, testSnitchURL),
+		newSyncSet(testNamespace, name, testClusterName, testDeadMansSnitchIntegration()),
+	}
+}
+
+// return the owner marker of the snitches of testDeadMansSnitchIntegration and testClusterDeployment
+func testOwnerMarker() string {
+	return ownerMarker(testDeadMansSnitchIntegration(), *testClusterDeployment())
+}
+
+// return the tags of a snitch in sync with testDeadMansSnitchIntegration and testClusterDeployment
+func testSnitchTags() []string {
+	return []string{testTag, testOwnerMarker()}
+}
+
+// return a snitch in DMS that is in sync with testDeadMansSnitchIntegration and testClusterDeployment
+func testLiveSnitch(name string) dmsclient.Snitch {
+	return dmsclient.Snitch{
+		Name:       name,
+		Token:      testSnitchToken,
+		CheckInURL: testSnitchURL,
+		Status:     "healthy",
+		Tags:       testSnitchTags(),
+		Notes:      snitchNotes(testExternalID),
+		Type:       &dmsclient.SnitchType{Interval: deadmanssnitchv1alpha1.DefaultInterval},
+		AlertType:  deadmanssnitchv1alpha1.DefaultAlertType,
 	}
 }
 
@@ -320,6 +420,14 @@ func hibernatingClusterDeployment() *hivev1.ClusterDeployment {
 	return cd
 }
 
+// return a ClusterDeployment resuming from hibernation that isn't running yet
+func resumingClusterDeployment() *hivev1.ClusterDeployment {
+	cd := testClusterDeployment()
+	cd.Status.PowerState = "Resuming"
+
+	return cd
+}
+
 // return a ClusterDeployment with Label["managed"] == false
 func nonManagedClusterDeployment() *hivev1.ClusterDeployment {
 	cd := testClusterDeployment()
@@ -384,10 +492,15 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{}, nil).Times(1)
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
 					{
+						Token:      testSnitchToken,
 						CheckInURL: testSnitchURL,
 						Status:     "pending",
+						Tags:       testSnitchTags(),
+						Type:       &dmsclient.SnitchType{Interval: deadmanssnitchv1alpha1.DefaultInterval},
+						AlertType:  deadmanssnitchv1alpha1.DefaultAlertType,
 					},
-				}, nil).Times(2)
+				}, nil).Times(4)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(2)
 				r.CheckIn(gomock.Any()).Return(nil).Times(1)
 				r.Update(gomock.Any()).Times(0)
 				r.Delete(gomock.Any()).Times(0)
@@ -417,15 +530,221 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{}, nil).Times(1)
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
 					{
+						Token:      testSnitchToken,
 						CheckInURL: testSnitchURL,
 						Status:     "pending",
+						Tags:       testSnitchTags(),
+						Type:       &dmsclient.SnitchType{Interval: deadmanssnitchv1alpha1.DefaultInterval},
+						AlertType:  deadmanssnitchv1alpha1.DefaultAlertType,
 					},
-				}, nil).Times(2)
+				}, nil).Times(4)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(2)
+				r.CheckIn(gomock.Any()).Return(nil).Times(1)
+				r.Update(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Test Updating interval and alert type",
+			localObjects: append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegrationHourlySmart(),
+			}, testExistingSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{
+					testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix),
+				}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(2)
+				r.FindSnitchesByName(testClusterName + ".base.domain-" + snitchNamePostFix).Return([]dmsclient.Snitch{testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)}, nil).Times(2)
+				r.Update(dmsclient.Snitch{
+					Token:     testSnitchToken,
+					Interval:  "hourly",
+					AlertType: "smart",
+				}).Return(dmsclient.Snitch{Token: testSnitchToken}, nil).Times(3)
+				r.Create(gomock.Any()).Times(0)
+				r.CheckIn(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Test Updating snitch to templated name, notes and tags",
+			localObjects: append([]runtime.Object{
+				testLabelledClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegrationTemplated(),
+			}, testExistingSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{
+					testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix),
+				}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(2)
+				r.FindSnitchesByName(testClusterName + "-us-east-1").Return([]dmsclient.Snitch{testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)}, nil).Times(2)
+				r.Update(dmsclient.Snitch{
+					Token: testSnitchToken,
+					Name:  testClusterName + "-us-east-1",
+					Tags:  []string{testTag, "managed-true", testOwnerMarker()},
+					Notes: "cluster " + testExternalID + " on aws",
+				}).Return(dmsclient.Snitch{Token: testSnitchToken}, nil).Times(3)
+				r.Create(gomock.Any()).Times(0)
+				r.CheckIn(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Test Recreating snitch deleted in DMS",
+			localObjects: append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegration(),
+			}, testExistingSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testRecreatedSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				recreatedSnitch := testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)
+				recreatedSnitch.CheckInURL = testRecreatedSnitchURL
+				pendingSnitch := recreatedSnitch
+				pendingSnitch.Status = "pending"
+				r.ListAll().Return([]dmsclient.Snitch{}, nil).Times(1)
+				r.List(testSnitchToken).Return(recreatedSnitch, nil).Times(2)
+				r.FindSnitchesByName(testClusterName + ".base.domain-" + snitchNamePostFix).Return([]dmsclient.Snitch{recreatedSnitch}, nil).Times(3)
+				r.Create(gomock.Any()).Return(pendingSnitch, nil).Times(1)
 				r.CheckIn(gomock.Any()).Return(nil).Times(1)
 				r.Update(gomock.Any()).Times(0)
 				r.Delete(gomock.Any()).Times(0)
 			},
 		},
+		{
+			name: "Test Checking in snitch left pending",
+			localObjects: append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegration(),
+			}, testExistingSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				pendingSnitch := testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)
+				pendingSnitch.Status = "pending"
+				r.ListAll().Return([]dmsclient.Snitch{pendingSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(2)
+				r.FindSnitchesByName(testClusterName + ".base.domain-" + snitchNamePostFix).Return([]dmsclient.Snitch{testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)}, nil).Times(2)
+				r.CheckIn(gomock.Any()).Return(nil).Times(1)
+				r.Create(gomock.Any()).Times(0)
+				r.Update(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Test Updating check-in URL of snitch found by name",
+			localObjects: append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegration(),
+			}, testExistingSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testRecreatedSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				namedSnitch := testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)
+				namedSnitch.CheckInURL = testRecreatedSnitchURL
+				r.ListAll().Return([]dmsclient.Snitch{namedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(namedSnitch, nil).Times(2)
+				r.FindSnitchesByName(testClusterName + ".base.domain-" + snitchNamePostFix).Return([]dmsclient.Snitch{namedSnitch}, nil).Times(2)
+				r.Create(gomock.Any()).Times(0)
+				r.CheckIn(gomock.Any()).Times(0)
+				r.Update(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Test Correcting renamed snitch",
+			localObjects: append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegration(),
+			}, testExistingSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				renamedSnitch := testLiveSnitch("renamed-by-hand")
+				renamedSnitch.Tags = []string{"other"}
+				r.ListAll().Return([]dmsclient.Snitch{renamedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(renamedSnitch, nil).Times(2)
+				r.FindSnitchesByName(testClusterName + ".base.domain-" + snitchNamePostFix).Return([]dmsclient.Snitch{}, nil).Times(2)
+				r.Update(dmsclient.Snitch{
+					Token: testSnitchToken,
+					Name:  testClusterName + ".base.domain-" + snitchNamePostFix,
+					Tags:  testSnitchTags(),
+				}).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(3)
+				r.Create(gomock.Any()).Times(0)
+				r.CheckIn(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
 		{
 			name: "Test Deleting",
 			localObjects: []runtime.Object{
@@ -441,7 +760,7 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.Create(gomock.Any()).Times(0)
 				r.Delete(gomock.Any()).Return(true, nil).Times(1)
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
-					{Token: testSnitchToken},
+					{Token: testSnitchToken, Tags: testSnitchTags()},
 				}, nil).Times(1)
 				r.Update(gomock.Any()).Times(0)
 				r.CheckIn(gomock.Any()).Times(0)
@@ -489,7 +808,7 @@ func TestReconcileClusterDeployment(t *testing.T) {
 			verifySecret:   verifyNoSecret,
 			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
-					{Token: testSnitchToken},
+					{Token: testSnitchToken, Tags: testSnitchTags()},
 				}, nil).Times(1)
 				r.Delete(gomock.Any()).Return(true, nil).Times(1)
 
@@ -499,6 +818,92 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.Delete(gomock.Any()).Times(0)
 			},
 		},
+		{
+			name: "Test Pausing snitch on Hibernation",
+			localObjects: append([]runtime.Object{
+				hibernatingClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegrationPauseOnHibernation(),
+			}, testExistingSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)}, nil).Times(1)
+				r.Pause(testSnitchToken).Return(nil).Times(1)
+				r.Unpause(gomock.Any()).Times(0)
+				r.Create(gomock.Any()).Times(0)
+				r.Update(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Test Keeping snitch paused while cluster resumes",
+			localObjects: append([]runtime.Object{
+				resumingClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegrationPauseOnHibernation(),
+			}, testPausedSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Times(0)
+				r.Unpause(gomock.Any()).Times(0)
+				r.Create(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "Test Unpausing snitch of resumed cluster",
+			localObjects: append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegrationPauseOnHibernation(),
+			}, testPausedSnitchResources()...),
+			expectedSyncSets: &SyncSetEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				referencedSecretName:     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				clusterDeploymentRefName: testClusterName,
+			},
+			expectedSecret: &SecretEntry{
+				name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				snitchURL:                testSnitchURL,
+				clusterDeploymentRefName: testClusterName,
+			},
+			verifySyncSets: verifySyncSetExists,
+			verifySecret:   verifySecretExists,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				pausedSnitch := testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)
+				pausedSnitch.Status = "paused"
+				r.ListAll().Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(2)
+				r.FindSnitchesByName(testClusterName + ".base.domain-" + snitchNamePostFix).Return([]dmsclient.Snitch{testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)}, nil).Times(2)
+				r.Unpause(testSnitchToken).Return(nil).Times(1)
+				r.Pause(gomock.Any()).Times(0)
+				r.Create(gomock.Any()).Times(0)
+				r.Update(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
 		{
 			name: "Test Non managed ClusterDeployment",
 			localObjects: []runtime.Object{
@@ -533,7 +938,7 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.Create(gomock.Any()).Times(0)
 				r.Delete(gomock.Any()).Return(true, nil).Times(1)
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
-					{Token: testSnitchToken},
+					{Token: testSnitchToken, Tags: testSnitchTags()},
 				}, nil).Times(1)
 				r.Update(gomock.Any()).Times(0)
 				r.CheckIn(gomock.Any()).Times(0)
@@ -554,7 +959,7 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.Create(gomock.Any()).Times(0)
 				r.Delete(gomock.Any()).Return(true, nil).Times(1)
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
-					{Token: testSnitchToken},
+					{Token: testSnitchToken, Tags: testSnitchTags()},
 				}, nil).Times(1)
 				r.Update(gomock.Any()).Times(0)
 				r.CheckIn(gomock.Any()).Times(0)
@@ -584,10 +989,15 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{}, nil).Times(1)
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
 					{
+						Token:      testSnitchToken,
 						CheckInURL: testSnitchURL,
 						Status:     "pending",
+						Tags:       testSnitchTags(),
+						Type:       &dmsclient.SnitchType{Interval: deadmanssnitchv1alpha1.DefaultInterval},
+						AlertType:  deadmanssnitchv1alpha1.DefaultAlertType,
 					},
-				}, nil).Times(2)
+				}, nil).Times(4)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain"), nil).Times(2)
 				r.CheckIn(gomock.Any()).Return(nil).Times(1)
 				r.Update(gomock.Any()).Times(0)
 				r.Delete(gomock.Any()).Times(0)
@@ -655,10 +1065,15 @@ func TestReconcileClusterDeployment(t *testing.T) {
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{}, nil).Times(1)
 				r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
 					{
+						Token:      testSnitchToken,
 						CheckInURL: testSnitchURL,
 						Status:     "pending",
+						Tags:       testSnitchTags(),
+						Type:       &dmsclient.SnitchType{Interval: deadmanssnitchv1alpha1.DefaultInterval},
+						AlertType:  deadmanssnitchv1alpha1.DefaultAlertType,
 					},
-				}, nil).Times(2)
+				}, nil).Times(4)
+				r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(2)
 				r.CheckIn(gomock.Any()).Return(nil).Times(1)
 				r.Update(gomock.Any()).Times(0)
 				r.Delete(gomock.Any()).Times(0)
@@ -677,10 +1092,11 @@ func TestReconcileClusterDeployment(t *testing.T) {
 			defer mocks.mockCtrl.Finish()
 
 			rdms := &ReconcileDeadmansSnitchIntegration{
-				client: mocks.fakeKubeClient,
-				scheme: scheme.Scheme,
-				dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector) dmsclient.Client {
-					return mocks.mockDMSClient
+				client:   mocks.fakeKubeClient,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(100),
+				dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+					return mocks.mockDMSClient, nil
 				},
 			}
 
@@ -716,6 +1132,573 @@ func TestReconcileClusterDeployment(t *testing.T) {
 	}
 }
 
+func TestReconcileStatus(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, []runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testDeadMansSnitchIntegration(),
+	})
+	defer mocks.mockCtrl.Finish()
+
+	r := mocks.mockDMSClient.EXPECT()
+	r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{}, nil).Times(1)
+	r.Create(gomock.Any()).Return(dmsclient.Snitch{CheckInURL: testSnitchURL, Token: testSnitchToken}, nil).Times(1)
+	r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
+		{
+			CheckInURL: testSnitchURL,
+			Token:      testSnitchToken,
+			Status:     "pending",
+			Tags:       testSnitchTags(),
+		},
+	}, nil).Times(2)
+	r.CheckIn(gomock.Any()).Return(nil).Times(1)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      testDeadMansSnitchintegrationName,
+			Namespace: config.OperatorNamespace,
+		},
+	})
+	assert.NoError(t, err)
+
+	dmsi := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{
+		Name:      testDeadMansSnitchintegrationName,
+		Namespace: config.OperatorNamespace,
+	}, dmsi)
+	assert.NoError(t, err)
+
+	assert.True(t, meta.IsStatusConditionTrue(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionReady))
+	assert.True(t, meta.IsStatusConditionTrue(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionAPIKeyValid))
+	assert.True(t, meta.IsStatusConditionFalse(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionDegraded))
+	assert.Equal(t, 1, dmsi.Status.MatchedClusterDeployments)
+	assert.Equal(t, 1, dmsi.Status.ManagedClusterDeployments)
+	assert.Equal(t, 0, dmsi.Status.FailedClusterDeployments)
+	if assert.Len(t, dmsi.Status.Snitches, 1) {
+		assert.Equal(t, testClusterName, dmsi.Status.Snitches[0].ClusterDeploymentName)
+		assert.Equal(t, testClusterName+".base.domain-"+snitchNamePostFix, dmsi.Status.Snitches[0].Name)
+		assert.Equal(t, testSnitchToken, dmsi.Status.Snitches[0].Token)
+		assert.Equal(t, "pending", dmsi.Status.Snitches[0].Status)
+		assert.Empty(t, dmsi.Status.Snitches[0].LastError)
+	}
+}
+
+func TestReconcileIsolatesClusterFailures(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// listed before the healthy cluster, without the cluster ID the snitch notes need
+	brokenClusterDeployment := testClusterDeployment()
+	brokenClusterDeployment.Name = "aaa-broken"
+	brokenClusterDeployment.UID = "ffffffff-bbbb-cccc-dddd-eeeeeeeeeeee"
+	brokenClusterDeployment.Spec.ClusterName = "aaa-broken"
+	brokenClusterDeployment.Spec.ClusterMetadata = nil
+
+	mocks := setupDefaultMocks(t, []runtime.Object{
+		brokenClusterDeployment,
+		testClusterDeployment(),
+		testSecret(),
+		testDeadMansSnitchIntegration(),
+	})
+	defer mocks.mockCtrl.Finish()
+
+	r := mocks.mockDMSClient.EXPECT()
+	r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{}, nil).Times(1)
+	r.Create(gomock.Any()).Return(dmsclient.Snitch{CheckInURL: testSnitchURL, Token: testSnitchToken}, nil).Times(1)
+	r.FindSnitchesByName(gomock.Any()).Return([]dmsclient.Snitch{
+		{
+			CheckInURL: testSnitchURL,
+			Token:      testSnitchToken,
+			Status:     "pending",
+			Tags:       testSnitchTags(),
+		},
+	}, nil).Times(2)
+	r.CheckIn(gomock.Any()).Return(nil).Times(1)
+
+	recorder := record.NewFakeRecorder(100)
+	clusterRetries := make(chan event.GenericEvent, 10)
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+		clusterBackoff: workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Second),
+		clusterRetries: clusterRetries,
+	}
+
+	result, err := rdms.Reconcile(reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      testDeadMansSnitchintegrationName,
+			Namespace: config.OperatorNamespace,
+		},
+	})
+	assert.NoError(t, err)
+	// the dmsi only waits for its resync, the failed cluster is retried on its own once its backoff passed
+	assert.GreaterOrEqual(t, int64(result.RequeueAfter), int64(resyncPeriod(testDeadMansSnitchIntegration())))
+	select {
+	case retry := <-clusterRetries:
+		assert.Equal(t, clusterDeploymentRequest(testDeadMansSnitchIntegration(), brokenClusterDeployment).NamespacedName,
+			types.NamespacedName{Name: retry.Meta.GetName(), Namespace: retry.Meta.GetNamespace()})
+	case <-time.After(time.Second):
+		t.Error("The failed cluster deployment wasn't retried")
+	}
+	assert.Len(t, clusterRetries, 0)
+
+	// the healthy cluster got its snitch despite the failure before it
+	assert.True(t, verifySecretExists(mocks.fakeKubeClient, &SecretEntry{
+		name:                     testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+		snitchURL:                testSnitchURL,
+		clusterDeploymentRefName: testClusterName,
+	}))
+
+	dmsi := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{
+		Name:      testDeadMansSnitchintegrationName,
+		Namespace: config.OperatorNamespace,
+	}, dmsi)
+	assert.NoError(t, err)
+
+	assert.True(t, meta.IsStatusConditionTrue(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionDegraded))
+	assert.Equal(t, 2, dmsi.Status.MatchedClusterDeployments)
+	assert.Equal(t, 1, dmsi.Status.ManagedClusterDeployments)
+	assert.Equal(t, 1, dmsi.Status.FailedClusterDeployments)
+	if assert.Len(t, dmsi.Status.Snitches, 2) {
+		assert.Equal(t, "aaa-broken", dmsi.Status.Snitches[0].ClusterDeploymentName)
+		assert.NotEmpty(t, dmsi.Status.Snitches[0].LastError)
+		assert.Equal(t, testClusterName, dmsi.Status.Snitches[1].ClusterDeploymentName)
+		assert.Empty(t, dmsi.Status.Snitches[1].LastError)
+	}
+	if assert.NotEmpty(t, recorder.Events) {
+		assert.Contains(t, <-recorder.Events, "ClusterDeploymentReconcileFailed")
+	}
+}
+
+func TestReconcileProviderErrorKeepsUnvisitedStatus(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// listed before the cluster of the status entry, and throttled by DMS
+	firstClusterDeployment := testClusterDeployment()
+	firstClusterDeployment.Name = "aaa-first"
+	firstClusterDeployment.UID = "ffffffff-bbbb-cccc-dddd-eeeeeeeeeeee"
+	firstClusterDeployment.Spec.ClusterName = "aaa-first"
+
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Status.ManagedClusterDeployments = 1
+	dmsi.Status.Snitches = []deadmanssnitchv1alpha1.SnitchStatus{{
+		ClusterDeploymentName:      testClusterName,
+		ClusterDeploymentNamespace: testNamespace,
+		Name:                       testClusterName + ".base.domain-" + snitchNamePostFix,
+		Token:                      testSnitchToken,
+		Status:                     "healthy",
+	}}
+
+	mocks := setupDefaultMocks(t, []runtime.Object{
+		firstClusterDeployment,
+		testClusterDeployment(),
+		testSecret(),
+		dmsi,
+	})
+	defer mocks.mockCtrl.Finish()
+
+	r := mocks.mockDMSClient.EXPECT()
+	r.FindSnitchesByName(gomock.Any()).Return(nil, &dmsclient.APIError{Operation: "list_all", StatusCode: 429, RetryAfter: time.Minute}).Times(1)
+	r.Create(gomock.Any()).Times(0)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	result, err := rdms.Reconcile(reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      testDeadMansSnitchintegrationName,
+			Namespace: config.OperatorNamespace,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, result.RequeueAfter)
+
+	dmsi = &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{
+		Name:      testDeadMansSnitchintegrationName,
+		Namespace: config.OperatorNamespace,
+	}, dmsi)
+	assert.NoError(t, err)
+
+	// the cluster the reconcile didn't get to keeps its entry
+	assert.Equal(t, 1, dmsi.Status.ManagedClusterDeployments)
+	assert.Equal(t, 1, dmsi.Status.FailedClusterDeployments)
+	if assert.Len(t, dmsi.Status.Snitches, 2) {
+		assert.Equal(t, "aaa-first", dmsi.Status.Snitches[0].ClusterDeploymentName)
+		assert.NotEmpty(t, dmsi.Status.Snitches[0].LastError)
+		assert.Equal(t, testClusterName, dmsi.Status.Snitches[1].ClusterDeploymentName)
+		assert.Equal(t, testSnitchToken, dmsi.Status.Snitches[1].Token)
+		assert.Empty(t, dmsi.Status.Snitches[1].LastError)
+	}
+}
+
+func TestReconcileStatusMissingAPIKey(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, []runtime.Object{
+		testClusterDeployment(),
+		testDeadMansSnitchIntegration(),
+	})
+	defer mocks.mockCtrl.Finish()
+
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      testDeadMansSnitchintegrationName,
+			Namespace: config.OperatorNamespace,
+		},
+	})
+	assert.Error(t, err)
+
+	dmsi := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{
+		Name:      testDeadMansSnitchintegrationName,
+		Namespace: config.OperatorNamespace,
+	}, dmsi)
+	assert.NoError(t, err)
+
+	assert.True(t, meta.IsStatusConditionFalse(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionAPIKeyValid))
+	assert.True(t, meta.IsStatusConditionFalse(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionReady))
+}
+
+func TestSnitchDrift(t *testing.T) {
+	desired := heartbeat.NewHeartbeat("snitch", []string{"a", "b"}, "hourly", "smart")
+	desired.Notes = snitchNotes(testExternalID)
+
+	tests := []struct {
+		name          string
+		current       heartbeat.Heartbeat
+		desired       heartbeat.Heartbeat
+		expectedPatch heartbeat.Heartbeat
+		expectedDrift []string
+	}{
+		{
+			name: "in sync with tags in another order",
+			current: heartbeat.Heartbeat{
+				Token:     testSnitchToken,
+				Name:      "snitch",
+				Tags:      []string{"b", "a"},
+				Notes:     snitchNotes(testExternalID),
+				Interval:  "hourly",
+				AlertType: "smart",
+			},
+			desired:       desired,
+			expectedPatch: heartbeat.Heartbeat{Token: testSnitchToken},
+			expectedDrift: []string{},
+		},
+		{
+			name: "every field drifted",
+			current: heartbeat.Heartbeat{
+				Token:     testSnitchToken,
+				Name:      "other",
+				Tags:      []string{"a"},
+				Notes:     "edited",
+				Interval:  "daily",
+				AlertType: "basic",
+			},
+			desired:       desired,
+			expectedPatch: heartbeat.Heartbeat{Token: testSnitchToken, Name: "snitch", Tags: []string{"a", "b"}, Notes: desired.Notes, Interval: "hourly", AlertType: "smart"},
+			expectedDrift: []string{"name", "tags", "interval", "alert_type", "notes"},
+		},
+		{
+			name: "no desired tags",
+			current: heartbeat.Heartbeat{
+				Token:     testSnitchToken,
+				Name:      "snitch",
+				Tags:      []string{"a"},
+				Notes:     snitchNotes(testExternalID),
+				Interval:  "hourly",
+				AlertType: "smart",
+			},
+			desired:       heartbeat.Heartbeat{Name: "snitch", Notes: desired.Notes, Interval: "hourly", AlertType: "smart"},
+			expectedPatch: heartbeat.Heartbeat{Token: testSnitchToken},
+			expectedDrift: []string{},
+		},
+		{
+			name: "provider without alert types",
+			current: heartbeat.Heartbeat{
+				Token:    testSnitchToken,
+				Name:     "snitch",
+				Tags:     []string{"a", "b"},
+				Notes:    snitchNotes(testExternalID),
+				Interval: "hourly",
+			},
+			desired:       desired,
+			expectedPatch: heartbeat.Heartbeat{Token: testSnitchToken},
+			expectedDrift: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, drift := snitchDrift(test.current, test.desired)
+			assert.Equal(t, test.expectedPatch, patch)
+			assert.Equal(t, test.expectedDrift, drift)
+		})
+	}
+}
+
+func TestUpdateSnitchKeepsOpsgenieName(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// Opsgenie identifies heartbeats by name, so one named otherwise than the dmsi asks for is patched under its name
+	resources := testRecordedSnitchResources()
+	secret := resources[0].(*corev1.Secret)
+	secret.Annotations[snitchTokenAnnotation] = "old-name"
+	secret.Annotations[snitchNameAnnotation] = "old-name"
+	mocks := setupDefaultMocks(t, append([]runtime.Object{testClusterDeployment()}, resources...))
+	defer mocks.mockCtrl.Finish()
+
+	ogClient := mockog.NewMockClient(mocks.mockCtrl)
+	ogClient.EXPECT().PingURL("old-name").Return("https://api.opsgenie.com/v2/heartbeats/old-name/ping").AnyTimes()
+	ogClient.EXPECT().Get("old-name").Return(opsgenieclient.Heartbeat{Name: "old-name"}, nil).Times(1)
+	ogClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(o opsgenieclient.Heartbeat) (opsgenieclient.Heartbeat, error) {
+		assert.Equal(t, "old-name", o.Name)
+		return o, nil
+	}).Times(1)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{client: mocks.fakeKubeClient, scheme: scheme.Scheme, recorder: record.NewFakeRecorder(100)}
+	snitch, err := rdms.updateSnitch(testDeadMansSnitchIntegration(), testClusterDeployment(), heartbeat.NewOpsgenieProvider(ogClient, "ping-key"), false)
+	assert.NoError(t, err)
+	if assert.NotNil(t, snitch) {
+		assert.Equal(t, "old-name", snitch.Token)
+	}
+}
+
+func TestHeartbeatProvider(t *testing.T) {
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:             fakekubeclient.NewFakeClient(testSecret()),
+		recorder:           record.NewFakeRecorder(100),
+		dmsclient:          dmsclient.NewClientWithOptions,
+		healthchecksclient: healthchecksclient.NewClient,
+		opsgenieclient:     opsgenieclient.NewClient,
+	}
+
+	dmsi := testDeadMansSnitchIntegration()
+	_, err := rdms.heartbeatProvider(dmsi, testAPIKey)
+	assert.NoError(t, err)
+
+	dmsi.Spec.Provider = deadmanssnitchv1alpha1.ProviderHealthchecks
+	dmsi.Spec.BaseURL = "https://healthchecks.example.com"
+	_, err = rdms.heartbeatProvider(dmsi, testAPIKey)
+	assert.NoError(t, err)
+
+	dmsi.Spec.BaseURL = "healthchecks.example.com"
+	_, err = rdms.heartbeatProvider(dmsi, testAPIKey)
+	assert.Error(t, err)
+
+	dmsi.Spec.Provider = deadmanssnitchv1alpha1.ProviderOpsgenie
+	dmsi.Spec.BaseURL = ""
+	_, err = rdms.heartbeatProvider(dmsi, testAPIKey)
+	assert.NoError(t, err)
+
+	dmsi.Spec.Provider = "unknown"
+	_, err = rdms.heartbeatProvider(dmsi, testAPIKey)
+	assert.Error(t, err)
+
+	dmsi.Spec.Provider = deadmanssnitchv1alpha1.ProviderDeadMansSnitch
+	dmsi.Spec.HTTPClient = &deadmanssnitchv1alpha1.HTTPClientConfig{CABundle: "not a certificate"}
+	_, err = rdms.heartbeatProvider(dmsi, testAPIKey)
+	assert.Error(t, err)
+}
+
+// failingGetClient fails every Get like an unreachable API server
+type failingGetClient struct {
+	client.Client
+}
+
+func (c failingGetClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return errors.NewServiceUnavailable("unavailable")
+}
+
+func TestOpsgeniePingAPIKey(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Spec.Provider = deadmanssnitchv1alpha1.ProviderOpsgenie
+	withPingKey := testSecret()
+	withPingKey.Data[opsgeniePingAPISecretKey] = []byte("ping-key")
+
+	tests := []struct {
+		name             string
+		client           client.Client
+		expectedKey      string
+		expectedErr      bool
+		expectedEventLen int
+	}{
+		{
+			name:        "dedicated ping key",
+			client:      fakekubeclient.NewFakeClient(withPingKey),
+			expectedKey: "ping-key",
+		},
+		{
+			name:             "no dedicated ping key",
+			client:           fakekubeclient.NewFakeClient(testSecret()),
+			expectedKey:      testAPIKey,
+			expectedEventLen: 1,
+		},
+		{
+			name:             "no secret",
+			client:           fakekubeclient.NewFakeClient(),
+			expectedKey:      testAPIKey,
+			expectedEventLen: 1,
+		},
+		{
+			// the API key must not be handed to the clusters because the ping key couldn't be read
+			name:        "secret unreadable",
+			client:      failingGetClient{Client: fakekubeclient.NewFakeClient(withPingKey)},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(100)
+			rdms := &ReconcileDeadmansSnitchIntegration{client: test.client, recorder: recorder}
+
+			pingAPIKey, err := rdms.opsgeniePingAPIKey(dmsi, testAPIKey)
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expectedKey, pingAPIKey)
+			assert.Len(t, recorder.Events, test.expectedEventLen)
+		})
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	opts, err := clientOptions(dmsi, config.HTTPClientConfig{})
+	assert.NoError(t, err)
+	assert.Empty(t, opts)
+
+	opts, err = clientOptions(dmsi, config.HTTPClientConfig{Timeout: time.Minute, TLSMinVersion: "1.2"})
+	assert.NoError(t, err)
+	assert.Len(t, opts, 2)
+
+	// the dmsi overrides the operator level settings
+	dmsi.Spec.HTTPClient = &deadmanssnitchv1alpha1.HTTPClientConfig{
+		Timeout:       &metav1.Duration{Duration: 10 * time.Second},
+		ProxyURL:      "http://proxy.example.com:3128",
+		TLSMinVersion: "1.3",
+	}
+	opts, err = clientOptions(dmsi, config.HTTPClientConfig{Timeout: time.Minute, TLSMinVersion: "1.2"})
+	assert.NoError(t, err)
+	assert.Len(t, opts, 3)
+
+	dmsi.Spec.HTTPClient.TLSMinVersion = "2.0"
+	_, err = clientOptions(dmsi, config.HTTPClientConfig{})
+	assert.Error(t, err)
+
+	_, err = clientOptions(testDeadMansSnitchIntegration(), config.HTTPClientConfig{CABundleFile: "/nonexistent/ca-bundle.crt"})
+	assert.Error(t, err)
+}
+
+func TestReadCABundleFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ca-bundle")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ca-bundle.crt")
+	modTime := time.Now().Add(-time.Hour)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("bundle-1"), 0600))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	data, err := readCABundleFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "bundle-1", string(data))
+
+	// an unchanged file is served from memory
+	assert.NoError(t, ioutil.WriteFile(path, []byte("bundle-2"), 0600))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	data, err = readCABundleFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "bundle-1", string(data))
+
+	// a rotated bundle is read again
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now()))
+	data, err = readCABundleFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "bundle-2", string(data))
+}
+
+func TestAPIErrorResult(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	result, err := apiErrorResult(dmsi, &dmsclient.APIError{Operation: "create", StatusCode: 429, RetryAfter: time.Minute})
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{Requeue: true, RequeueAfter: time.Minute}, result)
+
+	result, err = apiErrorResult(dmsi, &dmsclient.APIError{Operation: "list_all", StatusCode: 401})
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{RequeueAfter: apiErrorRequeueDelay}, result)
+	assert.True(t, meta.IsStatusConditionFalse(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionAPIKeyValid))
+
+	result, err = apiErrorResult(dmsi, &dmsclient.APIError{Operation: "create", StatusCode: 402})
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{RequeueAfter: apiErrorRequeueDelay}, result)
+	assert.Equal(t, "QuotaExceeded", meta.FindStatusCondition(dmsi.Status.Conditions, deadmanssnitchv1alpha1.ConditionDegraded).Reason)
+
+	result, err = apiErrorResult(dmsi, &dmsclient.APIError{Operation: "update", StatusCode: 500})
+	assert.Error(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+}
+
+func TestSetSecretCheckIn(t *testing.T) {
+	secret := newDMSSecret(testNamespace, "secret", testSnitchURL)
+	assert.True(t, secretHasCheckIn(secret, heartbeat.Heartbeat{CheckInURL: testSnitchURL}))
+
+	withAuth := heartbeat.Heartbeat{
+		CheckInURL:         "https://api.opsgenie.com/v2/heartbeats/test/ping",
+		CheckInAuthType:    "GenieKey",
+		CheckInCredentials: testAPIKey,
+	}
+	assert.False(t, secretHasCheckIn(secret, withAuth))
+	setSecretCheckIn(secret, withAuth)
+	assert.True(t, secretHasCheckIn(secret, withAuth))
+	assert.Equal(t, "GenieKey", string(secret.Data[config.KeySnitchAuthType]))
+	assert.Equal(t, testAPIKey, string(secret.Data[config.KeySnitchAuthCredentials]))
+
+	setSecretCheckIn(secret, heartbeat.Heartbeat{CheckInURL: testSnitchURL})
+	assert.Equal(t, map[string][]byte{config.KeySnitchURL: []byte(testSnitchURL)}, secret.Data)
+}
+
 func verifySyncSetExists(c client.Client, expected *SyncSetEntry) bool {
 	ssl := hivev1.SyncSetList{}
 	err := c.List(context.TODO(), &ssl)
@@ -0,0 +1,168 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OrphanedSnitchTag is added to the snitches left behind by a dmsi deleted with the Orphan or Pause deletion policy.
+// The next dmsi managing their cluster adopts them instead of creating new snitches, keeping their check-in URLs.
+const OrphanedSnitchTag = "dms-orphaned"
+
+// errSyncSetNotApplied is returned while hive hasn't applied the Upsert resource apply mode of the syncset of an orphaned
+// clusterdeployment yet. Deleting the syncset before then would have hive delete the secret from the cluster.
+var errSyncSetNotApplied = errors.New("Upsert resource apply mode of the syncset not applied yet")
+
+// releaseDMSClusterDeployment tears down what the deleted dmsi set up for the clusterdeployment, following its deletion policy
+func (r *ReconcileDeadmansSnitchIntegration) releaseDMSClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeployment *hivev1.ClusterDeployment, dmsc heartbeat.Provider) error {
+	switch dmsi.Spec.DeletionPolicy {
+	case deadmanssnitchv1alpha1.DeletionPolicyOrphan, deadmanssnitchv1alpha1.DeletionPolicyPause:
+		return r.orphanDMSClusterDeployment(dmsi, clusterDeployment, dmsc)
+	default:
+		return r.deleteDMSClusterDeployment(dmsi, clusterDeployment, dmsc)
+	}
+}
+
+// orphanDMSClusterDeployment tags the snitch of the clusterdeployment as orphaned and deletes the syncset, secret and finalizer
+// of the dmsi. With the Orphan deletion policy the syncset is only deleted once hive applied its Upsert resource apply mode,
+// so the secret is left on the cluster and it keeps checking in. With the Pause deletion policy the snitch is paused and hive
+// removes the secret from the cluster with the syncset: a check-in of the cluster would unpause the snitch right away.
+func (r *ReconcileDeadmansSnitchIntegration) orphanDMSClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeployment *hivev1.ClusterDeployment, dmsc heartbeat.Provider) error {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", clusterDeployment.Name, "cluster-deployment.Namespace:", clusterDeployment.Namespace)
+
+	snitchName, err := getSnitchName(dmsi, *clusterDeployment)
+	if err != nil {
+		return err
+	}
+	snitches, err := r.recordedSnitches(dmsi, clusterDeployment, dmsc)
+	if err != nil {
+		return err
+	}
+	for _, snitch := range snitches {
+		if dmsi.Spec.DeletionPolicy == deadmanssnitchv1alpha1.DeletionPolicyPause && snitch.Status != heartbeat.StatusPaused {
+			logger.Info(fmt.Sprint("Pausing orphaned snitch:", snitchName))
+			if err := dmsc.Pause(snitch.Token); err != nil {
+				return err
+			}
+		}
+		if !hasTag(snitch.Tags, OrphanedSnitchTag) {
+			logger.Info(fmt.Sprint("Tagging orphaned snitch:", snitchName))
+			tags := append(append([]string{}, snitch.Tags...), OrphanedSnitchTag)
+			if _, err := dmsc.Update(heartbeat.Heartbeat{Token: snitch.Token, Tags: tags}); err != nil {
+				return err
+			}
+		}
+		r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "SnitchOrphaned",
+			"Left snitch %s of clusterdeployment %s/%s for adoption", snitchName, clusterDeployment.Namespace, clusterDeployment.Name)
+	}
+
+	if dmsi.Spec.DeletionPolicy == deadmanssnitchv1alpha1.DeletionPolicyPause {
+		return r.deleteSnitchResources(dmsi, clusterDeployment)
+	}
+	applied, err := r.upsertSyncSet(dmsi, clusterDeployment)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		logger.Info("Waiting for hive to apply the Upsert resource apply mode of the DMS SyncSet")
+		return errSyncSetNotApplied
+	}
+	return r.deleteSnitchResources(dmsi, clusterDeployment)
+}
+
+// upsertSyncSet switches the syncset of the clusterdeployment to the Upsert resource apply mode,
+// so hive leaves the secret on the cluster once the syncset is deleted. It reports whether hive applied the switch.
+func (r *ReconcileDeadmansSnitchIntegration) upsertSyncSet(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterDeployment *hivev1.ClusterDeployment) (bool, error) {
+	ssName := utils.SecretName(clusterDeployment.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+	syncSet := &hivev1.SyncSet{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: ssName, Namespace: clusterDeployment.Namespace}, syncSet)
+	if err != nil {
+		if k8errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if syncSet.Spec.ResourceApplyMode != hivev1.UpsertResourceApplyMode {
+		original := syncSet.DeepCopy()
+		syncSet.Spec.ResourceApplyMode = hivev1.UpsertResourceApplyMode
+		return false, r.client.Patch(context.TODO(), syncSet, client.MergeFrom(original))
+	}
+	return r.syncSetApplied(clusterDeployment, syncSet)
+}
+
+// syncSetApplied checks if hive applied the current generation of the syncset to the cluster.
+// hive keeps what it applies in the clustersync named after the clusterdeployment, which it creates with the first syncset
+// of the cluster: without a clustersync, nothing was applied that the deletion of the syncset would remove.
+func (r *ReconcileDeadmansSnitchIntegration) syncSetApplied(clusterDeployment *hivev1.ClusterDeployment, syncSet *hivev1.SyncSet) (bool, error) {
+	clusterSync := &hiveintv1alpha1.ClusterSync{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: clusterDeployment.Name, Namespace: clusterDeployment.Namespace}, clusterSync)
+	if err != nil {
+		if k8errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	for _, status := range clusterSync.Status.SyncSets {
+		if status.Name == syncSet.Name {
+			return status.ObservedGeneration >= syncSet.Generation && status.Result == hiveintv1alpha1.SuccessSyncSetResult, nil
+		}
+	}
+	return false, nil
+}
+
+// adoptSnitch takes over a snitch orphaned by a deleted dmsi or an unmarked snitch, unpausing it and stamping the owner marker
+func (r *ReconcileDeadmansSnitchIntegration) adoptSnitch(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc heartbeat.Provider, snitch heartbeat.Heartbeat) (heartbeat.Heartbeat, error) {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
+	logger.Info(fmt.Sprint("Adopting snitch:", snitch.Name))
+
+	if snitch.Status == heartbeat.StatusPaused {
+		if err := dmsc.Unpause(snitch.Token); err != nil {
+			return snitch, err
+		}
+		snitch.Status = heartbeat.StatusPending
+	}
+
+	// stamp the owner marker of the dmsi in place of the marker of the dmsi that orphaned the snitch
+	tags := withOwnerMarker(withoutTag(snitch.Tags, OrphanedSnitchTag), dmsi, *cd)
+	if _, err := dmsc.Update(heartbeat.Heartbeat{Token: snitch.Token, Tags: tags}); err != nil {
+		return snitch, err
+	}
+	snitch.Tags = tags
+
+	r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "SnitchAdopted",
+		"Adopted snitch %s for clusterdeployment %s/%s", snitch.Name, cd.Namespace, cd.Name)
+	return snitch, nil
+}
+
+// hasTag reports whether tag is one of tags
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutTag returns a copy of tags without tag
+func withoutTag(tags []string, tag string) []string {
+	result := []string{}
+	for _, t := range tags {
+		if t != tag {
+			result = append(result, t)
+		}
+	}
+	return result
+}
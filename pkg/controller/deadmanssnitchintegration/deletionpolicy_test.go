@@ -0,0 +1,379 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	mockdms "github.com/openshift/deadmanssnitch-operator/pkg/dmsclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hiveapis "github.com/openshift/hive/apis"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// return a dmsi being deleted with the deletion policy
+func testDeletedDeadMansSnitchIntegration(deletionPolicy string) *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	now := metav1.Now()
+	dmsi.DeletionTimestamp = &now
+	dmsi.Finalizers = []string{finalizerName(dmsi)}
+	dmsi.Spec.DeletionPolicy = deletionPolicy
+	return dmsi
+}
+
+// return the clustersync of testClusterDeployment reporting the generation of its syncset as applied
+func testClusterSync(generation int64) *hiveintv1alpha1.ClusterSync {
+	return &hiveintv1alpha1.ClusterSync{
+		ObjectMeta: metav1.ObjectMeta{Name: testClusterName, Namespace: testNamespace},
+		Status: hiveintv1alpha1.ClusterSyncStatus{
+			SyncSets: []hiveintv1alpha1.SyncStatus{{
+				Name:               testResourceName(),
+				ObservedGeneration: generation,
+				Result:             hiveintv1alpha1.SuccessSyncSetResult,
+			}},
+		},
+	}
+}
+
+// return the secret and syncset of testClusterDeployment with hive having applied the Upsert resource apply mode of the syncset
+func testUpsertSnitchResources() []runtime.Object {
+	resources := testExistingSnitchResources()
+	syncSet := resources[1].(*hivev1.SyncSet)
+	syncSet.Generation = 2
+	syncSet.Spec.ResourceApplyMode = hivev1.UpsertResourceApplyMode
+	return append(resources, testClusterSync(2))
+}
+
+func TestReconcileDeletionPolicy(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	orphanedTags := append(testSnitchTags(), OrphanedSnitchTag)
+
+	tests := []struct {
+		name           string
+		deletionPolicy string
+		setupDMSMock   func(r *mockdms.MockClientMockRecorder)
+	}{
+		{
+			name:           "default deletes the snitch",
+			deletionPolicy: "",
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+				r.Delete(testSnitchToken).Return(true, nil).Times(1)
+				r.Update(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:           "Orphan tags the snitch",
+			deletionPolicy: deadmanssnitchv1alpha1.DeletionPolicyOrphan,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+				r.Update(dmsclient.Snitch{Token: testSnitchToken, Tags: orphanedTags}).Return(dmsclient.Snitch{Token: testSnitchToken}, nil).Times(1)
+				r.Pause(gomock.Any()).Times(0)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:           "Pause pauses and tags the snitch",
+			deletionPolicy: deadmanssnitchv1alpha1.DeletionPolicyPause,
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+				r.Pause(testSnitchToken).Return(nil).Times(1)
+				r.Update(dmsclient.Snitch{Token: testSnitchToken, Tags: orphanedTags}).Return(dmsclient.Snitch{Token: testSnitchToken}, nil).Times(1)
+				r.Delete(gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeletedDeadMansSnitchIntegration(test.deletionPolicy),
+			}, testUpsertSnitchResources()...))
+			defer mocks.mockCtrl.Finish()
+			test.setupDMSMock(mocks.mockDMSClient.EXPECT())
+
+			rdms := &ReconcileDeadmansSnitchIntegration{
+				client:   mocks.fakeKubeClient,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(100),
+				dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+					return mocks.mockDMSClient, nil
+				},
+			}
+
+			_, err := rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+			assert.NoError(t, err)
+
+			// the hub resources and finalizers are gone whatever the policy
+			name := testResourceName()
+			assert.True(t, verifyNoSecret(mocks.fakeKubeClient, &SecretEntry{name: name}))
+			assert.True(t, verifyNoSyncSet(mocks.fakeKubeClient, &SyncSetEntry{name: name}))
+			cd := &hivev1.ClusterDeployment{}
+			err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: testClusterName, Namespace: testNamespace}, cd)
+			assert.NoError(t, err)
+			assert.NotContains(t, cd.Finalizers, deadMansSnitchFinalizer)
+		})
+	}
+}
+
+func TestReconcileOrphanWaitsForSyncSet(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// hive applied the syncset in the Sync resource apply mode
+	resources := testExistingSnitchResources()
+	resources[1].(*hivev1.SyncSet).Generation = 1
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testDeletedDeadMansSnitchIntegration(deadmanssnitchv1alpha1.DeletionPolicyOrphan),
+		testClusterSync(1),
+	}, resources...))
+	defer mocks.mockCtrl.Finish()
+
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	orphaned := testLiveSnitch(snitchName)
+	orphaned.Tags = append(testSnitchTags(), OrphanedSnitchTag)
+	r := mocks.mockDMSClient.EXPECT()
+	r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{orphaned}, nil).AnyTimes()
+	r.Update(gomock.Any()).Times(0)
+	r.Delete(gomock.Any()).Times(0)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}}
+	name := testResourceName()
+	syncSetKey := types.NamespacedName{Name: name, Namespace: testNamespace}
+
+	// the syncset is switched to Upsert and kept until hive applies the switch
+	result, err := rdms.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Equal(t, syncSetApplyRequeueDelay, result.RequeueAfter)
+	syncSet := &hivev1.SyncSet{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), syncSetKey, syncSet)
+	assert.NoError(t, err)
+	assert.Equal(t, hivev1.UpsertResourceApplyMode, syncSet.Spec.ResourceApplyMode)
+
+	// the fake client doesn't bump the generation of the patched syncset like the API server does
+	syncSet.Generation = 2
+	assert.NoError(t, mocks.fakeKubeClient.Update(context.TODO(), syncSet))
+
+	result, err = rdms.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Equal(t, syncSetApplyRequeueDelay, result.RequeueAfter)
+	assert.False(t, verifyNoSyncSet(mocks.fakeKubeClient, &SyncSetEntry{name: name}))
+	assert.False(t, verifyNoSecret(mocks.fakeKubeClient, &SecretEntry{name: name}))
+	dmsi := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), request.NamespacedName, dmsi)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, dmsi.Finalizers)
+
+	// once hive applied the Upsert resource apply mode, the syncset is deleted
+	clusterSync := &hiveintv1alpha1.ClusterSync{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: testClusterName, Namespace: testNamespace}, clusterSync)
+	assert.NoError(t, err)
+	clusterSync.Status = testClusterSync(2).Status
+	assert.NoError(t, mocks.fakeKubeClient.Update(context.TODO(), clusterSync))
+
+	result, err = rdms.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, verifyNoSyncSet(mocks.fakeKubeClient, &SyncSetEntry{name: name}))
+	assert.True(t, verifyNoSecret(mocks.fakeKubeClient, &SecretEntry{name: name}))
+}
+
+// syncSetApplyModeRecorder records the resource apply modes syncsets are patched to
+type syncSetApplyModeRecorder struct {
+	client.Client
+	applyModes []hivev1.SyncSetResourceApplyMode
+}
+
+func (c *syncSetApplyModeRecorder) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if syncSet, ok := obj.(*hivev1.SyncSet); ok {
+		c.applyModes = append(c.applyModes, syncSet.Spec.ResourceApplyMode)
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestReconcilePauseRemovesSecretFromCluster(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// hive applied the syncset in the Sync resource apply mode
+	resources := testExistingSnitchResources()
+	resources[1].(*hivev1.SyncSet).Generation = 1
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testDeletedDeadMansSnitchIntegration(deadmanssnitchv1alpha1.DeletionPolicyPause),
+		testClusterSync(1),
+	}, resources...))
+	defer mocks.mockCtrl.Finish()
+
+	// a check-in would unpause the snitch, be it by the operator or by the cluster through the secret hive leaves behind
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	r := mocks.mockDMSClient.EXPECT()
+	r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+	r.Pause(testSnitchToken).Return(nil).Times(1)
+	r.Update(gomock.Any()).Return(dmsclient.Snitch{Token: testSnitchToken}, nil).Times(1)
+	r.CheckIn(gomock.Any()).Times(0)
+	r.Unpause(gomock.Any()).Times(0)
+
+	kubeClient := &syncSetApplyModeRecorder{Client: mocks.fakeKubeClient}
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   kubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	result, err := rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	// the syncset is deleted without being switched to Upsert, so hive removes the secret from the cluster
+	assert.NotContains(t, kubeClient.applyModes, hivev1.UpsertResourceApplyMode)
+	name := testResourceName()
+	assert.True(t, verifyNoSyncSet(mocks.fakeKubeClient, &SyncSetEntry{name: name}))
+	assert.True(t, verifyNoSecret(mocks.fakeKubeClient, &SecretEntry{name: name}))
+}
+
+func TestUpsertSyncSet(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, testExistingSnitchResources())
+	defer mocks.mockCtrl.Finish()
+
+	rdms := &ReconcileDeadmansSnitchIntegration{client: mocks.fakeKubeClient, scheme: scheme.Scheme}
+	applied, err := rdms.upsertSyncSet(testDeadMansSnitchIntegration(), testClusterDeployment())
+	assert.NoError(t, err)
+	assert.False(t, applied)
+
+	syncSet := &hivev1.SyncSet{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: testResourceName(), Namespace: testNamespace}, syncSet)
+	assert.NoError(t, err)
+	assert.Equal(t, hivev1.UpsertResourceApplyMode, syncSet.Spec.ResourceApplyMode)
+
+	// a clusterdeployment without a syncset has nothing to switch
+	other := testClusterDeployment()
+	other.Spec.ClusterName = "other"
+	applied, err = rdms.upsertSyncSet(testDeadMansSnitchIntegration(), other)
+	assert.NoError(t, err)
+	assert.True(t, applied)
+}
+
+func TestSyncSetApplied(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	syncSet := &hivev1.SyncSet{ObjectMeta: metav1.ObjectMeta{Name: testResourceName(), Namespace: testNamespace, Generation: 2}}
+	failed := testClusterSync(2)
+	failed.Status.SyncSets[0].Result = hiveintv1alpha1.FailureSyncSetResult
+	other := testClusterSync(2)
+	other.Status.SyncSets[0].Name = "other"
+
+	tests := []struct {
+		name         string
+		localObjects []runtime.Object
+		expected     bool
+	}{
+		{name: "no clustersync", expected: true},
+		{name: "generation applied", localObjects: []runtime.Object{testClusterSync(2)}, expected: true},
+		{name: "older generation applied", localObjects: []runtime.Object{testClusterSync(1)}},
+		{name: "generation failed", localObjects: []runtime.Object{failed}},
+		{name: "syncset not applied yet", localObjects: []runtime.Object{other}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, test.localObjects)
+			defer mocks.mockCtrl.Finish()
+
+			rdms := &ReconcileDeadmansSnitchIntegration{client: mocks.fakeKubeClient, scheme: scheme.Scheme}
+			applied, err := rdms.syncSetApplied(testClusterDeployment(), syncSet)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, applied)
+		})
+	}
+}
+
+func TestAdoptSnitch(t *testing.T) {
+	tests := []struct {
+		name         string
+		snitch       heartbeat.Heartbeat
+		setupDMSMock func(r *mockdms.MockClientMockRecorder)
+		expectedTags []string
+	}{
+		{
+			name:   "orphaned snitch",
+			snitch: heartbeat.Heartbeat{Token: testSnitchToken, Tags: append(testSnitchTags(), OrphanedSnitchTag), Status: heartbeat.StatusHealthy},
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.Update(dmsclient.Snitch{Token: testSnitchToken, Tags: testSnitchTags()}).Return(dmsclient.Snitch{Token: testSnitchToken}, nil).Times(1)
+				r.Unpause(gomock.Any()).Times(0)
+			},
+			expectedTags: testSnitchTags(),
+		},
+		{
+			name:   "paused snitch orphaned by another dmsi",
+			snitch: heartbeat.Heartbeat{Token: testSnitchToken, Tags: []string{OrphanedSnitchTag, OwnerMarkerTagPrefix + "previous"}, Status: heartbeat.StatusPaused},
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.Unpause(testSnitchToken).Return(nil).Times(1)
+				r.Update(dmsclient.Snitch{Token: testSnitchToken, Tags: []string{testOwnerMarker()}}).Return(dmsclient.Snitch{Token: testSnitchToken}, nil).Times(1)
+			},
+			expectedTags: []string{testOwnerMarker()},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			defer mocks.mockCtrl.Finish()
+			test.setupDMSMock(mocks.mockDMSClient.EXPECT())
+
+			recorder := record.NewFakeRecorder(100)
+			rdms := &ReconcileDeadmansSnitchIntegration{client: mocks.fakeKubeClient, scheme: scheme.Scheme, recorder: recorder}
+
+			snitch, err := rdms.adoptSnitch(testDeadMansSnitchIntegration(), testClusterDeployment(), heartbeat.NewDMSProvider(mocks.mockDMSClient), test.snitch)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedTags, snitch.Tags)
+			assert.NotEqual(t, heartbeat.StatusPaused, snitch.Status)
+			if assert.Len(t, recorder.Events, 1) {
+				assert.Contains(t, <-recorder.Events, "SnitchAdopted")
+			}
+		})
+	}
+}
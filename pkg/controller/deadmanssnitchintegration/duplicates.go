@@ -0,0 +1,63 @@
+package deadmanssnitchintegration
+
+import (
+	"fmt"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DuplicateSnitchTag is added to the unmarked snitches named like the snitch of a cluster that the operator may not delete,
+// so they can be cleaned up by hand
+const DuplicateSnitchTag = "dms-duplicate"
+
+// duplicateSnitches returns the snitches named like the snitch of the clusterdeployment, other than the canonical snitch
+func duplicateSnitches(snitches []heartbeat.Heartbeat, canonical heartbeat.Heartbeat, snitchName string) []heartbeat.Heartbeat {
+	duplicates := []heartbeat.Heartbeat{}
+	for _, snitch := range snitches {
+		if snitch.Name == snitchName && snitch.Token != canonical.Token {
+			duplicates = append(duplicates, snitch)
+		}
+	}
+	return duplicates
+}
+
+// collapseDuplicateSnitches keeps the canonical snitch, the one the secret of the clusterdeployment points to, as the only
+// snitch of the cluster. Duplicates the dmsi owns or may adopt are deleted, unmarked duplicates are tagged as such,
+// and duplicates owned by someone else are left alone.
+func (r *ReconcileDeadmansSnitchIntegration) collapseDuplicateSnitches(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc heartbeat.Provider, canonical heartbeat.Heartbeat, snitches []heartbeat.Heartbeat, snitchName string) error {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
+
+	for _, snitch := range duplicateSnitches(snitches, canonical, snitchName) {
+		if getSnitchOwnership(dmsi, *cd, snitch) != snitchConflicting {
+			localmetrics.Collector.ObserveDuplicateSnitchFound()
+			logger.Info(fmt.Sprint("Deleting duplicate snitch:", snitchName), "Token", snitch.Token)
+			err := dmsc.Delete(snitch.Token)
+			if err != nil && !apierror.IsNotFound(err) {
+				return err
+			}
+			localmetrics.Collector.ObserveDuplicateSnitchRemoved()
+			r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "DuplicateSnitchDeleted",
+				"Deleted duplicate snitch %s of clusterdeployment %s/%s", snitchName, cd.Namespace, cd.Name)
+			continue
+		}
+
+		if snitchOwnerMarker(snitch) != "" || hasTag(snitch.Tags, DuplicateSnitchTag) {
+			continue
+		}
+		localmetrics.Collector.ObserveDuplicateSnitchFound()
+		logger.Info(fmt.Sprint("Tagging unmarked duplicate snitch:", snitchName), "Token", snitch.Token)
+		tags := append(append([]string{}, snitch.Tags...), DuplicateSnitchTag)
+		if _, err := dmsc.Update(heartbeat.Heartbeat{Token: snitch.Token, Tags: tags}); err != nil {
+			return err
+		}
+		r.recorder.Eventf(dmsi, corev1.EventTypeWarning, "DuplicateSnitchFound",
+			"Tagged unmarked snitch %s named like the snitch of clusterdeployment %s/%s as %s", snitchName, cd.Namespace, cd.Name, DuplicateSnitchTag)
+	}
+	return nil
+}
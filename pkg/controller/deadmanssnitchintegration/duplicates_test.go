@@ -0,0 +1,123 @@
+package deadmanssnitchintegration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hiveapis "github.com/openshift/hive/apis"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDuplicateSnitches(t *testing.T) {
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	canonical := heartbeat.Heartbeat{Name: "renamed", Token: testSnitchToken}
+	duplicate := heartbeat.Heartbeat{Name: snitchName, Token: "duplicate"}
+	other := heartbeat.Heartbeat{Name: "other", Token: "other"}
+
+	assert.Equal(t, []heartbeat.Heartbeat{duplicate}, duplicateSnitches([]heartbeat.Heartbeat{other, duplicate, canonical}, canonical, snitchName))
+	assert.Empty(t, duplicateSnitches([]heartbeat.Heartbeat{other, canonical}, canonical, snitchName))
+}
+
+func TestReconcileDuplicateSnitches(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testDeadMansSnitchIntegration(),
+	}, testExistingSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	// the duplicates are listed first, the snitch the secret points to is still the canonical one
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	owned := dmsclient.Snitch{Name: snitchName, Token: "owned", CheckInURL: "https://nosnch.in/owned", Tags: testSnitchTags()}
+	unmarked := dmsclient.Snitch{Name: snitchName, Token: "unmarked", CheckInURL: "https://nosnch.in/unmarked", Tags: []string{testTag}}
+	flagged := dmsclient.Snitch{Name: snitchName, Token: "flagged", CheckInURL: "https://nosnch.in/flagged", Tags: []string{testTag, DuplicateSnitchTag}}
+	foreign := dmsclient.Snitch{Name: snitchName, Token: "foreign", CheckInURL: "https://nosnch.in/foreign", Tags: []string{testMarker("other-hub", "", testUID)}}
+
+	r := mocks.mockDMSClient.EXPECT()
+	r.ListAll().Return([]dmsclient.Snitch{owned, unmarked, flagged, foreign, testLiveSnitch(snitchName)}, nil).Times(1)
+	r.Delete("owned").Return(true, nil).Times(1)
+	r.Update(dmsclient.Snitch{Token: "unmarked", Tags: []string{testTag, DuplicateSnitchTag}}).Return(dmsclient.Snitch{Token: "unmarked"}, nil).Times(1)
+	r.Create(gomock.Any()).Times(0)
+
+	recorder := record.NewFakeRecorder(100)
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+	assert.NoError(t, err)
+
+	assert.True(t, verifySecretExists(mocks.fakeKubeClient, &SecretEntry{name: testResourceName(), snitchURL: testSnitchURL, clusterDeploymentRefName: testClusterName}))
+	if assert.GreaterOrEqual(t, len(recorder.Events), 2) {
+		assert.Contains(t, <-recorder.Events, "DuplicateSnitchDeleted")
+		assert.Contains(t, <-recorder.Events, "DuplicateSnitchFound")
+	}
+}
+
+func TestReconcileDuplicateOfRecordedSnitch(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testDeadMansSnitchIntegration(),
+	}, testRecordedSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	// the snitch is looked up by its recorded token, the duplicate created next to it later is only found by name
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	owned := dmsclient.Snitch{Name: snitchName, Token: "owned", CheckInURL: "https://nosnch.in/owned", Tags: testSnitchTags()}
+
+	r := mocks.mockDMSClient.EXPECT()
+	r.List(testSnitchToken).Return(testLiveSnitch(snitchName), nil).Times(2)
+	r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName), owned}, nil).Times(1)
+	r.Delete("owned").Return(true, nil).Times(1)
+	r.Create(gomock.Any()).Times(0)
+	r.Update(gomock.Any()).Times(0)
+
+	recorder := record.NewFakeRecorder(100)
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+	assert.NoError(t, err)
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	assert.Contains(t, strings.Join(events, "\n"), "DuplicateSnitchDeleted")
+
+	// reconciling the clusterdeployment alone leaves the search by name to the full resyncs
+	_, err = rdms.Reconcile(clusterDeploymentRequest(testDeadMansSnitchIntegration(), testClusterDeployment()))
+	assert.NoError(t, err)
+}
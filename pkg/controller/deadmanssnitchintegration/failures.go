@@ -0,0 +1,75 @@
+package deadmanssnitchintegration
+
+import (
+	"time"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// clusterRetryBaseDelay is how long to wait before retrying a ClusterDeployment that failed to reconcile once,
+	// the wait doubles with every further failure up to apiErrorRequeueDelay
+	clusterRetryBaseDelay = 5 * time.Second
+)
+
+// isProviderError is true for the errors of the heartbeat provider that fail every ClusterDeployment alike,
+// so reconciling the remaining ones is pointless
+func isProviderError(err error) bool {
+	return apierror.IsRateLimited(err) || apierror.IsUnauthorized(err) || apierror.IsQuotaExceeded(err)
+}
+
+// dmsiKey identifies the dmsi in metrics
+func dmsiKey(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) string {
+	return dmsi.Namespace + "/" + dmsi.Name
+}
+
+// clusterFailureBackoff returns the rate limiter spacing out the retries of each ClusterDeployment failing to reconcile
+func (r *ReconcileDeadmansSnitchIntegration) clusterFailureBackoff() workqueue.RateLimiter {
+	if r.clusterBackoff == nil {
+		r.clusterBackoff = workqueue.NewItemExponentialFailureRateLimiter(clusterRetryBaseDelay, apiErrorRequeueDelay)
+	}
+	return r.clusterBackoff
+}
+
+// clusterFailed reports the failure to reconcile the clusterdeployment in an event and the metrics of the dmsi,
+// and returns how long to wait before retrying the clusterdeployment
+func (r *ReconcileDeadmansSnitchIntegration) clusterFailed(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment, err error) time.Duration {
+	log.Error(err, "Error reconciling cluster deployment", "DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name,
+		"cluster-deployment.Name:", clusterdeployment.Name, "cluster-deployment.Namespace:", clusterdeployment.Namespace)
+	r.recorder.Eventf(dmsi, corev1.EventTypeWarning, "ClusterDeploymentReconcileFailed",
+		"Failed to reconcile clusterdeployment %s/%s: %v", clusterdeployment.Namespace, clusterdeployment.Name, err)
+	localmetrics.Collector.ObserveClusterDeploymentReconcileError(dmsiKey(dmsi))
+
+	return r.clusterFailureBackoff().When(clusterBackoffKey(dmsi, clusterdeployment))
+}
+
+// retryClusterDeployment queues the pair request of the clusterdeployment once retryAfter passed, through the clusterRetries
+// channel source of the controller. A full reconcile retries its failed ClusterDeployments that way, each with its own backoff,
+// instead of requeueing the whole dmsi and reconciling the healthy ClusterDeployments again.
+func (r *ReconcileDeadmansSnitchIntegration) retryClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment, retryAfter time.Duration) {
+	if r.clusterRetries == nil {
+		return
+	}
+	request := clusterDeploymentRequest(dmsi, clusterdeployment)
+	time.AfterFunc(retryAfter, func() {
+		r.clusterRetries <- event.GenericEvent{Meta: &metav1.ObjectMeta{Name: request.Name, Namespace: request.Namespace}}
+	})
+}
+
+// clusterSucceeded resets the backoff of the clusterdeployment after a successful reconcile
+func (r *ReconcileDeadmansSnitchIntegration) clusterSucceeded(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment) {
+	r.clusterFailureBackoff().Forget(clusterBackoffKey(dmsi, clusterdeployment))
+}
+
+// clusterBackoffKey identifies the clusterdeployment of the dmsi in the backoff
+func clusterBackoffKey(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment) string {
+	return dmsiKey(dmsi) + "/" + clusterdeployment.Namespace + "/" + clusterdeployment.Name
+}
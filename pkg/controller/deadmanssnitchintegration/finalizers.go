@@ -0,0 +1,129 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DeadMansSnitchFinalizerPrefix is the prefix of the legacy finalizers, followed by the name of the dmsi.
+	// DMSIs with the same name in different namespaces share it, so it is migrated to the finalizer of finalizerName
+	DeadMansSnitchFinalizerPrefix = "dms.managed.openshift.io/deadmanssnitch-"
+	// DeadMansSnitchFinalizerHashPrefix is the prefix of the finalizers, followed by a hash of the namespace and name of the dmsi
+	DeadMansSnitchFinalizerHashPrefix = "dms.managed.openshift.io/dmsi-"
+	// finalizerHashLength keeps the finalizers well within the 63 characters allowed after the prefix
+	finalizerHashLength = 16
+)
+
+// finalizerName returns the finalizer the dmsi puts on itself and the ClusterDeployments it manages.
+// It is unique to the namespace and name of the dmsi, and its length doesn't depend on them.
+func finalizerName(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) string {
+	return DeadMansSnitchFinalizerHashPrefix + dmsiHash(dmsi)
+}
+
+// dmsiHash returns a hash of the namespace and name of the dmsi that fits in finalizers and label values
+func dmsiHash(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) string {
+	sum := sha256.Sum256([]byte(dmsi.Namespace + "/" + dmsi.Name))
+	return hex.EncodeToString(sum[:])[:finalizerHashLength]
+}
+
+// legacyFinalizerName returns the finalizer the dmsi used before finalizerName
+func legacyFinalizerName(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) string {
+	return DeadMansSnitchFinalizerPrefix + dmsi.Name
+}
+
+// hasFinalizer is true when the object carries the finalizer of the dmsi, or the legacy finalizer that
+// can't be migrated anymore as the object is being deleted
+func hasFinalizer(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, object metav1.Object) bool {
+	return utils.HasFinalizer(object, finalizerName(dmsi)) || utils.HasFinalizer(object, legacyFinalizerName(dmsi))
+}
+
+// migrateDMSIFinalizer replaces the legacy finalizer of the dmsi with the finalizer of finalizerName
+func (r *ReconcileDeadmansSnitchIntegration) migrateDMSIFinalizer(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) error {
+	if dmsi.DeletionTimestamp != nil || !utils.HasFinalizer(dmsi, legacyFinalizerName(dmsi)) {
+		return nil
+	}
+	log.Info("Migrating legacy finalizer of dmsi", "DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name)
+	utils.DeleteFinalizer(dmsi, legacyFinalizerName(dmsi))
+	utils.AddFinalizer(dmsi, finalizerName(dmsi))
+	return r.client.Update(context.TODO(), dmsi)
+}
+
+// migrateClusterDeploymentFinalizer replaces the legacy finalizer on the clusterdeployment with the finalizers of the dmsi
+// and of every other dmsi with the same name that has a secret or syncset for the clusterdeployment, as any of them
+// may have added it. A clusterdeployment being deleted can't get new finalizers, so it keeps the legacy finalizer
+// until deleteDMSClusterDeployment finds none of them left to clean up.
+func (r *ReconcileDeadmansSnitchIntegration) migrateClusterDeploymentFinalizer(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment) error {
+	legacyFinalizer := legacyFinalizerName(dmsi)
+	if clusterdeployment.DeletionTimestamp != nil || !utils.HasFinalizer(clusterdeployment, legacyFinalizer) {
+		return nil
+	}
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", clusterdeployment.Name, "cluster-deployment.Namespace:", clusterdeployment.Namespace)
+
+	owners, err := r.legacyFinalizerOwners(dmsi, clusterdeployment)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Migrating legacy finalizer of cluster deployment", "Owners", len(owners)+1)
+	baseToPatch := client.MergeFrom(clusterdeployment.DeepCopy())
+	utils.DeleteFinalizer(clusterdeployment, legacyFinalizer)
+	utils.AddFinalizer(clusterdeployment, finalizerName(dmsi))
+	for i := range owners {
+		utils.AddFinalizer(clusterdeployment, finalizerName(&owners[i]))
+	}
+	return r.client.Patch(context.TODO(), clusterdeployment, baseToPatch)
+}
+
+// legacyFinalizerOwners returns the other DMSIs sharing the legacy finalizer of the dmsi that still have a secret or syncset
+// for the clusterdeployment
+func (r *ReconcileDeadmansSnitchIntegration) legacyFinalizerOwners(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment) ([]deadmanssnitchv1alpha1.DeadmansSnitchIntegration, error) {
+	dmsiList := &deadmanssnitchv1alpha1.DeadmansSnitchIntegrationList{}
+	err := r.client.List(context.TODO(), dmsiList)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	for _, other := range dmsiList.Items {
+		if other.Name != dmsi.Name || other.Namespace == dmsi.Namespace {
+			continue
+		}
+		secretExist, syncSetExist, err := r.snitchResourcesExist(&other, clusterdeployment)
+		if err != nil {
+			return nil, err
+		}
+		if secretExist || syncSetExist {
+			owners = append(owners, other)
+		}
+	}
+	return owners, nil
+}
+
+// removeClusterDeploymentFinalizer removes the finalizer of the dmsi from the clusterdeployment once its DMS resources are deleted.
+// The legacy finalizer is only removed when no other dmsi sharing it has resources left for the clusterdeployment.
+func (r *ReconcileDeadmansSnitchIntegration) removeClusterDeploymentFinalizer(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment *hivev1.ClusterDeployment) error {
+	baseToPatch := client.MergeFrom(clusterdeployment.DeepCopy())
+	utils.DeleteFinalizer(clusterdeployment, finalizerName(dmsi))
+
+	legacyFinalizer := legacyFinalizerName(dmsi)
+	if utils.HasFinalizer(clusterdeployment, legacyFinalizer) {
+		owners, err := r.legacyFinalizerOwners(dmsi, clusterdeployment)
+		if err != nil {
+			return err
+		}
+		if len(owners) == 0 {
+			utils.DeleteFinalizer(clusterdeployment, legacyFinalizer)
+		}
+	}
+
+	return r.client.Patch(context.TODO(), clusterdeployment, baseToPatch)
+}
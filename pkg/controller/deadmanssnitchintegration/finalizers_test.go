@@ -0,0 +1,182 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	hiveapis "github.com/openshift/hive/apis"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// return a dmsi with the name of testDeadMansSnitchIntegration in another namespace
+func testSameNamedDeadMansSnitchIntegration() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Namespace = "other-namespace"
+	dmsi.Spec.SnitchNamePostFix = "other-postfix"
+	return dmsi
+}
+
+// return a ClusterDeployment carrying the legacy finalizer shared by the same named dmsis
+func testLegacyFinalizerClusterDeployment() *hivev1.ClusterDeployment {
+	cd := testClusterDeployment()
+	cd.Finalizers = []string{legacyDeadMansSnitchFinalizer}
+	return cd
+}
+
+// return the secret of testSameNamedDeadMansSnitchIntegration for testClusterDeployment
+func testSameNamedSecretRef() runtime.Object {
+	secret := testSecretRef()
+	secret.Name = testClusterName + "-other-postfix-" + config.RefSecretPostfix
+	return secret
+}
+
+func TestFinalizerName(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	assert.True(t, strings.HasPrefix(finalizerName(dmsi), DeadMansSnitchFinalizerHashPrefix))
+	assert.Equal(t, finalizerName(dmsi), finalizerName(testDeadMansSnitchIntegration()))
+	assert.NotEqual(t, finalizerName(dmsi), finalizerName(testSameNamedDeadMansSnitchIntegration()))
+
+	dmsi.Name = strings.Repeat("a", 253)
+	assert.Len(t, finalizerName(dmsi), len(DeadMansSnitchFinalizerHashPrefix)+finalizerHashLength)
+}
+
+func TestMigrateClusterDeploymentFinalizer(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	dmsi := testDeadMansSnitchIntegration()
+	sameNamed := testSameNamedDeadMansSnitchIntegration()
+
+	tests := []struct {
+		name               string
+		clusterDeployment  *hivev1.ClusterDeployment
+		localObjects       []runtime.Object
+		expectedFinalizers []string
+	}{
+		{
+			name:               "legacy finalizer",
+			clusterDeployment:  testLegacyFinalizerClusterDeployment(),
+			localObjects:       []runtime.Object{dmsi, sameNamed},
+			expectedFinalizers: []string{finalizerName(dmsi)},
+		},
+		{
+			name:               "legacy finalizer shared with a same named dmsi",
+			clusterDeployment:  testLegacyFinalizerClusterDeployment(),
+			localObjects:       []runtime.Object{dmsi, sameNamed, testSameNamedSecretRef()},
+			expectedFinalizers: []string{finalizerName(dmsi), finalizerName(sameNamed)},
+		},
+		{
+			name:               "migrated finalizer",
+			clusterDeployment:  testClusterDeployment(),
+			localObjects:       []runtime.Object{dmsi, sameNamed, testSameNamedSecretRef()},
+			expectedFinalizers: []string{finalizerName(dmsi)},
+		},
+		{
+			name: "legacy finalizer of a deleted cluster",
+			clusterDeployment: func() *hivev1.ClusterDeployment {
+				cd := deletedClusterDeployment()
+				cd.Finalizers = []string{legacyDeadMansSnitchFinalizer}
+				return cd
+			}(),
+			localObjects:       []runtime.Object{dmsi, sameNamed},
+			expectedFinalizers: []string{legacyDeadMansSnitchFinalizer},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &ReconcileDeadmansSnitchIntegration{
+				client:   fakekubeclient.NewFakeClient(append(test.localObjects, test.clusterDeployment)...),
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(100),
+			}
+
+			err := r.migrateClusterDeploymentFinalizer(dmsi, test.clusterDeployment)
+			assert.NoError(t, err)
+
+			cd := &hivev1.ClusterDeployment{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: testClusterName, Namespace: testNamespace}, cd)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedFinalizers, cd.Finalizers)
+		})
+	}
+}
+
+func TestMigrateDMSIFinalizer(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Finalizers = []string{legacyDeadMansSnitchFinalizer}
+	r := &ReconcileDeadmansSnitchIntegration{
+		client:   fakekubeclient.NewFakeClient(dmsi),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+	}
+
+	err = r.migrateDMSIFinalizer(dmsi)
+	assert.NoError(t, err)
+
+	migrated := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: dmsi.Name, Namespace: dmsi.Namespace}, migrated)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{deadMansSnitchFinalizer}, migrated.Finalizers)
+}
+
+func TestRemoveClusterDeploymentFinalizer(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	dmsi := testDeadMansSnitchIntegration()
+	sameNamed := testSameNamedDeadMansSnitchIntegration()
+
+	tests := []struct {
+		name               string
+		localObjects       []runtime.Object
+		expectedFinalizers []string
+	}{
+		{
+			name:         "legacy finalizer",
+			localObjects: []runtime.Object{dmsi, sameNamed},
+		},
+		{
+			name:               "legacy finalizer still needed by a same named dmsi",
+			localObjects:       []runtime.Object{dmsi, sameNamed, testSameNamedSecretRef()},
+			expectedFinalizers: []string{legacyDeadMansSnitchFinalizer},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cd := deletedClusterDeployment()
+			cd.Finalizers = []string{legacyDeadMansSnitchFinalizer, deadMansSnitchFinalizer}
+			r := &ReconcileDeadmansSnitchIntegration{
+				client:   fakekubeclient.NewFakeClient(append(test.localObjects, cd)...),
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(100),
+			}
+
+			err := r.removeClusterDeploymentFinalizer(dmsi, cd)
+			assert.NoError(t, err)
+			if test.expectedFinalizers == nil {
+				assert.Empty(t, cd.Finalizers)
+			} else {
+				assert.Equal(t, test.expectedFinalizers, cd.Finalizers)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/maintenance"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileMaintenance pauses the snitch of a cluster while DeadmansSnitchMaintenanceWindows selecting it are open,
+// and unpauses it once the last of them closed.
+// The windows holding the snitch paused are recorded in the maintenance.PausedAnnotation of the hub secret, so
+// overlapping windows don't unpause it early, and a restarted operator knows which snitches it paused.
+// A snitch paused by someone else before the windows opened is left alone.
+func (r *ReconcileDeadmansSnitchIntegration) reconcileMaintenance(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc heartbeat.Provider, snitch *heartbeat.Heartbeat) (*heartbeat.Heartbeat, error) {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
+
+	windows := &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowList{}
+	err := r.client.List(context.TODO(), windows, &client.ListOptions{})
+	if err != nil {
+		return snitch, err
+	}
+	active := maintenance.ActiveWindows(windows.Items, cd.Labels, time.Now())
+
+	dmsSecret := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+	secret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: dmsSecret, Namespace: cd.Namespace}, secret)
+	if err != nil {
+		return snitch, err
+	}
+	holders := maintenance.Holders(secret.Annotations)
+	activeSet, holderSet := sets.NewString(active...), sets.NewString(holders...)
+	if activeSet.Equal(holderSet) {
+		return snitch, nil
+	}
+
+	snitchName, err := getSnitchName(dmsi, *cd)
+	if err != nil {
+		return snitch, err
+	}
+	if snitch == nil {
+		snitch, _, err = findSnitch(dmsc, secret, snitchName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, pausedForHibernation := secret.Annotations[snitchPausedAnnotation]
+	snitchPaused := snitch != nil && snitch.Status == heartbeat.StatusPaused
+
+	if len(holders) == 0 && snitchPaused && !pausedForHibernation {
+		logger.Info(fmt.Sprint("Snitch was paused before the maintenance window opened, leaving it alone:", snitchName))
+		return snitch, nil
+	}
+
+	if len(active) > 0 {
+		// record the windows before pausing, so a snitch paused by a failing reconcile is still unpaused
+		secret.Annotations = maintenance.SetHolders(secret.Annotations, active)
+		err = r.client.Update(context.TODO(), secret)
+		if err != nil {
+			return snitch, err
+		}
+		if snitch != nil && !snitchPaused {
+			logger.Info(fmt.Sprint("Pausing snitch for maintenance:", snitchName), "Windows", active)
+			err = dmsc.Pause(snitch.Token)
+			if err != nil {
+				return snitch, err
+			}
+			snitch.Status = heartbeat.StatusPaused
+		}
+		r.recordMaintenanceEvents(windows.Items, activeSet.Difference(holderSet).List(), dmsi, "SnitchPausedForMaintenance",
+			"Paused snitch %s of clusterdeployment %s/%s for maintenance window %s", snitchName, cd)
+		r.recordMaintenanceEvents(windows.Items, holderSet.Difference(activeSet).List(), dmsi, "SnitchReleasedByMaintenance",
+			"Snitch %s of clusterdeployment %s/%s is no longer held paused by maintenance window %s", snitchName, cd)
+		return snitch, nil
+	}
+
+	// the last window holding the snitch closed. A hibernating cluster keeps its snitch paused until it resumes.
+	if snitch != nil && !pausedForHibernation {
+		logger.Info(fmt.Sprint("Unpausing snitch after maintenance:", snitchName), "Windows", holders)
+		err = dmsc.Unpause(snitch.Token)
+		if err != nil {
+			return snitch, err
+		}
+	}
+	secret.Annotations = maintenance.SetHolders(secret.Annotations, nil)
+	err = r.client.Update(context.TODO(), secret)
+	if err != nil {
+		return snitch, err
+	}
+	r.recordMaintenanceEvents(windows.Items, holders, dmsi, "SnitchUnpausedAfterMaintenance",
+		"Unpaused snitch %s of clusterdeployment %s/%s after maintenance window %s closed", snitchName, cd)
+	return snitch, nil
+}
+
+// recordMaintenanceEvents records an event about the snitch of cd on the dmsi, naming the windows with keys,
+// and on each of these windows that still exists
+func (r *ReconcileDeadmansSnitchIntegration) recordMaintenanceEvents(windows []deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow, keys []string, dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, reason, messageFmt, snitchName string, cd *hivev1.ClusterDeployment) {
+	if len(keys) == 0 {
+		return
+	}
+	r.recorder.Eventf(dmsi, corev1.EventTypeNormal, reason, messageFmt, snitchName, cd.Namespace, cd.Name, strings.Join(keys, ", "))
+	for i := range windows {
+		key := maintenance.Key(&windows[i])
+		for _, k := range keys {
+			if k == key {
+				r.recorder.Eventf(&windows[i], corev1.EventTypeNormal, reason, messageFmt, snitchName, cd.Namespace, cd.Name, key)
+			}
+		}
+	}
+}
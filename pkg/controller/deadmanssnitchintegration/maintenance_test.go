@@ -0,0 +1,199 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	mockdms "github.com/openshift/deadmanssnitch-operator/pkg/dmsclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	"github.com/openshift/deadmanssnitch-operator/pkg/maintenance"
+	hiveapis "github.com/openshift/hive/apis"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// return a one-off maintenance window selecting testClusterDeployment that opened at start
+func testMaintenanceWindow(name string, start time.Time, duration time.Duration) *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow {
+	startTime := metav1.NewTime(start)
+	return &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: config.OperatorNamespace,
+		},
+		Spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+			StartTime: &startTime,
+			Duration:  metav1.Duration{Duration: duration},
+			ClusterDeploymentSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{config.ClusterDeploymentManagedLabel: "true"},
+			},
+		},
+	}
+}
+
+// return the secret and syncset of testClusterDeployment with the snitch held paused by the windows with keys
+func testMaintenanceSnitchResources(keys ...string) []runtime.Object {
+	resources := testExistingSnitchResources()
+	secret := resources[0].(*corev1.Secret)
+	secret.Annotations = maintenance.SetHolders(secret.Annotations, keys)
+	return resources
+}
+
+func TestReconcileMaintenance(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	pausedSnitch := testLiveSnitch(snitchName)
+	pausedSnitch.Status = "paused"
+	openWindow := testMaintenanceWindow("open", now.Add(-time.Hour), 2*time.Hour)
+	otherOpenWindow := testMaintenanceWindow("other-open", now.Add(-time.Minute), time.Hour)
+	closedWindow := testMaintenanceWindow("closed", now.Add(-3*time.Hour), time.Hour)
+
+	tests := []struct {
+		name            string
+		localObjects    []runtime.Object
+		setupDMSMock    func(r *mockdms.MockClientMockRecorder)
+		expectedHolders []string
+	}{
+		{
+			name:         "Test Pausing snitch when maintenance window opens",
+			localObjects: append([]runtime.Object{openWindow}, testExistingSnitchResources()...),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+				r.List(testSnitchToken).Return(pausedSnitch, nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(2)
+				r.Pause(testSnitchToken).Return(nil).Times(1)
+				r.Unpause(gomock.Any()).Times(0)
+			},
+			expectedHolders: []string{maintenance.Key(openWindow)},
+		},
+		{
+			name:         "Test Unpausing snitch when maintenance window closes",
+			localObjects: append([]runtime.Object{closedWindow}, testMaintenanceSnitchResources(maintenance.Key(closedWindow))...),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(snitchName), nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(2)
+				r.Unpause(testSnitchToken).Return(nil).Times(1)
+				r.Pause(gomock.Any()).Times(0)
+			},
+			expectedHolders: []string{},
+		},
+		{
+			name:         "Test Unpausing snitch when maintenance window is deleted",
+			localObjects: testMaintenanceSnitchResources(maintenance.Key(openWindow)),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(snitchName), nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(2)
+				r.Unpause(testSnitchToken).Return(nil).Times(1)
+				r.Pause(gomock.Any()).Times(0)
+			},
+			expectedHolders: []string{},
+		},
+		{
+			name: "Test Keeping snitch paused while overlapping window is open",
+			localObjects: append([]runtime.Object{closedWindow, otherOpenWindow},
+				testMaintenanceSnitchResources(maintenance.Key(closedWindow), maintenance.Key(otherOpenWindow))...),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(pausedSnitch, nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(2)
+				r.Pause(gomock.Any()).Times(0)
+				r.Unpause(gomock.Any()).Times(0)
+			},
+			expectedHolders: []string{maintenance.Key(otherOpenWindow)},
+		},
+		{
+			name:         "Test Joining overlapping window",
+			localObjects: append([]runtime.Object{openWindow, otherOpenWindow}, testMaintenanceSnitchResources(maintenance.Key(openWindow))...),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(pausedSnitch, nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(2)
+				r.Pause(gomock.Any()).Times(0)
+				r.Unpause(gomock.Any()).Times(0)
+			},
+			expectedHolders: []string{maintenance.Key(openWindow), maintenance.Key(otherOpenWindow)},
+		},
+		{
+			name:         "Test Leaving snitch paused by someone else alone",
+			localObjects: append([]runtime.Object{openWindow}, testExistingSnitchResources()...),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(1)
+				r.List(testSnitchToken).Return(pausedSnitch, nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{pausedSnitch}, nil).Times(2)
+				r.Pause(gomock.Any()).Times(0)
+				r.Unpause(gomock.Any()).Times(0)
+			},
+			expectedHolders: []string{},
+		},
+		{
+			name:         "Test Ignoring closed maintenance window",
+			localObjects: append([]runtime.Object{closedWindow}, testExistingSnitchResources()...),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(snitchName), nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(2)
+				r.Pause(gomock.Any()).Times(0)
+				r.Unpause(gomock.Any()).Times(0)
+			},
+			expectedHolders: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegration(),
+			}, test.localObjects...))
+			test.setupDMSMock(mocks.mockDMSClient.EXPECT())
+			defer mocks.mockCtrl.Finish()
+
+			rdms := &ReconcileDeadmansSnitchIntegration{
+				client:   mocks.fakeKubeClient,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(100),
+				dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+					return mocks.mockDMSClient, nil
+				},
+			}
+
+			// reconciling again must not pause or unpause the snitch again
+			for i := 0; i < 3; i++ {
+				_, err := rdms.Reconcile(reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      testDeadMansSnitchintegrationName,
+						Namespace: config.OperatorNamespace,
+					},
+				})
+				assert.NoError(t, err, "Unexpected Error with Reconcile (%d of 3)", i+1)
+			}
+
+			secret := &corev1.Secret{}
+			err := mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{
+				Name:      testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix,
+				Namespace: testNamespace,
+			}, secret)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedHolders, maintenance.Holders(secret.Annotations))
+		})
+	}
+}
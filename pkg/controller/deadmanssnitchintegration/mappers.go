@@ -7,14 +7,14 @@ import (
 	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	"github.com/sirupsen/logrus"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// clusterDeploymentToDeadMansSnitchIntegrationsMapper queues the ClusterDeployment for every DeadMansSnitchIntegration
+// that selects it, or has a finalizer on it and has to tear its snitch down
 type clusterDeploymentToDeadMansSnitchIntegrationsMapper struct {
 	Client client.Client
 }
@@ -26,25 +26,11 @@ func (m clusterDeploymentToDeadMansSnitchIntegrationsMapper) Map(mo handler.MapO
 		return []reconcile.Request{}
 	}
 
-	requests := []reconcile.Request{}
-	for _, dmsi := range dmsilist.Items {
-		selector, err := metav1.LabelSelectorAsSelector(&dmsi.Spec.ClusterDeploymentSelector)
-		if err != nil {
-			logrus.Debug(err)
-			continue
-		}
-		if selector.Matches(labels.Set(mo.Meta.GetLabels())) {
-			requests = append(requests, reconcile.Request{
-				NamespacedName: types.NamespacedName{
-					Name:      dmsi.Name,
-					Namespace: dmsi.Namespace,
-				}},
-			)
-		}
-	}
-	return requests
+	return clusterDeploymentRequests(dmsilist.Items, mo.Meta)
 }
 
+// ownedByClusterDeploymentToDeadMansSnitchIntegrationsMapper queues the ClusterDeployments owning a SyncSet or Secret
+// for the DeadMansSnitchIntegrations they belong to
 type ownedByClusterDeploymentToDeadMansSnitchIntegrationsMapper struct {
 	Client client.Client
 }
@@ -73,23 +59,56 @@ func (m ownedByClusterDeploymentToDeadMansSnitchIntegrationsMapper) Map(mo handl
 	}
 
 	requests := []reconcile.Request{}
-	for _, dmi := range dmsilist.Items {
-		selector, err := metav1.LabelSelectorAsSelector(&dmi.Spec.ClusterDeploymentSelector)
+	for _, cd := range relevantClusterDeployments {
+		requests = append(requests, clusterDeploymentRequests(dmsilist.Items, cd)...)
+	}
+	return requests
+}
+
+// maintenanceWindowToDeadMansSnitchIntegrationsMapper queues the ClusterDeployments selected by a maintenance window
+// when it changes, and those it paused, for the DeadMansSnitchIntegrations they belong to
+type maintenanceWindowToDeadMansSnitchIntegrationsMapper struct {
+	Client client.Client
+}
+
+func (m maintenanceWindowToDeadMansSnitchIntegrationsMapper) Map(mo handler.MapObject) []reconcile.Request {
+	window, ok := mo.Object.(*deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow)
+	if !ok {
+		return []reconcile.Request{}
+	}
+
+	dmsilist := &deadmanssnitchv1alpha1.DeadmansSnitchIntegrationList{}
+	err := m.Client.List(context.TODO(), dmsilist, &client.ListOptions{})
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	clusterDeployments, err := listSelectedClusterDeployments(m.Client, &window.Spec.ClusterDeploymentSelector)
+	if err != nil {
+		logrus.Debug(err)
+		clusterDeployments = []hivev1.ClusterDeployment{}
+	}
+	// the clusterdeployments the window paused may not match its selector anymore
+	selected := map[types.NamespacedName]bool{}
+	for _, cd := range clusterDeployments {
+		selected[types.NamespacedName{Name: cd.Name, Namespace: cd.Namespace}] = true
+	}
+	for _, paused := range window.Status.PausedClusterDeployments {
+		if selected[types.NamespacedName{Name: paused.Name, Namespace: paused.Namespace}] {
+			continue
+		}
+		cd := &hivev1.ClusterDeployment{}
+		err := m.Client.Get(context.TODO(), client.ObjectKey{Name: paused.Name, Namespace: paused.Namespace}, cd)
 		if err != nil {
 			logrus.Debug(err)
 			continue
 		}
+		clusterDeployments = append(clusterDeployments, *cd)
+	}
 
-		for _, cd := range relevantClusterDeployments {
-			if selector.Matches(labels.Set(cd.ObjectMeta.GetLabels())) {
-				requests = append(requests, reconcile.Request{
-					NamespacedName: types.NamespacedName{
-						Name:      dmi.Name,
-						Namespace: dmi.Namespace,
-					}},
-				)
-			}
-		}
+	requests := []reconcile.Request{}
+	for i := range clusterDeployments {
+		requests = append(requests, clusterDeploymentRequests(dmsilist.Items, &clusterDeployments[i])...)
 	}
 	return requests
 }
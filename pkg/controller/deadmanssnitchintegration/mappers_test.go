@@ -63,19 +63,43 @@ func TestClusterDeploymentToDeadMansSnitchIntegrationsMapper(t *testing.T) {
 			},
 			mapObject: handler.MapObject{
 				Meta: &metav1.ObjectMeta{
-					Labels: map[string]string{"test": "test"},
+					Name:      "cd",
+					Namespace: "cd-namespace",
+					Labels:    map[string]string{"test": "test"},
 				},
 			},
 			expectedRequests: []reconcile.Request{
 				{
 					NamespacedName: types.NamespacedName{
-						Name:      "test1",
+						Name:      "test1/cd-namespace/cd",
 						Namespace: "test",
 					},
 				},
 				{
 					NamespacedName: types.NamespacedName{
-						Name:      "test2",
+						Name:      "test2/cd-namespace/cd",
+						Namespace: "test",
+					},
+				},
+			},
+		},
+		{
+			name:   "clusterDeploymentToDeadMansSnitchIntegrations: not matching anymore but finalized",
+			mapper: clusterDeploymentToDeadMansSnitchIntegrations,
+			objects: []runtime.Object{
+				deadMansSnitchIntegration("test1", map[string]string{"test": "test"}),
+			},
+			mapObject: handler.MapObject{
+				Meta: &metav1.ObjectMeta{
+					Name:       "cd",
+					Namespace:  "cd-namespace",
+					Finalizers: []string{finalizerName(deadMansSnitchIntegration("test1", nil))},
+				},
+			},
+			expectedRequests: []reconcile.Request{
+				{
+					NamespacedName: types.NamespacedName{
+						Name:      "test1/cd-namespace/cd",
 						Namespace: "test",
 					},
 				},
@@ -98,6 +122,44 @@ func TestClusterDeploymentToDeadMansSnitchIntegrationsMapper(t *testing.T) {
 			},
 			expectedRequests: []reconcile.Request{},
 		},
+		{
+			name:   "maintenanceWindowToDeadMansSnitchIntegrations: selected and paused clusterdeployments",
+			mapper: maintenanceWindowToDeadMansSnitchIntegrations,
+			objects: []runtime.Object{
+				deadMansSnitchIntegration("test1", map[string]string{"test": "test"}),
+				mapperClusterDeployment("selected", map[string]string{"test": "test", "window": "test"}),
+				mapperClusterDeployment("paused", map[string]string{"test": "test"}),
+				mapperClusterDeployment("other", map[string]string{"test": "test"}),
+			},
+			mapObject: handler.MapObject{
+				Object: &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{
+					Spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+						ClusterDeploymentSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{"window": "test"},
+						},
+					},
+					Status: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowStatus{
+						PausedClusterDeployments: []deadmanssnitchv1alpha1.ClusterDeploymentReference{
+							{Name: "paused", Namespace: "cd-namespace"},
+						},
+					},
+				},
+			},
+			expectedRequests: []reconcile.Request{
+				{
+					NamespacedName: types.NamespacedName{
+						Name:      "test1/cd-namespace/selected",
+						Namespace: "test",
+					},
+				},
+				{
+					NamespacedName: types.NamespacedName{
+						Name:      "test1/cd-namespace/paused",
+						Namespace: "test",
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -120,6 +182,20 @@ func ownedByClusterDeploymentToDeadMansSnitchIntegrations(client client.Client)
 	return ownedByClusterDeploymentToDeadMansSnitchIntegrationsMapper{Client: client}
 }
 
+func maintenanceWindowToDeadMansSnitchIntegrations(client client.Client) handler.Mapper {
+	return maintenanceWindowToDeadMansSnitchIntegrationsMapper{Client: client}
+}
+
+func mapperClusterDeployment(name string, labels map[string]string) *hivev1.ClusterDeployment {
+	return &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "cd-namespace",
+			Labels:    labels,
+		},
+	}
+}
+
 func deadMansSnitchIntegration(name string, labels map[string]string) *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
 	return &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{
 		ObjectMeta: metav1.ObjectMeta{
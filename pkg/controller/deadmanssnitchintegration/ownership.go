@@ -0,0 +1,188 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/deadmanssnitch-operator/config"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// OwnerMarkerTagPrefix starts the tag stamped on every snitch the operator manages. It is followed by short hashes of
+	// the hub ID, the UID of the dmsi and the UID of the clusterdeployment, separated by dashes.
+	OwnerMarkerTagPrefix = "dms-owner-"
+	// ownerHashLength keeps the owner marker short enough for the tags of every heartbeat provider
+	ownerHashLength = 8
+)
+
+// snitchOwnership tells how a snitch found by name relates to the dmsi and clusterdeployment looking for their snitch
+type snitchOwnership int
+
+const (
+	// snitchOwned carries the owner marker of the dmsi and clusterdeployment
+	snitchOwned snitchOwnership = iota
+	// snitchAdoptable was orphaned by another dmsi of the hub for the same clusterdeployment,
+	// or is unmarked while the dmsi adopts unmarked snitches
+	snitchAdoptable
+	// snitchConflicting belongs to another hub or clusterdeployment, to a dmsi that still manages it,
+	// or is unmarked while the dmsi doesn't adopt unmarked snitches
+	snitchConflicting
+)
+
+// ownerHash returns the short hash of an ID stamped in the owner marker
+func ownerHash(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])[:ownerHashLength]
+}
+
+// ownerMarker returns the owner marker tag of the snitch of the clusterdeployment managed by the dmsi on this hub
+func ownerMarker(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment) string {
+	return OwnerMarkerTagPrefix + strings.Join([]string{
+		ownerHash(config.GetHubID()),
+		ownerHash(string(dmsi.UID)),
+		ownerHash(string(cd.UID)),
+	}, "-")
+}
+
+// snitchOwnerMarker returns the owner marker among the tags of a snitch, or an empty string for an unmarked snitch
+func snitchOwnerMarker(snitch heartbeat.Heartbeat) string {
+	for _, tag := range snitch.Tags {
+		if strings.HasPrefix(tag, OwnerMarkerTagPrefix) {
+			return tag
+		}
+	}
+	return ""
+}
+
+// withOwnerMarker returns the tags of a snitch with its owner marker replaced by the marker of the dmsi and clusterdeployment
+func withOwnerMarker(tags []string, dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment) []string {
+	result := []string{}
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, OwnerMarkerTagPrefix) {
+			result = append(result, tag)
+		}
+	}
+	return append(result, ownerMarker(dmsi, cd))
+}
+
+// getSnitchOwnership compares the owner marker of a snitch with the marker of the dmsi and clusterdeployment
+func getSnitchOwnership(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment, snitch heartbeat.Heartbeat) snitchOwnership {
+	marker := snitchOwnerMarker(snitch)
+	if marker == "" {
+		if dmsi.Spec.AdoptUnmarkedSnitches {
+			return snitchAdoptable
+		}
+		return snitchConflicting
+	}
+
+	desired := ownerMarker(dmsi, cd)
+	if marker == desired {
+		return snitchOwned
+	}
+	// the snitch of a deleted dmsi is adopted by the next dmsi of the hub managing the same clusterdeployment
+	hashes := strings.Split(strings.TrimPrefix(marker, OwnerMarkerTagPrefix), "-")
+	desiredHashes := strings.Split(strings.TrimPrefix(desired, OwnerMarkerTagPrefix), "-")
+	if len(hashes) == 3 && hashes[0] == desiredHashes[0] && hashes[2] == desiredHashes[2] && hasTag(snitch.Tags, OrphanedSnitchTag) {
+		return snitchAdoptable
+	}
+	return snitchConflicting
+}
+
+// selectSnitch returns the snitch the dmsi may use for the clusterdeployment out of the snitches found by name,
+// preferring an owned snitch over an adoptable one, along with the snitches it conflicts with
+func selectSnitch(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd hivev1.ClusterDeployment, snitches []heartbeat.Heartbeat) (*heartbeat.Heartbeat, []heartbeat.Heartbeat) {
+	var owned, adoptable *heartbeat.Heartbeat
+	conflicts := []heartbeat.Heartbeat{}
+	for i := range snitches {
+		switch getSnitchOwnership(dmsi, cd, snitches[i]) {
+		case snitchOwned:
+			if owned == nil {
+				owned = &snitches[i]
+			}
+		case snitchAdoptable:
+			if adoptable == nil {
+				adoptable = &snitches[i]
+			}
+		default:
+			conflicts = append(conflicts, snitches[i])
+		}
+	}
+	if owned != nil {
+		return owned, conflicts
+	}
+	return adoptable, conflicts
+}
+
+// withoutSnitch returns the snitches other than the one identified by token
+func withoutSnitch(snitches []heartbeat.Heartbeat, token string) []heartbeat.Heartbeat {
+	result := []heartbeat.Heartbeat{}
+	for _, snitch := range snitches {
+		if snitch.Token != token {
+			result = append(result, snitch)
+		}
+	}
+	return result
+}
+
+// reportOwnershipConflicts raises an event for each snitch named like the snitch of the clusterdeployment that the dmsi leaves alone
+func (r *ReconcileDeadmansSnitchIntegration) reportOwnershipConflicts(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, conflicts []heartbeat.Heartbeat) {
+	for _, snitch := range conflicts {
+		owner := snitchOwnerMarker(snitch)
+		if owner == "" {
+			owner = "nobody, and adoptUnmarkedSnitches is not set"
+		}
+		log.Info("Leaving alone snitch owned by someone else", "DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name,
+			"cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace, "SnitchName", snitch.Name, "Owner", owner)
+		r.recorder.Eventf(dmsi, corev1.EventTypeWarning, "SnitchOwnershipConflict",
+			"Snitch %s named like the snitch of clusterdeployment %s/%s is owned by %s", snitch.Name, cd.Namespace, cd.Name, owner)
+	}
+}
+
+// secretSnitchMatcher returns a function telling if a snitch is the one the secret of the clusterdeployment points to,
+// by the token it records or by its check-in URL. The secret of a cluster set up before the owner markers points to an unmarked snitch.
+func (r *ReconcileDeadmansSnitchIntegration) secretSnitchMatcher(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment) (func(snitch heartbeat.Heartbeat) bool, error) {
+	secret := &corev1.Secret{}
+	secretName := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: cd.Namespace}, secret)
+	if err != nil && !k8errors.IsNotFound(err) {
+		return nil, fmt.Errorf("Unable to get the secret of the snitch: %w", err)
+	}
+	token := secret.Annotations[snitchTokenAnnotation]
+	checkInURL := string(secret.Data[config.KeySnitchURL])
+
+	return func(snitch heartbeat.Heartbeat) bool {
+		return (token != "" && snitch.Token == token) || (checkInURL != "" && snitch.CheckInURL == checkInURL)
+	}, nil
+}
+
+// ownedSnitches filters the snitches found by name down to the ones the dmsi may tear down for the clusterdeployment:
+// the ones it owns or may adopt, and the one checked in to by the cluster through its secret
+func (r *ReconcileDeadmansSnitchIntegration) ownedSnitches(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, snitches []heartbeat.Heartbeat) ([]heartbeat.Heartbeat, error) {
+	pointedToBySecret, err := r.secretSnitchMatcher(dmsi, cd)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := []heartbeat.Heartbeat{}
+	conflicts := []heartbeat.Heartbeat{}
+	for _, snitch := range snitches {
+		if pointedToBySecret(snitch) || getSnitchOwnership(dmsi, *cd, snitch) != snitchConflicting {
+			owned = append(owned, snitch)
+			continue
+		}
+		conflicts = append(conflicts, snitch)
+	}
+	r.reportOwnershipConflicts(dmsi, cd, conflicts)
+	return owned, nil
+}
@@ -0,0 +1,228 @@
+package deadmanssnitchintegration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hiveapis "github.com/openshift/hive/apis"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// return an owner marker built from the hub ID, dmsi UID and clusterdeployment UID
+func testMarker(hubID, dmsiUID, clusterUID string) string {
+	return OwnerMarkerTagPrefix + strings.Join([]string{ownerHash(hubID), ownerHash(dmsiUID), ownerHash(clusterUID)}, "-")
+}
+
+func TestOwnerMarker(t *testing.T) {
+	marker := testOwnerMarker()
+	assert.Equal(t, testMarker(config.GetHubID(), "", testUID), marker)
+	assert.Len(t, marker, len(OwnerMarkerTagPrefix)+3*ownerHashLength+2)
+
+	cd := testClusterDeployment()
+	cd.UID = "other"
+	assert.NotEqual(t, marker, ownerMarker(testDeadMansSnitchIntegration(), *cd))
+
+	assert.Equal(t, []string{testTag, marker}, withOwnerMarker([]string{testTag, testMarker("other-hub", "", testUID)}, testDeadMansSnitchIntegration(), *testClusterDeployment()))
+}
+
+func TestGetSnitchOwnership(t *testing.T) {
+	tests := []struct {
+		name                  string
+		tags                  []string
+		adoptUnmarkedSnitches bool
+		expected              snitchOwnership
+	}{
+		{
+			name:     "owned",
+			tags:     testSnitchTags(),
+			expected: snitchOwned,
+		},
+		{
+			name:     "orphaned by another dmsi of the hub",
+			tags:     []string{testMarker(config.GetHubID(), "deleted-dmsi", testUID), OrphanedSnitchTag},
+			expected: snitchAdoptable,
+		},
+		{
+			name:     "managed by another dmsi of the hub",
+			tags:     []string{testMarker(config.GetHubID(), "other-dmsi", testUID)},
+			expected: snitchConflicting,
+		},
+		{
+			name:     "orphaned by another hub",
+			tags:     []string{testMarker("other-hub", "", testUID), OrphanedSnitchTag},
+			expected: snitchConflicting,
+		},
+		{
+			name:     "orphaned for another cluster",
+			tags:     []string{testMarker(config.GetHubID(), "deleted-dmsi", "other-cluster"), OrphanedSnitchTag},
+			expected: snitchConflicting,
+		},
+		{
+			name:     "unmarked",
+			tags:     []string{testTag},
+			expected: snitchConflicting,
+		},
+		{
+			name:                  "unmarked and adopting unmarked snitches",
+			tags:                  []string{testTag},
+			adoptUnmarkedSnitches: true,
+			expected:              snitchAdoptable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dmsi := testDeadMansSnitchIntegration()
+			dmsi.Spec.AdoptUnmarkedSnitches = test.adoptUnmarkedSnitches
+			snitch := heartbeat.Heartbeat{Token: testSnitchToken, Tags: test.tags}
+			assert.Equal(t, test.expected, getSnitchOwnership(dmsi, *testClusterDeployment(), snitch))
+		})
+	}
+}
+
+func TestSelectSnitch(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Spec.AdoptUnmarkedSnitches = true
+	foreign := heartbeat.Heartbeat{Token: "foreign", Tags: []string{testMarker("other-hub", "", testUID)}}
+	unmarked := heartbeat.Heartbeat{Token: "unmarked"}
+	owned := heartbeat.Heartbeat{Token: testSnitchToken, Tags: testSnitchTags()}
+
+	selected, conflicts := selectSnitch(dmsi, *testClusterDeployment(), []heartbeat.Heartbeat{foreign, unmarked, owned})
+	if assert.NotNil(t, selected) {
+		assert.Equal(t, testSnitchToken, selected.Token)
+	}
+	assert.Equal(t, []heartbeat.Heartbeat{foreign}, conflicts)
+
+	selected, _ = selectSnitch(dmsi, *testClusterDeployment(), []heartbeat.Heartbeat{foreign, unmarked})
+	if assert.NotNil(t, selected) {
+		assert.Equal(t, "unmarked", selected.Token)
+	}
+
+	selected, conflicts = selectSnitch(testDeadMansSnitchIntegration(), *testClusterDeployment(), []heartbeat.Heartbeat{foreign, unmarked})
+	assert.Nil(t, selected)
+	assert.Len(t, conflicts, 2)
+}
+
+func TestReconcileOwnershipConflict(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, []runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testDeadMansSnitchIntegration(),
+	})
+	defer mocks.mockCtrl.Finish()
+
+	// a snitch of the same name created on another hub is left alone, and a snitch of the hub is created next to it
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	foreign := dmsclient.Snitch{Name: snitchName, Token: "foreign", CheckInURL: "https://nosnch.in/foreign", Tags: []string{testMarker("other-hub", "", testUID)}}
+	created := testLiveSnitch(snitchName)
+	created.Status = heartbeat.StatusPending
+	r := mocks.mockDMSClient.EXPECT()
+	r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{foreign}, nil).Times(1)
+	r.Create(gomock.Any()).Return(created, nil).Times(1)
+	r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{foreign, created}, nil).Times(2)
+	r.CheckIn(gomock.Any()).Return(nil).Times(1)
+	r.Update(gomock.Any()).Times(0)
+	r.Delete(gomock.Any()).Times(0)
+
+	recorder := record.NewFakeRecorder(100)
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+	assert.NoError(t, err)
+
+	assert.True(t, verifySecretExists(mocks.fakeKubeClient, &SecretEntry{name: testResourceName(), snitchURL: testSnitchURL, clusterDeploymentRefName: testClusterName}))
+	if assert.NotEmpty(t, recorder.Events) {
+		assert.Contains(t, <-recorder.Events, "SnitchOwnershipConflict")
+	}
+}
+
+func TestReconcileLegacySnitchWithoutSyncSet(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// the cluster was set up before the owner markers and lost its syncset, its secret still points to the unmarked snitch
+	mocks := setupDefaultMocks(t, []runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testDeadMansSnitchIntegration(),
+		testExistingSnitchResources()[0],
+	})
+	defer mocks.mockCtrl.Finish()
+
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	legacy := testLiveSnitch(snitchName)
+	legacy.Tags = []string{testTag}
+	r := mocks.mockDMSClient.EXPECT()
+	r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{legacy}, nil).Times(1)
+	r.Update(dmsclient.Snitch{Token: testSnitchToken, Tags: testSnitchTags()}).Return(testLiveSnitch(snitchName), nil).Times(1)
+	r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).AnyTimes()
+	r.List(testSnitchToken).Return(testLiveSnitch(snitchName), nil).AnyTimes()
+	r.Create(gomock.Any()).Times(0)
+	r.Delete(gomock.Any()).Times(0)
+
+	recorder := record.NewFakeRecorder(100)
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+	assert.NoError(t, err)
+
+	// the snitch the secret points to is taken over instead of creating another one
+	assert.True(t, verifySecretExists(mocks.fakeKubeClient, &SecretEntry{name: testResourceName(), snitchURL: testSnitchURL, clusterDeploymentRefName: testClusterName}))
+	assert.True(t, verifySyncSetExists(mocks.fakeKubeClient, &SyncSetEntry{name: testResourceName(), referencedSecretName: testResourceName(), clusterDeploymentRefName: testClusterName}))
+	for len(recorder.Events) > 0 {
+		assert.NotContains(t, <-recorder.Events, "SnitchOwnershipConflict")
+	}
+}
+
+func TestOwnedSnitches(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, testExistingSnitchResources())
+	defer mocks.mockCtrl.Finish()
+
+	recorder := record.NewFakeRecorder(100)
+	rdms := &ReconcileDeadmansSnitchIntegration{client: mocks.fakeKubeClient, scheme: scheme.Scheme, recorder: recorder}
+
+	// an unmarked snitch the cluster checks in to through its secret was created before the owner marker
+	legacy := heartbeat.Heartbeat{Token: testSnitchToken, CheckInURL: testSnitchURL}
+	owned := heartbeat.Heartbeat{Token: "owned", Tags: testSnitchTags()}
+	foreign := heartbeat.Heartbeat{Token: "foreign", Tags: []string{testMarker("other-hub", "", testUID)}}
+
+	snitches, err := rdms.ownedSnitches(testDeadMansSnitchIntegration(), testClusterDeployment(), []heartbeat.Heartbeat{legacy, owned, foreign})
+	assert.NoError(t, err)
+	assert.Equal(t, []heartbeat.Heartbeat{legacy, owned}, snitches)
+	assert.Len(t, recorder.Events, 1)
+}
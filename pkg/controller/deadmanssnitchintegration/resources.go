@@ -0,0 +1,137 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DMSILabel is set on the secrets and syncsets of a dmsi on the hub, with the hash of its namespace and name as value
+const DMSILabel = "dms.managed.openshift.io/dmsi"
+
+// resourceLabels returns the labels the secrets and syncsets of the dmsi carry
+func resourceLabels(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) map[string]string {
+	return map[string]string{DMSILabel: dmsiHash(dmsi)}
+}
+
+// setLabels adds the labels to the object, keeping the labels set by others
+func setLabels(object metav1.Object, labels map[string]string) {
+	objectLabels := object.GetLabels()
+	if objectLabels == nil {
+		objectLabels = map[string]string{}
+	}
+	for key, value := range labels {
+		objectLabels[key] = value
+	}
+	object.SetLabels(objectLabels)
+}
+
+// setClusterDeploymentOwner makes the clusterdeployment the controller of the object for garbage collection.
+// The owner reference of a clusterdeployment deleted and recreated with the same name is replaced.
+func setClusterDeploymentOwner(cd *hivev1.ClusterDeployment, object metav1.Object, scheme *runtime.Scheme) error {
+	ownerReferences := []metav1.OwnerReference{}
+	for _, ownerReference := range object.GetOwnerReferences() {
+		isController := ownerReference.Controller != nil && *ownerReference.Controller
+		if isController && ownerReference.Kind == "ClusterDeployment" && ownerReference.UID != cd.UID {
+			continue
+		}
+		ownerReferences = append(ownerReferences, ownerReference)
+	}
+	object.SetOwnerReferences(ownerReferences)
+	return controllerutil.SetControllerReference(cd, object, scheme)
+}
+
+// syncSecret patches the labels and owner reference of the existing secret of the clusterdeployment.
+// Its check-in data is kept in sync with the snitch by updateSnitch.
+func (r *ReconcileDeadmansSnitchIntegration) syncSecret(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment) error {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
+	secret := &corev1.Secret{}
+	secretName := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: cd.Namespace}, secret)
+	if err != nil {
+		if k8errors.IsNotFound(err) {
+			return nil
+		}
+		logger.Error(err, "Failed to fetch secret")
+		return err
+	}
+
+	original := secret.DeepCopy()
+	setLabels(secret, resourceLabels(dmsi))
+	if err := setClusterDeploymentOwner(cd, secret, r.scheme); err != nil {
+		logger.Error(err, "Error setting controller reference on secret")
+		return err
+	}
+	if equality.Semantic.DeepEqual(original.ObjectMeta, secret.ObjectMeta) {
+		return nil
+	}
+
+	logger.Info("Updating secret to the desired state")
+	if err := r.client.Patch(context.TODO(), secret, client.MergeFrom(original)); err != nil {
+		logger.Error(err, "Failed to update secret")
+		return err
+	}
+	r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "SecretUpdated", "Updated secret %s/%s", secret.Namespace, secret.Name)
+	return nil
+}
+
+// syncSyncSet creates the syncset of the clusterdeployment, or patches the existing one to the desired state of the dmsi.
+// The syncset is in Sync mode, so hive deletes the secret from its previous target on the cluster when the target changes.
+func (r *ReconcileDeadmansSnitchIntegration) syncSyncSet(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment) error {
+	logger := log.WithValues("DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name, "cluster-deployment.Name:", cd.Name, "cluster-deployment.Namespace:", cd.Namespace)
+	ssName := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+	desired := newSyncSet(cd.Namespace, ssName, cd.Name, dmsi)
+
+	syncSet := &hivev1.SyncSet{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: ssName, Namespace: cd.Namespace}, syncSet)
+	if k8errors.IsNotFound(err) {
+		logger.Info("SyncSet not found, Creating a new SyncSet")
+		if err := setClusterDeploymentOwner(cd, desired, r.scheme); err != nil {
+			logger.Error(err, "Error setting controller reference on syncset")
+			return err
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			logger.Error(err, "Error creating syncset")
+			return err
+		}
+		logger.Info("Done creating a new SyncSet")
+		return nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to fetch syncset")
+		return err
+	}
+
+	// only the fields set by newSyncSet are synced, so fields defaulted by hive don't cause an update on every reconcile
+	original := syncSet.DeepCopy()
+	setLabels(syncSet, desired.Labels)
+	if err := setClusterDeploymentOwner(cd, syncSet, r.scheme); err != nil {
+		logger.Error(err, "Error setting controller reference on syncset")
+		return err
+	}
+	syncSet.Spec.ClusterDeploymentRefs = desired.Spec.ClusterDeploymentRefs
+	syncSet.Spec.ResourceApplyMode = desired.Spec.ResourceApplyMode
+	syncSet.Spec.Secrets = desired.Spec.Secrets
+	if equality.Semantic.DeepEqual(original, syncSet) {
+		return nil
+	}
+
+	logger.Info("Updating syncset to the desired state")
+	if err := r.client.Patch(context.TODO(), syncSet, client.MergeFrom(original)); err != nil {
+		logger.Error(err, "Failed to update syncset")
+		return err
+	}
+	r.recorder.Eventf(dmsi, corev1.EventTypeNormal, "SyncSetUpdated", "Updated syncset %s/%s", syncSet.Namespace, syncSet.Name)
+	return nil
+}
@@ -0,0 +1,208 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hiveapis "github.com/openshift/hive/apis"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// return the name of the secret and syncset of testClusterDeployment
+func testResourceName() string {
+	return testClusterName + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix
+}
+
+// return a dmsi syncing the secret to another target than testDeadMansSnitchIntegration
+func testMovedTargetDeadMansSnitchIntegration() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Spec.TargetSecretRef.Namespace = "openshift-monitoring-moved"
+	return dmsi
+}
+
+// return the secret and syncset as created before they carried labels and owner references
+func testUnlabelledSnitchResources() []runtime.Object {
+	resources := testExistingSnitchResources()
+	for _, resource := range resources {
+		resource.(metav1.Object).SetLabels(nil)
+	}
+	return resources
+}
+
+func TestSetClusterDeploymentOwner(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	controller := true
+	secret := newDMSSecret(testNamespace, testResourceName(), testSnitchURL)
+	secret.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "hive.openshift.io/v1", Kind: "ClusterDeployment", Name: testClusterName, UID: "recreated", Controller: &controller},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other"},
+	}
+
+	cd := testClusterDeployment()
+	assert.NoError(t, setClusterDeploymentOwner(cd, secret, scheme.Scheme))
+	if assert.Len(t, secret.OwnerReferences, 2) {
+		assert.Equal(t, "other", string(secret.OwnerReferences[0].UID))
+		assert.Equal(t, cd.UID, secret.OwnerReferences[1].UID)
+		assert.True(t, *secret.OwnerReferences[1].Controller)
+	}
+
+	// the owner reference is kept once it is in place
+	assert.NoError(t, setClusterDeploymentOwner(cd, secret, scheme.Scheme))
+	assert.Len(t, secret.OwnerReferences, 2)
+}
+
+func TestSyncSyncSet(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		localObjects   []runtime.Object
+		dmsi           *deadmanssnitchv1alpha1.DeadmansSnitchIntegration
+		expectedEvents int
+	}{
+		{
+			name:         "missing syncset",
+			localObjects: []runtime.Object{testClusterDeployment()},
+			dmsi:         testDeadMansSnitchIntegration(),
+		},
+		{
+			name:           "syncset without labels and owner reference",
+			localObjects:   append([]runtime.Object{testClusterDeployment()}, testUnlabelledSnitchResources()...),
+			dmsi:           testDeadMansSnitchIntegration(),
+			expectedEvents: 1,
+		},
+		{
+			name:           "changed target secret",
+			localObjects:   append([]runtime.Object{testClusterDeployment()}, testExistingSnitchResources()...),
+			dmsi:           testMovedTargetDeadMansSnitchIntegration(),
+			expectedEvents: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, test.localObjects)
+			defer mocks.mockCtrl.Finish()
+
+			recorder := record.NewFakeRecorder(100)
+			rdms := &ReconcileDeadmansSnitchIntegration{
+				client:   mocks.fakeKubeClient,
+				scheme:   scheme.Scheme,
+				recorder: recorder,
+			}
+
+			assert.NoError(t, rdms.syncSyncSet(test.dmsi, testClusterDeployment()))
+			assert.Len(t, recorder.Events, test.expectedEvents)
+
+			syncSet := &hivev1.SyncSet{}
+			err := mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: testResourceName(), Namespace: testNamespace}, syncSet)
+			assert.NoError(t, err)
+			assert.Equal(t, dmsiHash(test.dmsi), syncSet.Labels[DMSILabel])
+			assert.Equal(t, hivev1.SyncResourceApplyMode, syncSet.Spec.ResourceApplyMode)
+			if assert.Len(t, syncSet.OwnerReferences, 1) {
+				assert.Equal(t, testUID, string(syncSet.OwnerReferences[0].UID))
+			}
+			if assert.Len(t, syncSet.Spec.Secrets, 1) {
+				assert.Equal(t, test.dmsi.Spec.TargetSecretRef.Namespace, syncSet.Spec.Secrets[0].TargetRef.Namespace)
+			}
+
+			// the syncset is left alone once it is in the desired state
+			assert.NoError(t, rdms.syncSyncSet(test.dmsi, testClusterDeployment()))
+			assert.Len(t, recorder.Events, test.expectedEvents)
+		})
+	}
+}
+
+func TestSyncSecret(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, append([]runtime.Object{testClusterDeployment()}, testUnlabelledSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	recorder := record.NewFakeRecorder(100)
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+	}
+
+	assert.NoError(t, rdms.syncSecret(testDeadMansSnitchIntegration(), testClusterDeployment()))
+	assert.NoError(t, rdms.syncSecret(testDeadMansSnitchIntegration(), testClusterDeployment()))
+	assert.Len(t, recorder.Events, 1)
+
+	secret := &corev1.Secret{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: testResourceName(), Namespace: testNamespace}, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, dmsiHash(testDeadMansSnitchIntegration()), secret.Labels[DMSILabel])
+	assert.Equal(t, testSnitchURL, string(secret.Data[config.KeySnitchURL]))
+	if assert.Len(t, secret.OwnerReferences, 1) {
+		assert.Equal(t, testUID, string(secret.OwnerReferences[0].UID))
+	}
+
+	// a clusterdeployment without a secret is left to createSecret
+	other := testClusterDeployment()
+	other.Spec.ClusterName = "other"
+	assert.NoError(t, rdms.syncSecret(testDeadMansSnitchIntegration(), other))
+}
+
+func TestReconcileChangedTargetSecretRef(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		testMovedTargetDeadMansSnitchIntegration(),
+	}, testExistingSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	r := mocks.mockDMSClient.EXPECT()
+	r.ListAll().Return([]dmsclient.Snitch{testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)}, nil).AnyTimes()
+	r.Create(gomock.Any()).Times(0)
+	r.Delete(gomock.Any()).Times(0)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+	assert.NoError(t, err)
+
+	// the existing syncset now maps the secret to the new target, hive removes it from the old one
+	syncSet := &hivev1.SyncSet{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: testResourceName(), Namespace: testNamespace}, syncSet)
+	assert.NoError(t, err)
+	if assert.Len(t, syncSet.Spec.Secrets, 1) {
+		assert.Equal(t, "openshift-monitoring-moved", syncSet.Spec.Secrets[0].TargetRef.Namespace)
+		assert.Equal(t, testResourceName(), syncSet.Spec.Secrets[0].SourceRef.Name)
+	}
+}
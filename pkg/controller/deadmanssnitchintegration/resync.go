@@ -0,0 +1,31 @@
+package deadmanssnitchintegration
+
+import (
+	"time"
+
+	"github.com/openshift/deadmanssnitch-operator/config"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// resyncJitterFactor is the largest fraction of the resync period added to it,
+	// so DMSIs created or changed together don't keep resyncing together
+	resyncJitterFactor = 0.1
+)
+
+// resyncPeriod returns how often every ClusterDeployment of the dmsi is reconciled, catching the changes made
+// at the heartbeat provider, snitches that never left pending and a rotated API key
+func resyncPeriod(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) time.Duration {
+	if dmsi.Spec.ResyncPeriod != nil && dmsi.Spec.ResyncPeriod.Duration > 0 {
+		return dmsi.Spec.ResyncPeriod.Duration
+	}
+	return config.GetResyncPeriod()
+}
+
+// resyncResult requeues the dmsi for its next resync
+func resyncResult(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) reconcile.Result {
+	return reconcile.Result{RequeueAfter: wait.Jitter(resyncPeriod(dmsi), resyncJitterFactor)}
+}
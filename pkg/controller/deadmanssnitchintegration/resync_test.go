@@ -0,0 +1,30 @@
+package deadmanssnitchintegration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/deadmanssnitch-operator/config"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResyncPeriod(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	assert.Equal(t, config.GetResyncPeriod(), resyncPeriod(dmsi))
+
+	dmsi.Spec.ResyncPeriod = &metav1.Duration{Duration: 10 * time.Minute}
+	assert.Equal(t, 10*time.Minute, resyncPeriod(dmsi))
+}
+
+func TestResyncResult(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Spec.ResyncPeriod = &metav1.Duration{Duration: 10 * time.Minute}
+
+	for i := 0; i < 100; i++ {
+		result := resyncResult(dmsi)
+		assert.GreaterOrEqual(t, int64(result.RequeueAfter), int64(10*time.Minute))
+		assert.LessOrEqual(t, int64(result.RequeueAfter), int64(11*time.Minute))
+	}
+
+}
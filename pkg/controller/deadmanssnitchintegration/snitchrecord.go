@@ -0,0 +1,78 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"time"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// The hub secret of a cluster records its snitch in annotations, so the snitch is looked up by token instead of
+// searching DMS by name. Annotations aren't synced to the cluster by the syncset, unlike the data of the secret.
+const (
+	// snitchTokenAnnotation holds the token of the snitch of the cluster
+	snitchTokenAnnotation = "dms.managed.openshift.io/snitch-token"
+	// snitchNameAnnotation holds the name of the snitch of the cluster
+	snitchNameAnnotation = "dms.managed.openshift.io/snitch-name"
+	// snitchCreatedAnnotation holds when the snitch was created, or when it was recorded for providers that don't report it
+	snitchCreatedAnnotation = "dms.managed.openshift.io/snitch-created-at"
+)
+
+// secretHasSnitch checks if the secret of a cluster records the snitch
+func secretHasSnitch(secret *corev1.Secret, snitch heartbeat.Heartbeat) bool {
+	return secret.Annotations[snitchTokenAnnotation] == snitch.Token &&
+		secret.Annotations[snitchNameAnnotation] == snitch.Name
+}
+
+// setSecretSnitch records the token, name and creation time of the snitch on the secret of a cluster.
+// The creation time is kept as long as the secret records the same snitch.
+func setSecretSnitch(secret *corev1.Secret, snitch heartbeat.Heartbeat) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	if secret.Annotations[snitchTokenAnnotation] != snitch.Token || secret.Annotations[snitchCreatedAnnotation] == "" {
+		createdAt := snitch.CreatedAt
+		if createdAt == "" {
+			createdAt = time.Now().UTC().Format(time.RFC3339)
+		}
+		secret.Annotations[snitchCreatedAnnotation] = createdAt
+	}
+	secret.Annotations[snitchTokenAnnotation] = snitch.Token
+	secret.Annotations[snitchNameAnnotation] = snitch.Name
+}
+
+// recordedSnitches returns the snitches the dmsi tears down for the clusterdeployment: the snitch recorded on its secret,
+// or for a secret created before its snitch was recorded, the snitches named like it the dmsi owns
+func (r *ReconcileDeadmansSnitchIntegration) recordedSnitches(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, cd *hivev1.ClusterDeployment, dmsc heartbeat.Provider) ([]heartbeat.Heartbeat, error) {
+	secret := &corev1.Secret{}
+	secretName := utils.SecretName(cd.Spec.ClusterName, dmsi.Spec.SnitchNamePostFix)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: cd.Namespace}, secret)
+	if err != nil && !k8errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if token := secret.Annotations[snitchTokenAnnotation]; token != "" {
+		snitch, err := dmsc.FindByToken(token)
+		if err != nil || snitch == nil {
+			return nil, err
+		}
+		return []heartbeat.Heartbeat{*snitch}, nil
+	}
+
+	snitchName, err := getSnitchName(dmsi, *cd)
+	if err != nil {
+		return nil, err
+	}
+	snitches, err := dmsc.FindByName(snitchName)
+	if err != nil {
+		return nil, err
+	}
+	return r.ownedSnitches(dmsi, cd, snitches)
+}
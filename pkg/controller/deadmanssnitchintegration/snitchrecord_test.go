@@ -0,0 +1,168 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	mockdms "github.com/openshift/deadmanssnitch-operator/pkg/dmsclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/heartbeat"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hiveapis "github.com/openshift/hive/apis"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// return the secret and syncset of testClusterDeployment with the secret recording the snitch
+func testRecordedSnitchResources() []runtime.Object {
+	resources := testExistingSnitchResources()
+	secret := resources[0].(*corev1.Secret)
+	secret.Annotations = map[string]string{
+		snitchTokenAnnotation:   testSnitchToken,
+		snitchNameAnnotation:    testClusterName + ".base.domain-" + snitchNamePostFix,
+		snitchCreatedAnnotation: "2020-01-01T00:00:00.000Z",
+	}
+	return resources
+}
+
+func TestSetSecretSnitch(t *testing.T) {
+	secret := newDMSSecret(testNamespace, testResourceName(), testSnitchURL)
+	snitch := heartbeat.Heartbeat{Token: testSnitchToken, Name: "snitch", CreatedAt: "2020-01-01T00:00:00.000Z"}
+	assert.False(t, secretHasSnitch(secret, snitch))
+
+	setSecretSnitch(secret, snitch)
+	assert.True(t, secretHasSnitch(secret, snitch))
+	assert.Equal(t, "2020-01-01T00:00:00.000Z", secret.Annotations[snitchCreatedAnnotation])
+
+	// the creation time is kept while the secret records the same snitch, and recorded for providers that don't report it
+	setSecretSnitch(secret, heartbeat.Heartbeat{Token: testSnitchToken, Name: "renamed"})
+	assert.Equal(t, "renamed", secret.Annotations[snitchNameAnnotation])
+	assert.Equal(t, "2020-01-01T00:00:00.000Z", secret.Annotations[snitchCreatedAnnotation])
+
+	setSecretSnitch(secret, heartbeat.Heartbeat{Token: "recreated", Name: "snitch"})
+	assert.Equal(t, "recreated", secret.Annotations[snitchTokenAnnotation])
+	assert.NotEqual(t, "2020-01-01T00:00:00.000Z", secret.Annotations[snitchCreatedAnnotation])
+	assert.NotEmpty(t, secret.Annotations[snitchCreatedAnnotation])
+}
+
+func TestReconcileRecordedSnitch(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	snitchName := testClusterName + ".base.domain-" + snitchNamePostFix
+	recreated := testLiveSnitch(snitchName)
+	recreated.Token = "recreated"
+	recreated.CheckInURL = testRecreatedSnitchURL
+	pending := recreated
+	pending.Status = heartbeat.StatusPending
+
+	tests := []struct {
+		name          string
+		localObjects  []runtime.Object
+		setupDMSMock  func(r *mockdms.MockClientMockRecorder)
+		expectedToken string
+		expectedURL   string
+	}{
+		{
+			name:         "legacy secret is backfilled",
+			localObjects: testExistingSnitchResources(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.ListAll().Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+				r.List(testSnitchToken).Return(testLiveSnitch(snitchName), nil).Times(1)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(1)
+			},
+			expectedToken: testSnitchToken,
+			expectedURL:   testSnitchURL,
+		},
+		{
+			name:         "recorded snitch is looked up by token",
+			localObjects: testRecordedSnitchResources(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.List(testSnitchToken).Return(testLiveSnitch(snitchName), nil).Times(2)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{testLiveSnitch(snitchName)}, nil).Times(2)
+			},
+			expectedToken: testSnitchToken,
+			expectedURL:   testSnitchURL,
+		},
+		{
+			name:         "recorded snitch deleted in DMS is recreated",
+			localObjects: testRecordedSnitchResources(),
+			setupDMSMock: func(r *mockdms.MockClientMockRecorder) {
+				r.List(testSnitchToken).Return(dmsclient.Snitch{}, &dmsclient.APIError{Operation: "describe", StatusCode: 404}).Times(1)
+				r.Create(gomock.Any()).Return(pending, nil).Times(1)
+				r.CheckIn(gomock.Any()).Return(nil).Times(1)
+				r.List("recreated").Return(recreated, nil).Times(1)
+				r.FindSnitchesByName(snitchName).Return([]dmsclient.Snitch{recreated}, nil).Times(2)
+			},
+			expectedToken: "recreated",
+			expectedURL:   testRecreatedSnitchURL,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, append([]runtime.Object{
+				testClusterDeployment(),
+				testSecret(),
+				testDeadMansSnitchIntegration(),
+			}, test.localObjects...))
+			defer mocks.mockCtrl.Finish()
+			r := mocks.mockDMSClient.EXPECT()
+			test.setupDMSMock(r)
+			r.Update(gomock.Any()).Times(0)
+			r.Delete(gomock.Any()).Times(0)
+
+			rdms := &ReconcileDeadmansSnitchIntegration{
+				client:   mocks.fakeKubeClient,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(100),
+				dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+					return mocks.mockDMSClient, nil
+				},
+			}
+
+			for i := 0; i < 2; i++ {
+				_, err := rdms.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}})
+				assert.NoError(t, err, "Unexpected Error with Reconcile (%d of 2)", i+1)
+			}
+
+			secret := &corev1.Secret{}
+			err := mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: testResourceName(), Namespace: testNamespace}, secret)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedToken, secret.Annotations[snitchTokenAnnotation])
+			assert.Equal(t, snitchName, secret.Annotations[snitchNameAnnotation])
+			assert.NotEmpty(t, secret.Annotations[snitchCreatedAnnotation])
+			assert.Equal(t, test.expectedURL, string(secret.Data[config.KeySnitchURL]))
+		})
+	}
+}
+
+func TestDeleteRecordedSnitch(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	mocks := setupDefaultMocks(t, append([]runtime.Object{testClusterDeployment()}, testRecordedSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	// the recorded snitch is deleted by token, without searching DMS by name
+	r := mocks.mockDMSClient.EXPECT()
+	r.List(testSnitchToken).Return(testLiveSnitch(testClusterName+".base.domain-"+snitchNamePostFix), nil).Times(1)
+	r.Delete(testSnitchToken).Return(true, nil).Times(1)
+	r.FindSnitchesByName(gomock.Any()).Times(0)
+	r.ListAll().Times(0)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{client: mocks.fakeKubeClient, scheme: scheme.Scheme, recorder: record.NewFakeRecorder(100)}
+	err = rdms.deleteDMSClusterDeployment(testDeadMansSnitchIntegration(), testClusterDeployment(), heartbeat.NewDMSProvider(mocks.mockDMSClient))
+	assert.NoError(t, err)
+	assert.True(t, verifyNoSecret(mocks.fakeKubeClient, &SecretEntry{name: testResourceName()}))
+}
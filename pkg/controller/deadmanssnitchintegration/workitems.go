@@ -0,0 +1,200 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The work items of the controller are either a whole DeadmansSnitchIntegration, reconciling every ClusterDeployment it
+// selects or has a finalizer on, or a (DeadmansSnitchIntegration, ClusterDeployment) pair reconciling a single ClusterDeployment.
+// Whole DeadmansSnitchIntegrations are only queued when their spec changes, so ClusterDeployment, SyncSet and Secret events
+// cost a single ClusterDeployment reconcile instead of one for every ClusterDeployment on the hub.
+const (
+	// requestSeparator joins the name of the DeadmansSnitchIntegration and the namespace and name of the ClusterDeployment
+	// in the name of a pair request. Kubernetes names can't contain it, so pairs are told apart from whole DMSIs.
+	requestSeparator = "/"
+
+	// clusterDeploymentLabelIndex indexes ClusterDeployments by each of their labels as "key=value",
+	// to list the ClusterDeployments of a selector without going through all of them
+	clusterDeploymentLabelIndex = "dms.metadata.labels"
+	// clusterDeploymentFinalizerIndex indexes ClusterDeployments by the DeadmansSnitchIntegration finalizers they carry
+	clusterDeploymentFinalizerIndex = "dms.metadata.finalizers"
+)
+
+// dmsiSpecChangedPredicate passes the DeadmansSnitchIntegration updates that need a full reconcile: spec changes, which
+// bump the generation, and deletions. Status and finalizer updates only concern ClusterDeployments already queued on their own.
+var dmsiSpecChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.MetaOld == nil || e.MetaNew == nil {
+			return true
+		}
+		return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+			(e.MetaOld.GetDeletionTimestamp() == nil && e.MetaNew.GetDeletionTimestamp() != nil)
+	},
+}
+
+// dmsiRequest returns the request reconciling every ClusterDeployment of the dmsi
+func dmsiRequest(dmsi metav1.Object) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Name: dmsi.GetName(), Namespace: dmsi.GetNamespace()}}
+}
+
+// clusterDeploymentRequest returns the request reconciling a single ClusterDeployment of the dmsi
+func clusterDeploymentRequest(dmsi metav1.Object, clusterdeployment metav1.Object) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{
+		Name:      strings.Join([]string{dmsi.GetName(), clusterdeployment.GetNamespace(), clusterdeployment.GetName()}, requestSeparator),
+		Namespace: dmsi.GetNamespace(),
+	}}
+}
+
+// parseRequest returns the dmsi of the request, and the ClusterDeployment for pair requests
+func parseRequest(request reconcile.Request) (types.NamespacedName, *types.NamespacedName) {
+	parts := strings.Split(request.Name, requestSeparator)
+	if len(parts) != 3 {
+		return request.NamespacedName, nil
+	}
+	return types.NamespacedName{Name: parts[0], Namespace: request.Namespace},
+		&types.NamespacedName{Name: parts[2], Namespace: parts[1]}
+}
+
+// addIndexes registers the indexes of the controller with the cache of the manager
+func addIndexes(mgr manager.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.TODO(), &hivev1.ClusterDeployment{}, clusterDeploymentLabelIndex, clusterDeploymentLabels)
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.TODO(), &hivev1.ClusterDeployment{}, clusterDeploymentFinalizerIndex, clusterDeploymentFinalizers)
+	if err != nil {
+		return err
+	}
+	return mgr.GetFieldIndexer().IndexField(context.TODO(), &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}, apiKeySecretIndex, apiKeySecretRefs)
+}
+
+// clusterDeploymentLabels extracts the values of clusterDeploymentLabelIndex
+func clusterDeploymentLabels(obj runtime.Object) []string {
+	cd, ok := obj.(*hivev1.ClusterDeployment)
+	if !ok {
+		return nil
+	}
+	values := []string{}
+	for key, value := range cd.Labels {
+		values = append(values, key+"="+value)
+	}
+	return values
+}
+
+// clusterDeploymentFinalizers extracts the values of clusterDeploymentFinalizerIndex
+func clusterDeploymentFinalizers(obj runtime.Object) []string {
+	cd, ok := obj.(*hivev1.ClusterDeployment)
+	if !ok {
+		return nil
+	}
+	values := []string{}
+	for _, finalizer := range cd.Finalizers {
+		if strings.HasPrefix(finalizer, DeadMansSnitchFinalizerHashPrefix) || strings.HasPrefix(finalizer, DeadMansSnitchFinalizerPrefix) {
+			values = append(values, finalizer)
+		}
+	}
+	return values
+}
+
+// listSelectedClusterDeployments lists the ClusterDeployments matching selector. With matchLabels the label index narrows
+// the list down to the ClusterDeployments having one of them, instead of filtering every ClusterDeployment on the hub.
+func listSelectedClusterDeployments(c client.Client, selector *metav1.LabelSelector) ([]hivev1.ClusterDeployment, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := []client.ListOption{client.MatchingLabelsSelector{Selector: labelSelector}}
+	if len(selector.MatchLabels) > 0 {
+		keys := []string{}
+		for key := range selector.MatchLabels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		listOpts = append(listOpts, client.MatchingFields{clusterDeploymentLabelIndex: keys[0] + "=" + selector.MatchLabels[keys[0]]})
+	}
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	err = c.List(context.TODO(), clusterDeployments, listOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return clusterDeployments.Items, nil
+}
+
+// listFinalizedClusterDeployments lists the ClusterDeployments carrying the finalizer, or the legacy finalizer, of the dmsi
+func listFinalizedClusterDeployments(c client.Client, dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) ([]hivev1.ClusterDeployment, error) {
+	finalized := []hivev1.ClusterDeployment{}
+	seen := map[types.UID]bool{}
+	for _, finalizer := range []string{finalizerName(dmsi), legacyFinalizerName(dmsi)} {
+		clusterDeployments := &hivev1.ClusterDeploymentList{}
+		err := c.List(context.TODO(), clusterDeployments, client.MatchingFields{clusterDeploymentFinalizerIndex: finalizer})
+		if err != nil {
+			return nil, err
+		}
+		for _, cd := range clusterDeployments.Items {
+			if seen[cd.UID] || !utils.HasFinalizer(&cd, finalizer) {
+				continue
+			}
+			seen[cd.UID] = true
+			finalized = append(finalized, cd)
+		}
+	}
+	return finalized, nil
+}
+
+// selectsClusterDeployment is true when the dmsi needs a snitch for the clusterdeployment
+func selectsClusterDeployment(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment metav1.Object) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&dmsi.Spec.ClusterDeploymentSelector)
+	if err != nil {
+		return false, err
+	}
+	if !selector.Matches(labels.Set(clusterdeployment.GetLabels())) {
+		return false, nil
+	}
+	return !shouldSkipClusterDeployment(dmsi.Spec.ClusterDeploymentAnnotationsToSkip, clusterdeployment), nil
+}
+
+// shouldSkipClusterDeployment is true when the clusterdeployment has one of the annotations to skip
+func shouldSkipClusterDeployment(clusterDeploymentAnnotationsToSkip []deadmanssnitchv1alpha1.ClusterDeploymentAnnotationsToSkip, cd metav1.Object) bool {
+	for annoKey, annoVal := range cd.GetAnnotations() {
+		for _, skipper := range clusterDeploymentAnnotationsToSkip {
+			if annoKey == skipper.Name && annoVal == skipper.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clusterDeploymentRequests returns the pair requests of the clusterdeployment for every dmsi that selects it or has a finalizer on it
+func clusterDeploymentRequests(dmsis []deadmanssnitchv1alpha1.DeadmansSnitchIntegration, clusterdeployment metav1.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for i := range dmsis {
+		dmsi := &dmsis[i]
+		selected, err := selectsClusterDeployment(dmsi, clusterdeployment)
+		if err != nil {
+			log.Error(err, "Invalid clusterDeploymentSelector", "DeadMansSnitchIntegration.Namespace", dmsi.Namespace, "DMSI.Name", dmsi.Name)
+		}
+		if selected || hasFinalizer(dmsi, clusterdeployment) {
+			requests = append(requests, clusterDeploymentRequest(dmsi, clusterdeployment))
+		}
+	}
+	return requests
+}
@@ -0,0 +1,276 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/deadmanssnitch-operator/config"
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	mockdms "github.com/openshift/deadmanssnitch-operator/pkg/dmsclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+	hiveapis "github.com/openshift/hive/apis"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestParseRequest(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	cd := testClusterDeployment()
+
+	dmsiName, cdName := parseRequest(clusterDeploymentRequest(dmsi, cd))
+	assert.Equal(t, types.NamespacedName{Name: dmsi.Name, Namespace: dmsi.Namespace}, dmsiName)
+	if assert.NotNil(t, cdName) {
+		assert.Equal(t, types.NamespacedName{Name: cd.Name, Namespace: cd.Namespace}, *cdName)
+	}
+
+	dmsiName, cdName = parseRequest(reconcile.Request{NamespacedName: types.NamespacedName{Name: dmsi.Name, Namespace: dmsi.Namespace}})
+	assert.Equal(t, types.NamespacedName{Name: dmsi.Name, Namespace: dmsi.Namespace}, dmsiName)
+	assert.Nil(t, cdName)
+}
+
+func TestClusterDeploymentIndexes(t *testing.T) {
+	cd := testClusterDeployment()
+	cd.Finalizers = append(cd.Finalizers, legacyDeadMansSnitchFinalizer, "hive.openshift.io/deprovision")
+
+	assert.Equal(t, []string{config.ClusterDeploymentManagedLabel + "=true"}, clusterDeploymentLabels(cd))
+	assert.Equal(t, []string{deadMansSnitchFinalizer, legacyDeadMansSnitchFinalizer}, clusterDeploymentFinalizers(cd))
+	assert.Nil(t, clusterDeploymentLabels(testSecret()))
+}
+
+func TestSelectsClusterDeployment(t *testing.T) {
+	tests := []struct {
+		name              string
+		dmsi              *deadmanssnitchv1alpha1.DeadmansSnitchIntegration
+		clusterDeployment *hivev1.ClusterDeployment
+		expected          bool
+	}{
+		{
+			name:              "matching labels",
+			dmsi:              testDeadMansSnitchIntegration(),
+			clusterDeployment: testClusterDeployment(),
+			expected:          true,
+		},
+		{
+			name:              "not matching labels",
+			dmsi:              testDeadMansSnitchIntegration(),
+			clusterDeployment: nonManagedClusterDeployment(),
+		},
+		{
+			name:              "annotation to skip",
+			dmsi:              testDeadMansSnitchIntegrationWithSkips(),
+			clusterDeployment: testFakeClusterDeployment(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected, err := selectsClusterDeployment(test.dmsi, test.clusterDeployment)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, selected)
+		})
+	}
+}
+
+func TestDMSISpecChangedPredicate(t *testing.T) {
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Generation = 1
+
+	statusUpdate := dmsi.DeepCopy()
+	statusUpdate.Status.ManagedClusterDeployments = 1
+	assert.False(t, dmsiSpecChangedPredicate.Update(event.UpdateEvent{MetaOld: dmsi, ObjectOld: dmsi, MetaNew: statusUpdate, ObjectNew: statusUpdate}))
+
+	specUpdate := dmsi.DeepCopy()
+	specUpdate.Generation = 2
+	assert.True(t, dmsiSpecChangedPredicate.Update(event.UpdateEvent{MetaOld: dmsi, ObjectOld: dmsi, MetaNew: specUpdate, ObjectNew: specUpdate}))
+
+	deleted := dmsi.DeepCopy()
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	assert.True(t, dmsiSpecChangedPredicate.Update(event.UpdateEvent{MetaOld: dmsi, ObjectOld: dmsi, MetaNew: deleted, ObjectNew: deleted}))
+}
+
+func TestReconcileClusterDeploymentRequest(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	// the status of a full reconcile, with a cluster deleted since then
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Status.MatchedClusterDeployments = 2
+	dmsi.Status.Snitches = []deadmanssnitchv1alpha1.SnitchStatus{
+		{ClusterDeploymentName: "gone", ClusterDeploymentNamespace: testNamespace, Token: "gone"},
+	}
+
+	mocks := setupDefaultMocks(t, append([]runtime.Object{
+		testClusterDeployment(),
+		testSecret(),
+		dmsi,
+	}, testExistingSnitchResources()...))
+	defer mocks.mockCtrl.Finish()
+
+	mocks.mockDMSClient.EXPECT().ListAll().Return([]dmsclient.Snitch{testLiveSnitch(testClusterName + ".base.domain-" + snitchNamePostFix)}, nil).Times(1)
+
+	rdms := &ReconcileDeadmansSnitchIntegration{
+		client:   mocks.fakeKubeClient,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mocks.mockDMSClient, nil
+		},
+	}
+
+	_, err = rdms.Reconcile(clusterDeploymentRequest(dmsi, testClusterDeployment()))
+	assert.NoError(t, err)
+
+	updated := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: dmsi.Name, Namespace: dmsi.Namespace}, updated)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Status.MatchedClusterDeployments)
+	assert.Equal(t, 2, updated.Status.ManagedClusterDeployments)
+	if assert.Len(t, updated.Status.Snitches, 2) {
+		assert.Equal(t, "gone", updated.Status.Snitches[0].ClusterDeploymentName)
+		assert.Equal(t, testClusterName, updated.Status.Snitches[1].ClusterDeploymentName)
+		assert.Equal(t, testSnitchToken, updated.Status.Snitches[1].Token)
+	}
+
+	gone := testClusterDeployment()
+	gone.Name = "gone"
+	_, err = rdms.Reconcile(clusterDeploymentRequest(dmsi, gone))
+	assert.NoError(t, err)
+
+	err = mocks.fakeKubeClient.Get(context.TODO(), types.NamespacedName{Name: dmsi.Name, Namespace: dmsi.Namespace}, updated)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated.Status.ManagedClusterDeployments)
+	if assert.Len(t, updated.Status.Snitches, 1) {
+		assert.Equal(t, testClusterName, updated.Status.Snitches[0].ClusterDeploymentName)
+	}
+}
+
+// benchmarkClusterCounts are the hub sizes the benchmarks run for
+var benchmarkClusterCounts = []int{10, 100, 1000}
+
+// setupBenchmark returns a reconciler for a hub with clusters ClusterDeployments, each with a snitch in sync with DMS
+func setupBenchmark(b *testing.B, clusters int) (*ReconcileDeadmansSnitchIntegration, []*hivev1.ClusterDeployment) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	if err != nil {
+		b.Fatal(err)
+	}
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	dmsi := testDeadMansSnitchIntegration()
+	dmsi.Finalizers = []string{deadMansSnitchFinalizer}
+	objects := []runtime.Object{testSecret(), dmsi}
+	clusterDeployments := []*hivev1.ClusterDeployment{}
+	snitches := []dmsclient.Snitch{}
+	for i := 0; i < clusters; i++ {
+		cd := testClusterDeployment()
+		cd.Name = fmt.Sprintf("%s-%d", testClusterName, i)
+		cd.UID = types.UID(fmt.Sprintf("%s-%d", testUID, i))
+		cd.Spec.ClusterName = cd.Name
+		clusterDeployments = append(clusterDeployments, cd)
+
+		snitch := testLiveSnitch(cd.Name + ".base.domain-" + snitchNamePostFix)
+		snitch.CheckInURL = fmt.Sprintf("%s/%d", testSnitchURL, i)
+		snitch.Tags = []string{testTag, ownerMarker(dmsi, *cd)}
+		snitches = append(snitches, snitch)
+
+		// the secret and syncset are in their desired state, so reconciles don't patch them
+		name := cd.Name + "-" + snitchNamePostFix + "-" + config.RefSecretPostfix
+		secret := newDMSSecret(testNamespace, name, snitch.CheckInURL)
+		secret.Labels = resourceLabels(dmsi)
+		syncSet := newSyncSet(testNamespace, name, cd.Name, dmsi)
+		for _, object := range []metav1.Object{secret, syncSet} {
+			if err := setClusterDeploymentOwner(cd, object, scheme.Scheme); err != nil {
+				b.Fatal(err)
+			}
+		}
+		objects = append(objects, cd, secret, syncSet)
+	}
+
+	mockCtrl := gomock.NewController(b)
+	mockDMSClient := mockdms.NewMockClient(mockCtrl)
+	mockDMSClient.EXPECT().ListAll().Return(snitches, nil).AnyTimes()
+	localmetrics.Collector = localmetrics.NewMetricsCollector()
+
+	return &ReconcileDeadmansSnitchIntegration{
+		client:   fakekubeclient.NewFakeClient(objects...),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(100),
+		dmsclient: func(apiKey string, collector *localmetrics.MetricsCollector, opts ...dmsclient.Option) (dmsclient.Client, error) {
+			return mockDMSClient, nil
+		},
+	}, clusterDeployments
+}
+
+// BenchmarkReconcileDeadmansSnitchIntegration measures a full reconcile of the dmsi, which every ClusterDeployment,
+// SyncSet and Secret event used to cost
+func BenchmarkReconcileDeadmansSnitchIntegration(b *testing.B) {
+	for _, clusters := range benchmarkClusterCounts {
+		b.Run(fmt.Sprintf("clusters=%d", clusters), func(b *testing.B) {
+			r, _ := setupBenchmark(b, clusters)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: testDeadMansSnitchintegrationName, Namespace: config.OperatorNamespace}}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := r.Reconcile(request)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkReconcileClusterDeployment measures the reconcile of a single (dmsi, ClusterDeployment) pair,
+// which a ClusterDeployment, SyncSet or Secret event costs now
+func BenchmarkReconcileClusterDeployment(b *testing.B) {
+	for _, clusters := range benchmarkClusterCounts {
+		b.Run(fmt.Sprintf("clusters=%d", clusters), func(b *testing.B) {
+			r, clusterDeployments := setupBenchmark(b, clusters)
+			request := clusterDeploymentRequest(testDeadMansSnitchIntegration(), clusterDeployments[0])
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := r.Reconcile(request)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkClusterDeploymentMapper measures mapping a ClusterDeployment event to its requests
+func BenchmarkClusterDeploymentMapper(b *testing.B) {
+	for _, clusters := range benchmarkClusterCounts {
+		b.Run(fmt.Sprintf("clusters=%d", clusters), func(b *testing.B) {
+			r, clusterDeployments := setupBenchmark(b, clusters)
+			mapper := clusterDeploymentToDeadMansSnitchIntegrationsMapper{Client: r.client}
+			mapObject := handler.MapObject{Meta: clusterDeployments[0], Object: clusterDeployments[0]}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if len(mapper.Map(mapObject)) != 1 {
+					b.Fatal("expected a single request")
+				}
+			}
+		})
+	}
+}
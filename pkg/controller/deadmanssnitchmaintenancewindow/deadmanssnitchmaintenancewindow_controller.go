@@ -0,0 +1,294 @@
+package deadmanssnitchmaintenancewindow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/deadmanssnitch-operator/config"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/maintenance"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_deadmanssnitchmaintenancewindow")
+
+// pausedSecretIndex indexes Secrets by the keys of the maintenance windows holding their snitch paused,
+// to list the hub secrets of a window without going through every Secret on the hub
+const pausedSecretIndex = "dms.maintenance.holders"
+
+// pausedSecretPredicate passes the events of the secrets held paused by maintenance windows, before or after the event.
+// Other Secrets can't concern a window.
+var pausedSecretPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return heldPaused(e.Meta)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return heldPaused(e.MetaOld) || heldPaused(e.MetaNew)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return heldPaused(e.Meta)
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return heldPaused(e.Meta)
+	},
+}
+
+// Add creates a new DeadmansSnitchMaintenanceWindow Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileDeadmansSnitchMaintenanceWindow{
+		client:   mgr.GetClient(),
+		recorder: mgr.GetEventRecorderFor(config.OperatorName),
+		now:      time.Now,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("deadmanssnitchmaintenancewindow-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource DeadmansSnitchMaintenanceWindow
+	err = c.Watch(&source.Kind{Type: &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	err = mgr.GetFieldIndexer().IndexField(context.TODO(), &corev1.Secret{}, pausedSecretIndex, pausedSecretHolders)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to Secrets. The DeadmansSnitchIntegration controller records the windows holding
+	// a snitch paused on its secret, queue a request for these windows to update their status.
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(pausedSecretToMaintenanceWindows),
+		},
+		pausedSecretPredicate,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// heldPaused reports whether obj is a secret recorded as held paused by maintenance windows
+func heldPaused(obj metav1.Object) bool {
+	return obj != nil && len(maintenance.Holders(obj.GetAnnotations())) > 0
+}
+
+// pausedSecretHolders extracts the values of pausedSecretIndex
+func pausedSecretHolders(obj runtime.Object) []string {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return nil
+	}
+	return maintenance.Holders(secret.Annotations)
+}
+
+// pausedSecretToMaintenanceWindows maps a secret to the maintenance windows holding its snitch paused
+func pausedSecretToMaintenanceWindows(mo handler.MapObject) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, key := range maintenance.Holders(mo.Meta.GetAnnotations()) {
+		parts := strings.SplitN(key, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: parts[0],
+				Name:      parts[1],
+			}},
+		)
+	}
+	return requests
+}
+
+// blank assignment to verify that ReconcileDeadmansSnitchMaintenanceWindow implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileDeadmansSnitchMaintenanceWindow{}
+
+// ReconcileDeadmansSnitchMaintenanceWindow reconciles a DeadmansSnitchMaintenanceWindow object.
+// It tracks when the window opens and closes in its status, and requeues itself for the next transition.
+// The snitches are paused and unpaused by the DeadmansSnitchIntegration controller, which watches the windows.
+type ReconcileDeadmansSnitchMaintenanceWindow struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client   client.Client
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+// Reconcile evaluates the schedule of a DeadmansSnitchMaintenanceWindow and records where it stands in its status
+func (r *ReconcileDeadmansSnitchMaintenanceWindow) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling DeadmansSnitchMaintenanceWindow")
+
+	window := &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, window)
+	if err != nil {
+		if k8errors.IsNotFound(err) {
+			// The DeadmansSnitchIntegration controller releases the snitches of a deleted window
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	originalStatus := window.Status.DeepCopy()
+	now := r.now()
+
+	paused, err := r.pausedClusterDeployments(window)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	window.Status.PausedClusterDeployments = paused
+
+	state, err := maintenance.Evaluate(window.Spec, now)
+	if err != nil {
+		if window.Status.Phase != deadmanssnitchv1alpha1.MaintenanceWindowPhaseInvalid {
+			r.recorder.Eventf(window, corev1.EventTypeWarning, "InvalidSchedule", "Maintenance window can't be evaluated: %v", err)
+		}
+		window.Status.Phase = deadmanssnitchv1alpha1.MaintenanceWindowPhaseInvalid
+		window.Status.ActiveUntil = nil
+		window.Status.NextStartTime = nil
+		setCondition(window, metav1.ConditionFalse, "InvalidSchedule", err.Error())
+		// the window is evaluated again once its spec changes
+		return reconcile.Result{}, r.updateStatus(window, originalStatus)
+	}
+
+	var requeueAfter time.Duration
+	wasActive := window.Status.Phase == deadmanssnitchv1alpha1.MaintenanceWindowPhaseActive
+	switch {
+	case state.Active:
+		if !wasActive {
+			reqLogger.Info(fmt.Sprintf("Maintenance window opened, closing at %s", state.End))
+			window.Status.LastStartTime = &metav1.Time{Time: state.Start}
+			r.recorder.Eventf(window, corev1.EventTypeNormal, "MaintenanceWindowOpened",
+				"Maintenance window opened, snitches of the selected clusterdeployments are paused until %s", state.End.UTC().Format(time.RFC3339))
+		}
+		window.Status.Phase = deadmanssnitchv1alpha1.MaintenanceWindowPhaseActive
+		window.Status.ActiveUntil = &metav1.Time{Time: state.End}
+		window.Status.NextStartTime = nil
+		setCondition(window, metav1.ConditionTrue, "WindowOpen", fmt.Sprintf("Open until %s", state.End.UTC().Format(time.RFC3339)))
+		requeueAfter = state.End.Sub(now)
+
+	default:
+		if wasActive {
+			reqLogger.Info("Maintenance window closed")
+			closedAt := now
+			if activeUntil := window.Status.ActiveUntil; activeUntil != nil && activeUntil.Time.Before(now) {
+				// the operator wasn't running when the window closed
+				closedAt = activeUntil.Time
+			}
+			window.Status.LastEndTime = &metav1.Time{Time: closedAt}
+			r.recorder.Eventf(window, corev1.EventTypeNormal, "MaintenanceWindowClosed",
+				"Maintenance window closed, snitches of the selected clusterdeployments are unpaused")
+		}
+		window.Status.ActiveUntil = nil
+		if state.Completed() {
+			if !wasActive && window.Status.LastStartTime == nil && !state.Start.IsZero() {
+				// the window opened and closed while the operator wasn't running
+				window.Status.LastStartTime = &metav1.Time{Time: state.Start}
+				window.Status.LastEndTime = &metav1.Time{Time: state.End}
+			}
+			window.Status.Phase = deadmanssnitchv1alpha1.MaintenanceWindowPhaseCompleted
+			window.Status.NextStartTime = nil
+			setCondition(window, metav1.ConditionFalse, "WindowCompleted", "The window won't open again")
+			break
+		}
+		window.Status.Phase = deadmanssnitchv1alpha1.MaintenanceWindowPhaseScheduled
+		window.Status.NextStartTime = &metav1.Time{Time: state.Next}
+		setCondition(window, metav1.ConditionFalse, "WindowClosed", fmt.Sprintf("Opens at %s", state.Next.UTC().Format(time.RFC3339)))
+		requeueAfter = state.Next.Sub(now)
+	}
+
+	err = r.updateStatus(window, originalStatus)
+	if err != nil {
+		reqLogger.Error(err, "Error updating maintenance window status")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// pausedClusterDeployments returns the clusterdeployments whose hub secret records window as holding their snitch paused
+func (r *ReconcileDeadmansSnitchMaintenanceWindow) pausedClusterDeployments(window *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow) ([]deadmanssnitchv1alpha1.ClusterDeploymentReference, error) {
+	key := maintenance.Key(window)
+	secrets := &corev1.SecretList{}
+	err := r.client.List(context.TODO(), secrets, client.MatchingFields{pausedSecretIndex: key})
+	if err != nil {
+		return nil, err
+	}
+
+	paused := []deadmanssnitchv1alpha1.ClusterDeploymentReference{}
+	for _, secret := range secrets.Items {
+		if !maintenance.IsHolder(secret.Annotations, key) {
+			continue
+		}
+		for _, or := range secret.OwnerReferences {
+			if or.APIVersion == hivev1.SchemeGroupVersion.String() && strings.ToLower(or.Kind) == "clusterdeployment" {
+				paused = append(paused, deadmanssnitchv1alpha1.ClusterDeploymentReference{Name: or.Name, Namespace: secret.Namespace})
+			}
+		}
+	}
+	sort.Slice(paused, func(i, j int) bool {
+		if paused[i].Namespace != paused[j].Namespace {
+			return paused[i].Namespace < paused[j].Namespace
+		}
+		return paused[i].Name < paused[j].Name
+	})
+	if len(paused) == 0 {
+		return nil, nil
+	}
+	return paused, nil
+}
+
+// setCondition sets the Active condition on the window for its current generation
+func setCondition(window *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&window.Status.Conditions, metav1.Condition{
+		Type:               deadmanssnitchv1alpha1.ConditionActive,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: window.Generation,
+	})
+}
+
+// updateStatus writes the window status if it changed since the start of the reconcile
+func (r *ReconcileDeadmansSnitchMaintenanceWindow) updateStatus(window *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow, originalStatus *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowStatus) error {
+	window.Status.ObservedGeneration = window.Generation
+	if equality.Semantic.DeepEqual(originalStatus, &window.Status) {
+		return nil
+	}
+	return r.client.Status().Update(context.TODO(), window)
+}
@@ -0,0 +1,265 @@
+package deadmanssnitchmaintenancewindow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/maintenance"
+	hiveapis "github.com/openshift/hive/apis"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testWindowName      = "testwindow"
+	testWindowNamespace = "deadmanssnitch-operator"
+	testNamespace       = "testNamespace"
+)
+
+var testNow = time.Date(2021, time.June, 5, 3, 0, 0, 0, time.UTC)
+
+// return a maintenance window opening at start for an hour
+func testOneOffWindow(start time.Time) *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow {
+	startTime := metav1.NewTime(start)
+	return &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testWindowName,
+			Namespace: testWindowNamespace,
+		},
+		Spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+			StartTime: &startTime,
+			Duration:  metav1.Duration{Duration: time.Hour},
+		},
+	}
+}
+
+// return a maintenance window opening every Saturday at 02:00 for two hours
+func testRecurringWindow() *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow {
+	return &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testWindowName,
+			Namespace: testWindowNamespace,
+		},
+		Spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+			Schedule: "0 2 * * 6",
+			Duration: metav1.Duration{Duration: 2 * time.Hour},
+		},
+	}
+}
+
+// return the hub secret of the clusterdeployment name, with its snitch held paused by the windows with keys
+func testHubSecret(name string, keys ...string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name + "-dms-secret",
+			Namespace:   testNamespace,
+			Annotations: maintenance.SetHolders(nil, keys),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: hivev1.SchemeGroupVersion.String(),
+				Kind:       "ClusterDeployment",
+				Name:       name,
+			}},
+		},
+	}
+}
+
+func TestReconcileMaintenanceWindow(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	err = hiveapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+
+	wasActive := func(window *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow, until time.Time) *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow {
+		window.Status.Phase = deadmanssnitchv1alpha1.MaintenanceWindowPhaseActive
+		window.Status.ActiveUntil = &metav1.Time{Time: until}
+		return window
+	}
+	key := testWindowNamespace + "/" + testWindowName
+
+	tests := []struct {
+		name                 string
+		window               *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow
+		localObjects         []runtime.Object
+		expectedPhase        string
+		expectedActive       metav1.ConditionStatus
+		expectedRequeueAfter time.Duration
+		expectedLastStart    *time.Time
+		expectedLastEnd      *time.Time
+		expectedNextStart    *time.Time
+		expectedPaused       []deadmanssnitchv1alpha1.ClusterDeploymentReference
+		expectedEvent        string
+	}{
+		{
+			name:                 "one-off window waiting to open",
+			window:               testOneOffWindow(testNow.Add(time.Hour)),
+			expectedPhase:        deadmanssnitchv1alpha1.MaintenanceWindowPhaseScheduled,
+			expectedActive:       metav1.ConditionFalse,
+			expectedRequeueAfter: time.Hour,
+			expectedNextStart:    timePtr(testNow.Add(time.Hour)),
+		},
+		{
+			name:   "window opening",
+			window: testOneOffWindow(testNow.Add(-10 * time.Minute)),
+			localObjects: []runtime.Object{
+				testHubSecret("cluster-b", key),
+				testHubSecret("cluster-a", key, "other/window"),
+				testHubSecret("cluster-c", "other/window"),
+				testHubSecret("cluster-d"),
+			},
+			expectedPhase:        deadmanssnitchv1alpha1.MaintenanceWindowPhaseActive,
+			expectedActive:       metav1.ConditionTrue,
+			expectedRequeueAfter: 50 * time.Minute,
+			expectedLastStart:    timePtr(testNow.Add(-10 * time.Minute)),
+			expectedPaused: []deadmanssnitchv1alpha1.ClusterDeploymentReference{
+				{Name: "cluster-a", Namespace: testNamespace},
+				{Name: "cluster-b", Namespace: testNamespace},
+			},
+			expectedEvent: "Normal MaintenanceWindowOpened",
+		},
+		{
+			name:                 "open window after a restart",
+			window:               wasActive(testOneOffWindow(testNow.Add(-10*time.Minute)), testNow.Add(50*time.Minute)),
+			expectedPhase:        deadmanssnitchv1alpha1.MaintenanceWindowPhaseActive,
+			expectedActive:       metav1.ConditionTrue,
+			expectedRequeueAfter: 50 * time.Minute,
+		},
+		{
+			name:            "one-off window closing",
+			window:          wasActive(testOneOffWindow(testNow.Add(-time.Hour)), testNow),
+			expectedPhase:   deadmanssnitchv1alpha1.MaintenanceWindowPhaseCompleted,
+			expectedActive:  metav1.ConditionFalse,
+			expectedLastEnd: timePtr(testNow),
+			expectedEvent:   "Normal MaintenanceWindowClosed",
+		},
+		{
+			name:            "one-off window that closed while the operator was down",
+			window:          wasActive(testOneOffWindow(testNow.Add(-3*time.Hour)), testNow.Add(-2*time.Hour)),
+			expectedPhase:   deadmanssnitchv1alpha1.MaintenanceWindowPhaseCompleted,
+			expectedActive:  metav1.ConditionFalse,
+			expectedLastEnd: timePtr(testNow.Add(-2 * time.Hour)),
+			expectedEvent:   "Normal MaintenanceWindowClosed",
+		},
+		{
+			name:                 "recurring window open",
+			window:               testRecurringWindow(),
+			expectedPhase:        deadmanssnitchv1alpha1.MaintenanceWindowPhaseActive,
+			expectedActive:       metav1.ConditionTrue,
+			expectedRequeueAfter: time.Hour,
+			expectedLastStart:    timePtr(testNow.Add(-time.Hour)),
+			expectedEvent:        "Normal MaintenanceWindowOpened",
+		},
+		{
+			name: "recurring window closed",
+			window: func() *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow {
+				window := testRecurringWindow()
+				window.Spec.Schedule = "0 2 * * 0"
+				return window
+			}(),
+			expectedPhase:        deadmanssnitchv1alpha1.MaintenanceWindowPhaseScheduled,
+			expectedActive:       metav1.ConditionFalse,
+			expectedRequeueAfter: 23 * time.Hour,
+			expectedNextStart:    timePtr(testNow.Add(23 * time.Hour)),
+		},
+		{
+			name: "invalid schedule",
+			window: func() *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow {
+				window := testRecurringWindow()
+				window.Spec.Schedule = "every saturday"
+				return window
+			}(),
+			expectedPhase:  deadmanssnitchv1alpha1.MaintenanceWindowPhaseInvalid,
+			expectedActive: metav1.ConditionFalse,
+			expectedEvent:  "Warning InvalidSchedule",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileDeadmansSnitchMaintenanceWindow{
+				client:   fakekubeclient.NewFakeClient(append([]runtime.Object{test.window}, test.localObjects...)...),
+				recorder: recorder,
+				now:      func() time.Time { return testNow },
+			}
+
+			result, err := r.Reconcile(reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: testWindowName, Namespace: testWindowNamespace},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedRequeueAfter, result.RequeueAfter)
+
+			window := &deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{}
+			err = r.client.Get(context.TODO(), types.NamespacedName{Name: testWindowName, Namespace: testWindowNamespace}, window)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedPhase, window.Status.Phase)
+			condition := meta.FindStatusCondition(window.Status.Conditions, deadmanssnitchv1alpha1.ConditionActive)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, test.expectedActive, condition.Status)
+			}
+			assertTime(t, test.expectedLastStart, window.Status.LastStartTime, "lastStartTime")
+			assertTime(t, test.expectedLastEnd, window.Status.LastEndTime, "lastEndTime")
+			assertTime(t, test.expectedNextStart, window.Status.NextStartTime, "nextStartTime")
+			assert.Equal(t, test.expectedPaused, window.Status.PausedClusterDeployments)
+
+			select {
+			case event := <-recorder.Events:
+				assert.Contains(t, event, test.expectedEvent)
+			default:
+				assert.Empty(t, test.expectedEvent, "no event recorded")
+			}
+		})
+	}
+}
+
+func TestPausedSecretToMaintenanceWindows(t *testing.T) {
+	secret := testHubSecret("cluster", "ns-a/window-a", "ns-b/window-b", "invalid")
+	requests := pausedSecretToMaintenanceWindows(handler.MapObject{Meta: secret, Object: secret})
+	assert.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "ns-a", Name: "window-a"}},
+		{NamespacedName: types.NamespacedName{Namespace: "ns-b", Name: "window-b"}},
+	}, requests)
+}
+
+func TestPausedSecretWatch(t *testing.T) {
+	held := testHubSecret("held", "ns-a/window-a", "ns-b/window-b")
+	released := testHubSecret("released")
+
+	assert.Equal(t, []string{"ns-a/window-a", "ns-b/window-b"}, pausedSecretHolders(held))
+	assert.Empty(t, pausedSecretHolders(released))
+	assert.Nil(t, pausedSecretHolders(testOneOffWindow(testNow)))
+
+	assert.True(t, pausedSecretPredicate.Create(event.CreateEvent{Meta: held, Object: held}))
+	assert.False(t, pausedSecretPredicate.Create(event.CreateEvent{Meta: released, Object: released}))
+	// releasing the snitch still concerns the windows that held it
+	assert.True(t, pausedSecretPredicate.Update(event.UpdateEvent{MetaOld: held, ObjectOld: held, MetaNew: released, ObjectNew: released}))
+	assert.False(t, pausedSecretPredicate.Update(event.UpdateEvent{MetaOld: released, ObjectOld: released, MetaNew: released, ObjectNew: released}))
+	assert.True(t, pausedSecretPredicate.Delete(event.DeleteEvent{Meta: held, Object: held}))
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func assertTime(t *testing.T, expected *time.Time, actual *metav1.Time, field string) {
+	if expected == nil {
+		assert.Nil(t, actual, field)
+		return
+	}
+	if assert.NotNil(t, actual, field) {
+		assert.True(t, expected.Equal(actual.Time), "%s is %s, expected %s", field, actual.Time, *expected)
+	}
+}
@@ -0,0 +1,142 @@
+package dmsclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+// DefaultCacheTTL is how long a listing of the snitches is served from a SnitchCache
+const DefaultCacheTTL = time.Minute
+
+// SnitchCache is an index of the snitches of a DMS account by name and token, refreshed from
+// ListAll once it is older than its TTL. Clients sharing it only list the snitches once per TTL.
+type SnitchCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	// mu is held while listing, so concurrent lookups of a stale cache list the snitches once
+	mu      sync.Mutex
+	index   *snitchIndex
+	fetched time.Time
+}
+
+type snitchIndex struct {
+	all     []Snitch
+	byName  map[string][]Snitch
+	byToken map[string]Snitch
+}
+
+// NewSnitchCache creates an empty cache serving a listing for ttl
+func NewSnitchCache(ttl time.Duration) *SnitchCache {
+	return &SnitchCache{ttl: ttl, now: time.Now}
+}
+
+var (
+	sharedCachesMu sync.Mutex
+	sharedCaches   = map[string]*SnitchCache{}
+)
+
+// SharedSnitchCache returns the cache of the account authToken belongs to at the API served at baseURL, so the clients created
+// for every reconcile and every DeadmansSnitchIntegration using the same API key and endpoint share it
+func SharedSnitchCache(authToken string, baseURL string, ttl time.Duration) *SnitchCache {
+	// don't keep the API keys around as map keys
+	sum := sha256.Sum256([]byte(baseURL + "\x00" + authToken))
+	key := hex.EncodeToString(sum[:])
+
+	sharedCachesMu.Lock()
+	defer sharedCachesMu.Unlock()
+	cache, ok := sharedCaches[key]
+	if !ok {
+		cache = NewSnitchCache(ttl)
+		sharedCaches[key] = cache
+	}
+	return cache
+}
+
+// WithSnitchCache serves the snitch listings of the client from cache
+func WithSnitchCache(cache *SnitchCache) Option {
+	return func(o *Options) {
+		o.SnitchCache = cache
+	}
+}
+
+// Invalidate drops the cached listing, so the next lookup lists the snitches again
+func (s *SnitchCache) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index = nil
+}
+
+// lookup returns the index of the snitches, listing them with listAll when the cached one is missing or expired
+func (s *SnitchCache) lookup(listAll func() ([]Snitch, error), collector *localmetrics.MetricsCollector) (*snitchIndex, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index != nil && s.now().Sub(s.fetched) < s.ttl {
+		collector.ObserveSnitchCacheLookup(true)
+		return s.index, nil
+	}
+	collector.ObserveSnitchCacheLookup(false)
+
+	snitches, err := listAll()
+	if err != nil {
+		return nil, err
+	}
+	s.index = newSnitchIndex(snitches)
+	s.fetched = s.now()
+	return s.index, nil
+}
+
+// add records a snitch created through the cache in the cached listing, so looking it up doesn't list the snitches again.
+// A listing taken while the snitch was being created may already have it.
+func (s *SnitchCache) add(snitch Snitch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil {
+		return
+	}
+	// the index is replaced rather than changed, lookups may still be reading it
+	snitches := []Snitch{}
+	for _, cached := range s.index.all {
+		if cached.Token != snitch.Token {
+			snitches = append(snitches, cached)
+		}
+	}
+	s.index = newSnitchIndex(append(snitches, snitch))
+}
+
+// newSnitchIndex indexes snitches by name and token
+func newSnitchIndex(snitches []Snitch) *snitchIndex {
+	index := &snitchIndex{
+		all:     snitches,
+		byName:  map[string][]Snitch{},
+		byToken: map[string]Snitch{},
+	}
+	for _, snitch := range snitches {
+		index.byName[snitch.Name] = append(index.byName[snitch.Name], snitch)
+		index.byToken[snitch.Token] = snitch
+	}
+	return index
+}
+
+// cached returns the index of the snitches if it hasn't expired, without listing them
+func (s *SnitchCache) cached() *snitchIndex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil && s.now().Sub(s.fetched) < s.ttl {
+		return s.index
+	}
+	return nil
+}
+
+// copySnitches returns a copy of snitches, so callers can't change the cached index
+func copySnitches(snitches []Snitch) []Snitch {
+	if snitches == nil {
+		return nil
+	}
+	return append([]Snitch{}, snitches...)
+}
@@ -0,0 +1,130 @@
+package dmsclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+func TestSnitchCache(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`[{"token":"abc","name":"snitch"},{"token":"def","name":"other"},{"token":"ghi","name":"snitch"}]`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewSnitchCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	c, err := NewClientWithOptions("token", localmetrics.NewMetricsCollector(), WithBaseURL(server.URL), WithSnitchCache(cache))
+	assert.NoError(t, err)
+
+	found, err := c.FindSnitchesByName("snitch")
+	assert.NoError(t, err)
+	assert.Equal(t, []Snitch{{Token: "abc", Name: "snitch"}, {Token: "ghi", Name: "snitch"}}, found)
+
+	// served from the cache
+	all, err := c.ListAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	snitch, err := c.List("def")
+	assert.NoError(t, err)
+	assert.Equal(t, Snitch{Token: "def", Name: "other"}, snitch)
+	found, err = c.FindSnitchesByName("missing")
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	assert.Equal(t, []string{"GET /v1/snitches"}, requests)
+
+	// changing the returned snitches doesn't change the cache
+	all[0].Name = "changed"
+	found, _ = c.FindSnitchesByName("snitch")
+	assert.Equal(t, "snitch", found[0].Name)
+
+	// expired
+	now = now.Add(time.Minute)
+	_, err = c.ListAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GET /v1/snitches", "GET /v1/snitches"}, requests)
+
+	// a change to a snitch invalidates the cache
+	_ = c.Pause("abc")
+	_, err = c.ListAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GET /v1/snitches", "GET /v1/snitches", "POST /v1/snitches/abc/pause", "GET /v1/snitches"}, requests)
+}
+
+func TestSnitchCacheInvalidatedAfterChange(t *testing.T) {
+	var c Client
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "PATCH" {
+			// a concurrent reconcile lists the snitches before DMS answers the update
+			_, _ = c.ListAll()
+			_, _ = w.Write([]byte(`{"token":"abc","name":"renamed"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"token":"abc","name":"snitch"}]`))
+	}))
+	defer server.Close()
+
+	var err error
+	c, err = NewClientWithOptions("token", localmetrics.NewMetricsCollector(), WithBaseURL(server.URL), WithSnitchCache(NewSnitchCache(time.Minute)))
+	assert.NoError(t, err)
+
+	_, err = c.Update(Snitch{Token: "abc", Name: "renamed"})
+	assert.NoError(t, err)
+	// the listing taken while the update was in flight isn't served
+	_, err = c.ListAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PATCH /v1/snitches/abc", "GET /v1/snitches", "GET /v1/snitches"}, requests)
+}
+
+func TestSnitchCacheAddsCreatedSnitch(t *testing.T) {
+	var c Client
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" {
+			// a concurrent reconcile lists the snitches before DMS answers the create
+			_, _ = c.ListAll()
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"token":"abc","name":"snitch"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"token":"def","name":"other"}]`))
+	}))
+	defer server.Close()
+
+	var err error
+	c, err = NewClientWithOptions("token", localmetrics.NewMetricsCollector(), WithBaseURL(server.URL), WithSnitchCache(NewSnitchCache(time.Minute)))
+	assert.NoError(t, err)
+
+	found, err := c.FindSnitchesByName("snitch")
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	_, err = c.Create(Snitch{Name: "snitch"})
+	assert.NoError(t, err)
+
+	// the created snitch is found without listing the snitches again
+	found, err = c.FindSnitchesByName("snitch")
+	assert.NoError(t, err)
+	assert.Equal(t, []Snitch{{Token: "abc", Name: "snitch"}}, found)
+	all, err := c.ListAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, []string{"GET /v1/snitches", "POST /v1/snitches"}, requests)
+}
+
+func TestSharedSnitchCache(t *testing.T) {
+	assert.True(t, SharedSnitchCache("key", "", time.Minute) == SharedSnitchCache("key", "", time.Minute))
+	assert.False(t, SharedSnitchCache("key", "", time.Minute) == SharedSnitchCache("other key", "", time.Minute))
+	// the same key at another endpoint lists other snitches
+	assert.False(t, SharedSnitchCache("key", "", time.Minute) == SharedSnitchCache("key", "https://dms.example.com", time.Minute))
+}
@@ -7,23 +7,27 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
 )
 
 const (
-	apiEndpoint = "https://api.deadmanssnitch.com/v1"
+	apiEndpoint = "https://api.deadmanssnitch.com"
 )
 
 // Client is a wrapper interface for the dmsClient to allow for easier testing
 type Client interface {
 	ListAll() ([]Snitch, error)
+	Iterate(opts ListOptions) *SnitchIterator
 	List(snitchToken string) (Snitch, error)
 	Create(newSnitch Snitch) (Snitch, error)
 	Delete(snitchToken string) (bool, error)
 	FindSnitchesByName(snitchName string) ([]Snitch, error)
 	Update(updateSnitch Snitch) (Snitch, error)
+	Pause(snitchToken string) error
+	Unpause(snitchToken string) error
 	CheckIn(s Snitch) error
 }
 
@@ -33,25 +37,31 @@ type SnitchType struct {
 }
 
 // Snitch Struct
+// Fields are omitted when empty so a Snitch only holding the changed fields
+// can be sent to Update without clearing the others.
 type Snitch struct {
-	Name        string     `json:"name"`
-	Token       string     `json:"token"`
-	Href        string     `json:"href"`
-	Tags        []string   `json:"tags"`
-	Notes       string     `json:"notes"`
-	Status      string     `json:"status"`
-	CheckedInAt string     `json:"checked_in_at"`
-	CheckInURL  string     `json:"check_in_url"`
-	CreatedAt   string     `json:"created_at"`
-	Interval    string     `json:"interval"`
-	AlertType   string     `json:"alert_type"`
-	AlertEmail  []string   `json:"alert_email"`
-	Type        SnitchType `json:"type"`
+	Name        string      `json:"name,omitempty"`
+	Token       string      `json:"token,omitempty"`
+	Href        string      `json:"href,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Notes       string      `json:"notes,omitempty"`
+	Status      string      `json:"status,omitempty"`
+	CheckedInAt string      `json:"checked_in_at,omitempty"`
+	CheckInURL  string      `json:"check_in_url,omitempty"`
+	CreatedAt   string      `json:"created_at,omitempty"`
+	Interval    string      `json:"interval,omitempty"`
+	AlertType   string      `json:"alert_type,omitempty"`
+	AlertEmail  []string    `json:"alert_email,omitempty"`
+	Type        *SnitchType `json:"type,omitempty"`
 }
 
-func defaultURL() *url.URL {
-	url, _ := url.Parse(apiEndpoint)
-	return url
+// CurrentInterval returns the interval of the snitch. The API reports it
+// under type when reading a snitch, but expects it in interval when writing.
+func (s Snitch) CurrentInterval() string {
+	if s.Type != nil && s.Type.Interval != "" {
+		return s.Type.Interval
+	}
+	return s.Interval
 }
 
 // Client wraps http client
@@ -59,17 +69,44 @@ type dmsClient struct {
 	authToken        string
 	BaseURL          *url.URL
 	httpClient       *http.Client
+	retryPolicy      RetryPolicy
+	cache            *SnitchCache
 	metricsCollector *localmetrics.MetricsCollector
 }
 
-// NewClient creates an API client
-func NewClient(authToken string, collector *localmetrics.MetricsCollector) Client {
+// NewClient creates an API client for api.deadmanssnitch.com with the default options
+func NewClient(authToken string, collector *localmetrics.MetricsCollector) (Client, error) {
+	return NewClientWithOptions(authToken, collector)
+}
+
+// NewClientWithOptions creates an API client configured by opts
+func NewClientWithOptions(authToken string, collector *localmetrics.MetricsCollector, opts ...Option) (Client, error) {
+	o := newOptions(opts...)
+
+	baseURL, err := url.Parse(o.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing base URL: %v", err)
+	}
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("Error parsing base URL: %q is not an absolute URL", o.BaseURL)
+	}
+
+	httpClient := o.HTTPClient
+	if httpClient == nil {
+		httpClient, err = NewHTTPClient(opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &dmsClient{
 		authToken:        authToken,
-		BaseURL:          defaultURL(),
-		httpClient:       http.DefaultClient,
+		BaseURL:          baseURL,
+		httpClient:       httpClient,
+		retryPolicy:      o.RetryPolicy,
+		cache:            o.SnitchCache,
 		metricsCollector: collector,
-	}
+	}, nil
 }
 
 // NewSnitch creates a new Snitch only requiring a few items
@@ -82,9 +119,19 @@ func NewSnitch(name string, tags []string, interval string, alertType string) Sn
 	}
 }
 
+// url returns the URL of an API path
+func (c *dmsClient) url(path string) *url.URL {
+	// keep the path prefix of the base URL, i.e. of a stand-in served below the root
+	u := *c.BaseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	return &u
+}
+
 func (c *dmsClient) newRequest(method, path string, body interface{}) (*http.Request, error) {
-	rel := &url.URL{Path: path}
-	u := c.BaseURL.ResolveReference(rel)
+	return c.newRequestURL(method, c.url(path).String(), body)
+}
+
+func (c *dmsClient) newRequestURL(method, u string, body interface{}) (*http.Request, error) {
 	var buf io.ReadWriter
 
 	if body != nil {
@@ -94,7 +141,7 @@ func (c *dmsClient) newRequest(method, path string, body interface{}) (*http.Req
 			return nil, err
 		}
 	}
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequest(method, u, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -107,51 +154,107 @@ func (c *dmsClient) newRequest(method, path string, body interface{}) (*http.Req
 	return req, nil
 }
 
+// do sends the request, retrying it with backoff when DMS can't be reached, is unavailable or rate limits it.
+// Requests that may have changed something in DMS are only retried when they were rate limited.
 func (c *dmsClient) do(req *http.Request, operation string) (*http.Response, error) {
 	start := time.Now()
 	defer func() {
 		c.metricsCollector.ObserveSnitchCallDuration(time.Since(start).Seconds(), operation)
 	}()
-	resp, err := c.httpClient.Do(req)
 
-	// raise an error if unable to authenticate to DMS service
-	if resp.StatusCode == 401 {
-		err = fmt.Errorf("unauthorized error: please check the deadmanssnitch credentials")
-	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := rewindBody(req); err != nil {
+				c.metricsCollector.ObserveSnitchCallError()
+				return nil, fmt.Errorf("Error calling the API endpoint: %v", err)
+			}
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			delay := c.retryPolicy.backoff(attempt)
+			if attempt < c.retryPolicy.MaxRetries && isIdempotent(req.Method) && wait(req, delay) == nil {
+				c.metricsCollector.ObserveSnitchCallRetry(operation)
+				continue
+			}
+			c.metricsCollector.ObserveSnitchCallError()
+			return nil, fmt.Errorf("Error calling the API endpoint: %v", err)
+		}
+		c.observeRateLimit(resp.Header)
+
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+		if !isRetryableStatus(resp.StatusCode) {
+			c.metricsCollector.ObserveSnitchCallError()
+			return nil, newAPIError(operation, resp)
+		}
+
+		// a rate limited request was rejected before DMS acted on it, so it is safe to send again
+		throttled := resp.StatusCode == http.StatusTooManyRequests
+		if throttled {
+			c.metricsCollector.ObserveSnitchThrottled(operation)
+		}
+		delay := c.retryPolicy.backoff(attempt)
+		if requested := retryAfter(resp.Header, time.Now()); requested > 0 {
+			throttled = true
+			delay = requested
+		}
+		apiErr := newAPIError(operation, resp)
+		if attempt < c.retryPolicy.MaxRetries && (throttled || isIdempotent(req.Method)) && delay <= c.retryPolicy.MaxDelay {
+			if wait(req, delay) == nil {
+				c.metricsCollector.ObserveSnitchCallRetry(operation)
+				continue
+			}
+		}
 
-	if err != nil {
 		c.metricsCollector.ObserveSnitchCallError()
-		return resp, fmt.Errorf("Error calling the API endpoint: %v", err)
+		if throttled {
+			apiErr.RetryAfter = delay
+		}
+		return nil, apiErr
 	}
-
-	return resp, nil
 }
 
-// ListAll snitches
+// ListAll snitches, from the cache of the client if it has one
 func (c *dmsClient) ListAll() ([]Snitch, error) {
-	req, err := c.newRequest("GET", "/v1/snitches", nil)
-	if err != nil {
-		return nil, err
+	if c.cache == nil {
+		return c.listAll()
 	}
-
-	resp, err := c.do(req, "list_all")
+	index, err := c.cache.lookup(c.listAll, c.metricsCollector)
 	if err != nil {
 		return nil, err
 	}
+	return copySnitches(index.all), nil
+}
 
-	var snitches []Snitch
-	decodeErr := json.NewDecoder(resp.Body).Decode(&snitches)
-	if decodeErr != nil {
-		err = fmt.Errorf("Error listing all snitches: %v", decodeErr)
+// listAll snitches from the API, following its pages
+func (c *dmsClient) listAll() ([]Snitch, error) {
+	snitches := []Snitch{}
+	it := c.Iterate(ListOptions{})
+	for it.Next() {
+		snitches = append(snitches, it.Snitch())
 	}
-
-	return snitches, err
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return snitches, nil
 }
 
 //List a single snitch
 func (c *dmsClient) List(snitchToken string) (Snitch, error) {
 	var snitch Snitch
 
+	if c.cache != nil {
+		if index := c.cache.cached(); index != nil {
+			if cached, ok := index.byToken[snitchToken]; ok {
+				c.metricsCollector.ObserveSnitchCacheLookup(true)
+				return cached, nil
+			}
+		}
+		c.metricsCollector.ObserveSnitchCacheLookup(false)
+	}
+
 	req, err := c.newRequest("GET", "/v1/snitches/"+snitchToken, nil)
 	if err != nil {
 		return snitch, err
@@ -179,6 +282,7 @@ func (c *dmsClient) Create(newSnitch Snitch) (Snitch, error) {
 	}
 	resp, err := c.do(req, "create")
 	if err != nil {
+		c.invalidateCache()
 		return snitch, err
 	}
 
@@ -186,33 +290,44 @@ func (c *dmsClient) Create(newSnitch Snitch) (Snitch, error) {
 
 	decodeErr := json.NewDecoder(resp.Body).Decode(&snitch)
 	if decodeErr != nil {
-		err = fmt.Errorf("Error creating snitch: %v", decodeErr)
+		c.invalidateCache()
+		return snitch, fmt.Errorf("Error creating snitch: %v", decodeErr)
 	}
-	return snitch, err
+	// the created snitch is added to the cached listing instead of listing the snitches again to find it
+	if c.cache != nil {
+		c.cache.add(snitch)
+	}
+	return snitch, nil
 }
 
-// Delete a snitch
+// Delete a snitch. Deleting a snitch that doesn't exist fails with an error IsNotFound reports.
 func (c *dmsClient) Delete(snitchToken string) (bool, error) {
 	req, err := c.newRequest("DELETE", "/v1/snitches/"+snitchToken, nil)
 	if err != nil {
 		return false, err
 	}
+	defer c.invalidateCache()
 	resp, err := c.do(req, "delete")
 	if err != nil {
 		return false, err
 	}
+	resp.Body.Close()
 
-	if resp.StatusCode == 204 {
-		return true, nil
-	}
-
-	return false, nil
+	return true, nil
 }
 
 // FindSnitchesByName This will search for snitches using a name. This
 // could return multiple snitches, as the same name may be used multiple times
 func (c *dmsClient) FindSnitchesByName(snitchName string) ([]Snitch, error) {
 	var foundSnitches []Snitch
+	if c.cache != nil {
+		index, err := c.cache.lookup(c.listAll, c.metricsCollector)
+		if err != nil {
+			return foundSnitches, err
+		}
+		return copySnitches(index.byName[snitchName]), nil
+	}
+
 	listedSnitches, err := c.ListAll()
 	if err != nil {
 		return foundSnitches, err
@@ -234,17 +349,50 @@ func (c *dmsClient) Update(updateSnitch Snitch) (Snitch, error) {
 	if err != nil {
 		return snitch, err
 	}
+	defer c.invalidateCache()
 	resp, err := c.do(req, "update")
 	if err != nil {
 		return snitch, err
 	}
 
 	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&snitch)
 
+	decodeErr := json.NewDecoder(resp.Body).Decode(&snitch)
+	if decodeErr != nil {
+		err = fmt.Errorf("Error updating snitch: %v", decodeErr)
+	}
 	return snitch, err
 }
 
+// Pause the snitch until its next check in
+func (c *dmsClient) Pause(snitchToken string) error {
+	req, err := c.newRequest("POST", "/v1/snitches/"+snitchToken+"/pause", nil)
+	if err != nil {
+		return err
+	}
+	defer c.invalidateCache()
+	resp, err := c.do(req, "pause")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// Unpause the snitch. DMS has no call to unpause a snitch, it resumes on its next check in,
+// so Unpause checks in on behalf of the monitored system. DMS alerts again if it then misses its next check in.
+func (c *dmsClient) Unpause(snitchToken string) error {
+	snitch, err := c.List(snitchToken)
+	if err != nil {
+		return err
+	}
+	if snitch.CheckInURL == "" {
+		return fmt.Errorf("Error unpausing snitch %s: no check in URL", snitchToken)
+	}
+	return c.CheckIn(snitch)
+}
+
 // Initialize the snitch with a basic GET call to its url
 func (c *dmsClient) CheckIn(s Snitch) error {
 	var buf io.ReadWriter
@@ -255,10 +403,21 @@ func (c *dmsClient) CheckIn(s Snitch) error {
 
 	req.Header.Set("User-Agent", "golang httpClient")
 
-	_, err = c.do(req, "check_in")
+	// the first check in changes the status of the snitch
+	defer c.invalidateCache()
+	resp, err := c.do(req, "check_in")
 	if err != nil {
 		return err
 	}
+	resp.Body.Close()
 
 	return nil
 }
+
+// invalidateCache drops the cached listing once a call changing a snitch returned, so a listing
+// fetched while the call was in flight isn't served afterwards
+func (c *dmsClient) invalidateCache() {
+	if c.cache != nil {
+		c.cache.Invalidate()
+	}
+}
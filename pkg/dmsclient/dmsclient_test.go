@@ -0,0 +1,23 @@
+package dmsclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnpause(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/v1/snitches/abc" {
+			_, _ = w.Write([]byte(`{"token":"abc","status":"paused","check_in_url":"` + "http://" + r.Host + `/abc"}`))
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, newTestClient(t, server).Unpause("abc"))
+	assert.Equal(t, []string{"GET /v1/snitches/abc", "GET /abc"}, requests)
+}
@@ -0,0 +1,18 @@
+package dmsclient
+
+import (
+	"net/http"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+)
+
+// providerName names DMS in the errors of the client
+const providerName = "deadmanssnitch"
+
+// APIError is returned when DMS answers a call with a status code outside of 2xx
+type APIError = apierror.Error
+
+// newAPIError reads the error DMS sent in the body of resp, and closes it
+func newAPIError(operation string, resp *http.Response) *APIError {
+	return apierror.New(providerName, operation, resp)
+}
@@ -0,0 +1,102 @@
+package dmsclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+)
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      func(w http.ResponseWriter)
+		call          func(c Client) error
+		expectedError string
+		notFound      bool
+		rateLimited   bool
+		unauthorized  bool
+		quotaExceeded bool
+	}{
+		{
+			name:     "not found",
+			response: respond(http.StatusNotFound, `{"type":"resource_not_found","error":"Not Found"}`),
+			call: func(c Client) error {
+				_, err := c.Delete("abc")
+				return err
+			},
+			expectedError: "Error calling the API endpoint: delete returned status code 404 (resource_not_found): Not Found",
+			notFound:      true,
+		},
+		{
+			name:     "unauthorized",
+			response: respond(http.StatusUnauthorized, ""),
+			call: func(c Client) error {
+				_, err := c.ListAll()
+				return err
+			},
+			expectedError: "Error calling the API endpoint: list_all returned status code 401: please check the deadmanssnitch credentials",
+			unauthorized:  true,
+		},
+		{
+			name:     "quota exceeded",
+			response: respond(http.StatusPaymentRequired, `{"type":"plan_limit_reached","error":"Your plan doesn't allow more snitches"}`),
+			call: func(c Client) error {
+				_, err := c.Create(Snitch{Name: "snitch"})
+				return err
+			},
+			expectedError: "Error calling the API endpoint: create returned status code 402 (plan_limit_reached): Your plan doesn't allow more snitches",
+			quotaExceeded: true,
+		},
+		{
+			name:     "rate limited",
+			response: respond(http.StatusTooManyRequests, "", headerRetryAfter, "60"),
+			call: func(c Client) error {
+				_, err := c.Update(Snitch{Token: "abc", Interval: "daily"})
+				return err
+			},
+			expectedError: "Error calling the API endpoint: update returned status code 429, retry after 1m0s",
+			rateLimited:   true,
+		},
+		{
+			name:     "invalid",
+			response: respond(http.StatusUnprocessableEntity, "interval is invalid", "Content-Type", "text/plain"),
+			call: func(c Client) error {
+				return c.Pause("abc")
+			},
+			expectedError: "Error calling the API endpoint: pause returned status code 422: interval is invalid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newTestServer(test.response)
+			defer server.Close()
+
+			err := test.call(newTestClient(t, server))
+			assert.EqualError(t, err, test.expectedError)
+			// the helpers see through wrapping
+			wrapped := fmt.Errorf("Error reconciling: %w", err)
+			assert.Equal(t, test.notFound, apierror.IsNotFound(wrapped))
+			assert.Equal(t, test.rateLimited, apierror.IsRateLimited(wrapped))
+			assert.Equal(t, test.unauthorized, apierror.IsUnauthorized(wrapped))
+			assert.Equal(t, test.quotaExceeded, apierror.IsQuotaExceeded(wrapped))
+		})
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	server, _ := newTestServer(respond(http.StatusOK, ""))
+	c := newTestClient(t, server)
+	server.Close()
+
+	// a call DMS never answered must fail without a response to read
+	err := c.CheckIn(Snitch{CheckInURL: server.URL + "/abc"})
+	assert.Error(t, err)
+	var apiErr *APIError
+	assert.False(t, errors.As(err, &apiErr))
+}
@@ -48,6 +48,20 @@ func (mr *MockClientMockRecorder) ListAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAll", reflect.TypeOf((*MockClient)(nil).ListAll))
 }
 
+// Iterate mocks base method
+func (m *MockClient) Iterate(opts dmsclient.ListOptions) *dmsclient.SnitchIterator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iterate", opts)
+	ret0, _ := ret[0].(*dmsclient.SnitchIterator)
+	return ret0
+}
+
+// Iterate indicates an expected call of Iterate
+func (mr *MockClientMockRecorder) Iterate(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterate", reflect.TypeOf((*MockClient)(nil).Iterate), opts)
+}
+
 // List mocks base method
 func (m *MockClient) List(snitchToken string) (dmsclient.Snitch, error) {
 	m.ctrl.T.Helper()
@@ -123,6 +137,34 @@ func (mr *MockClientMockRecorder) Update(updateSnitch interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), updateSnitch)
 }
 
+// Pause mocks base method
+func (m *MockClient) Pause(snitchToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pause", snitchToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Pause indicates an expected call of Pause
+func (mr *MockClientMockRecorder) Pause(snitchToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockClient)(nil).Pause), snitchToken)
+}
+
+// Unpause mocks base method
+func (m *MockClient) Unpause(snitchToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unpause", snitchToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unpause indicates an expected call of Unpause
+func (mr *MockClientMockRecorder) Unpause(snitchToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unpause", reflect.TypeOf((*MockClient)(nil).Unpause), snitchToken)
+}
+
 // CheckIn mocks base method
 func (m *MockClient) CheckIn(s dmsclient.Snitch) error {
 	m.ctrl.T.Helper()
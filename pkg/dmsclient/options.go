@@ -0,0 +1,173 @@
+package dmsclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a request to the API when no timeout is set
+const DefaultTimeout = 30 * time.Second
+
+// Options are the settings of the client, set with the Option functions
+type Options struct {
+	// BaseURL is the scheme, host and optional path prefix the API is served at
+	BaseURL string
+	// Timeout bounds a request, including reading the response body
+	Timeout time.Duration
+	// ProxyURL is the proxy requests go through. The proxy environment variables are used when empty
+	ProxyURL string
+	// CABundle holds PEM encoded certificates trusted in addition to the system roots
+	CABundle []byte
+	// TLSMinVersion is the minimum TLS version accepted, i.e. tls.VersionTLS12. The Go default applies when 0
+	TLSMinVersion uint16
+	// RetryPolicy controls how failed requests are retried
+	RetryPolicy RetryPolicy
+	// SnitchCache serves the snitch listings when set
+	SnitchCache *SnitchCache
+	// HTTPClient sends the requests when set, instead of a client built from the timeout, proxy and TLS options
+	HTTPClient *http.Client
+}
+
+// Option sets one of the Options of a client
+type Option func(*Options)
+
+// WithBaseURL points the client at another API endpoint, i.e. a local stand-in or a regional endpoint
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) {
+		o.BaseURL = baseURL
+	}
+}
+
+// WithTimeout bounds every request of the client
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// WithProxy sends the requests of the client through a proxy
+func WithProxy(proxyURL string) Option {
+	return func(o *Options) {
+		o.ProxyURL = proxyURL
+	}
+}
+
+// WithCABundle trusts the PEM encoded certificates in caBundle in addition to the system roots
+func WithCABundle(caBundle []byte) Option {
+	return func(o *Options) {
+		o.CABundle = caBundle
+	}
+}
+
+// WithTLSMinVersion sets the minimum TLS version the client accepts
+func WithTLSMinVersion(version uint16) Option {
+	return func(o *Options) {
+		o.TLSMinVersion = version
+	}
+}
+
+// WithHTTPClient sends the requests of the client with httpClient, i.e. one returned by SharedHTTPClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *Options) {
+		o.HTTPClient = httpClient
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	o := Options{
+		BaseURL:     apiEndpoint,
+		Timeout:     DefaultTimeout,
+		RetryPolicy: DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NewHTTPClient creates an HTTP client honouring the timeout, proxy and TLS options.
+// The clients of the other heartbeat providers use it to share the transport settings.
+func NewHTTPClient(opts ...Option) (*http.Client, error) {
+	o := newOptions(opts...)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.ProxyURL != "" {
+		proxyURL, err := url.Parse(o.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing proxy URL: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if len(o.CABundle) > 0 || o.TLSMinVersion != 0 {
+		tlsConfig := &tls.Config{MinVersion: o.TLSMinVersion}
+		if len(o.CABundle) > 0 {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(o.CABundle) {
+				return nil, fmt.Errorf("Error parsing CA bundle: no PEM encoded certificates found")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Timeout:   o.Timeout,
+		Transport: transport,
+	}, nil
+}
+
+var (
+	sharedHTTPClientsMu sync.Mutex
+	sharedHTTPClients   = map[string]*http.Client{}
+)
+
+// SharedHTTPClient returns the HTTP client honouring the timeout, proxy and TLS options, so the clients created
+// for every reconcile with the same options share it and its connections instead of building a transport each time
+func SharedHTTPClient(opts ...Option) (*http.Client, error) {
+	o := newOptions(opts...)
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%d\x00%s\x00%d\x00", o.Timeout, o.ProxyURL, o.TLSMinVersion)
+	sum.Write(o.CABundle)
+	key := hex.EncodeToString(sum.Sum(nil))
+
+	sharedHTTPClientsMu.Lock()
+	defer sharedHTTPClientsMu.Unlock()
+	if httpClient, ok := sharedHTTPClients[key]; ok {
+		return httpClient, nil
+	}
+	httpClient, err := NewHTTPClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	sharedHTTPClients[key] = httpClient
+	return httpClient, nil
+}
+
+// ParseTLSVersion converts a TLS version as written in a configuration, i.e. "1.2", to its tls constant
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown TLS version %q", version)
+	}
+}
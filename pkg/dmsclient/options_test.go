@@ -0,0 +1,81 @@
+package dmsclient
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+func TestNewClientWithOptions(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	c, err := NewClientWithOptions("token", localmetrics.NewMetricsCollector(), WithBaseURL(server.URL+"/dms/"), WithTimeout(time.Second))
+	assert.NoError(t, err)
+	_, err = c.ListAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GET /dms/v1/snitches"}, requests)
+
+	_, err = NewClientWithOptions("token", nil, WithBaseURL("api.deadmanssnitch.com"))
+	assert.Error(t, err)
+
+	_, err = NewClientWithOptions("token", nil, WithCABundle([]byte("not a certificate")))
+	assert.Error(t, err)
+
+	_, err = NewClientWithOptions("token", nil, WithProxy("http://proxy.example.com:3128"))
+	assert.NoError(t, err)
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	httpClient, err := NewHTTPClient()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultTimeout, httpClient.Timeout)
+
+	httpClient, err = NewHTTPClient(WithTimeout(time.Minute), WithTLSMinVersion(tls.VersionTLS12))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, httpClient.Timeout)
+	assert.Equal(t, uint16(tls.VersionTLS12), httpClient.Transport.(*http.Transport).TLSClientConfig.MinVersion)
+}
+
+func TestSharedHTTPClient(t *testing.T) {
+	shared, err := SharedHTTPClient(WithTimeout(time.Minute), WithCABundle(nil))
+	assert.NoError(t, err)
+	again, err := SharedHTTPClient(WithTimeout(time.Minute), WithBaseURL("https://dms.example.com"))
+	assert.NoError(t, err)
+	assert.True(t, shared == again)
+
+	other, err := SharedHTTPClient(WithTimeout(time.Second))
+	assert.NoError(t, err)
+	assert.False(t, shared == other)
+
+	_, err = SharedHTTPClient(WithCABundle([]byte("not a certificate")))
+	assert.Error(t, err)
+
+	// a client given an HTTP client sends its requests with it
+	c, err := NewClientWithOptions("token", nil, WithHTTPClient(shared))
+	assert.NoError(t, err)
+	assert.True(t, shared == c.(*dmsClient).httpClient)
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	version, err := ParseTLSVersion("1.3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), version)
+
+	version, err = ParseTLSVersion("")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0), version)
+
+	_, err = ParseTLSVersion("TLSv1.2")
+	assert.Error(t, err)
+}
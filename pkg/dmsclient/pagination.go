@@ -0,0 +1,153 @@
+package dmsclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxPages guards against a DMS endpoint linking its pages in a loop
+const maxPages = 10000
+
+// ListOptions filters the snitches listed by Iterate
+type ListOptions struct {
+	// Tags only lists the snitches having all of these tags. DMS does the filtering.
+	Tags []string
+}
+
+// SnitchIterator walks through the snitches of a listing, fetching the next page once the
+// snitches of the previous one are consumed. Use it like a bufio.Scanner:
+//
+//	it := client.Iterate(dmsclient.ListOptions{Tags: []string{"production"}})
+//	for it.Next() {
+//		fmt.Println(it.Snitch().Name)
+//	}
+//	return it.Err()
+//
+// Iterating holds one page of snitches in memory, where ListAll holds all of them.
+// DMS announces further pages in a Link header with rel="next".
+type SnitchIterator struct {
+	client  *dmsClient
+	nextURL string
+	pages   int
+	seen    map[string]bool
+	page    []Snitch
+	current Snitch
+	err     error
+}
+
+// NewSnitchIterator returns an iterator over snitches, i.e. for a mocked Client to return
+func NewSnitchIterator(snitches []Snitch) *SnitchIterator {
+	return &SnitchIterator{page: snitches}
+}
+
+// Next advances to the next snitch, and reports whether there is one
+func (it *SnitchIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.nextURL == "" {
+			return false
+		}
+		it.fetch()
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Snitch returns the snitch Next advanced to
+func (it *SnitchIterator) Snitch() Snitch {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *SnitchIterator) Err() error {
+	return it.err
+}
+
+// fetch gets the page at nextURL, and the URL of the page after it
+func (it *SnitchIterator) fetch() {
+	pageURL := it.nextURL
+	it.nextURL = ""
+	it.pages++
+	if it.pages > maxPages || it.seen[pageURL] {
+		it.err = fmt.Errorf("Error listing all snitches: stopped after %d pages", it.pages-1)
+		return
+	}
+	it.seen[pageURL] = true
+
+	req, err := it.client.newRequestURL("GET", pageURL, nil)
+	if err != nil {
+		it.err = err
+		return
+	}
+	resp, err := it.client.do(req, "list_all")
+	if err != nil {
+		it.err = err
+		return
+	}
+	defer resp.Body.Close()
+
+	decodeErr := json.NewDecoder(resp.Body).Decode(&it.page)
+	if decodeErr != nil {
+		it.err = fmt.Errorf("Error listing all snitches: %v", decodeErr)
+		return
+	}
+
+	next := nextPageLink(resp.Header)
+	if next == "" {
+		return
+	}
+	nextURL, err := req.URL.Parse(next)
+	if err != nil {
+		it.err = fmt.Errorf("Error listing all snitches: invalid next page link: %v", err)
+		return
+	}
+	// never send the API key anywhere but the API
+	if nextURL.Scheme != it.client.BaseURL.Scheme || nextURL.Host != it.client.BaseURL.Host {
+		it.err = fmt.Errorf("Error listing all snitches: next page on %s is outside of the API", nextURL.Host)
+		return
+	}
+	it.nextURL = nextURL.String()
+}
+
+// Iterate returns an iterator over the snitches matching opts.
+// It always calls the API, the cache of the client only serves ListAll.
+func (c *dmsClient) Iterate(opts ListOptions) *SnitchIterator {
+	u := c.url("/v1/snitches")
+	if len(opts.Tags) > 0 {
+		u.RawQuery = url.Values{"tags": {strings.Join(opts.Tags, ",")}}.Encode()
+	}
+	return &SnitchIterator{
+		client:  c,
+		nextURL: u.String(),
+		seen:    map[string]bool{},
+	}
+}
+
+// nextPageLink returns the target of the rel="next" entry of the Link header, as in
+//
+//	Link: <https://api.deadmanssnitch.com/v1/snitches?page=2>; rel="next", <...?page=5>; rel="last"
+func nextPageLink(header http.Header) string {
+	for _, value := range header["Link"] {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(strings.ToLower(param), "rel=") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(param[len("rel="):], `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package dmsclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+func TestListAllPages(t *testing.T) {
+	var requests []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v1/snitches?page=2>; rel="next", <%s/v1/snitches?page=3>; rel="last"`, server.URL, server.URL))
+			_, _ = w.Write([]byte(`[{"token":"a"},{"token":"b"}]`))
+		case "2":
+			// relative links are resolved against the page
+			w.Header().Set("Link", `</v1/snitches?page=3>; rel="next"`)
+			_, _ = w.Write([]byte(`[]`))
+		case "3":
+			_, _ = w.Write([]byte(`[{"token":"c"}]`))
+		}
+	}))
+	defer server.Close()
+
+	snitches, err := newTestClient(t, server).ListAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Snitch{{Token: "a"}, {Token: "b"}, {Token: "c"}}, snitches)
+	assert.Equal(t, []string{"/v1/snitches", "/v1/snitches?page=2", "/v1/snitches?page=3"}, requests)
+}
+
+func TestIterateTags(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		_, _ = w.Write([]byte(`[{"token":"a","tags":["production","hive"]}]`))
+	}))
+	defer server.Close()
+
+	it := newTestClient(t, server).Iterate(ListOptions{Tags: []string{"production", "hive"}})
+	assert.True(t, it.Next())
+	assert.Equal(t, "a", it.Snitch().Token)
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"/v1/snitches?tags=production%2Chive"}, requests)
+}
+
+func TestIterateBadLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+	}{
+		{name: "loop", link: `</v1/snitches>; rel="next"`},
+		{name: "other host", link: `<https://example.com/v1/snitches?page=2>; rel="next"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Link", test.link)
+				_, _ = w.Write([]byte(`[{"token":"a"}]`))
+			}))
+			defer server.Close()
+
+			c, err := NewClientWithOptions("token", localmetrics.NewMetricsCollector(), WithBaseURL(server.URL))
+			assert.NoError(t, err)
+			_, err = c.ListAll()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNextPageLink(t *testing.T) {
+	assert.Equal(t, "", nextPageLink(http.Header{}))
+	assert.Equal(t, "/p2", nextPageLink(http.Header{"Link": {`</p1>; rel="prev", </p2>; rel="next"`}}))
+	assert.Equal(t, "/p2", nextPageLink(http.Header{"Link": {`</p0>; rel=first`, `</p2>; title="x"; rel="next last"`}}))
+	assert.Equal(t, "", nextPageLink(http.Header{"Link": {`</p5>; rel="last"`}}))
+}
+
+func TestSnitchIterator(t *testing.T) {
+	it := NewSnitchIterator([]Snitch{{Token: "a"}, {Token: "b"}})
+	var tokens []string
+	for it.Next() {
+		tokens = append(tokens, it.Snitch().Token)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"a", "b"}, tokens)
+}
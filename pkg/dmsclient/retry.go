@@ -0,0 +1,135 @@
+package dmsclient
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerRetryAfter         = "Retry-After"
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, 0 disables retrying
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled on every further retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts. When DMS asks to wait longer,
+	// the request fails with a rate limited APIError instead of blocking the caller.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used when no RetryPolicy is set
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   10 * time.Second,
+}
+
+// WithRetryPolicy sets how the client retries failed requests
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *Options) {
+		o.RetryPolicy = policy
+	}
+}
+
+// backoff returns the delay before retry number attempt+1: an exponential backoff
+// of which a random half is dropped, so clients throttled together don't retry together
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << uint(attempt)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// isIdempotent reports whether a request can be sent again after it may have reached DMS
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the wait DMS asks for in the Retry-After header, either in seconds or as a date,
+// or until the rate limit resets when the quota is used up. It returns 0 when there is nothing to wait for.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	if value := header.Get(headerRetryAfter); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(value); err == nil && date.After(now) {
+			return date.Sub(now)
+		}
+	}
+
+	if header.Get(headerRateLimitRemaining) == "0" {
+		if reset, err := strconv.ParseInt(header.Get(headerRateLimitReset), 10, 64); err == nil && reset > 0 {
+			// the reset is either a unix timestamp or a number of seconds
+			if resetAt := time.Unix(reset, 0); resetAt.After(now) {
+				return resetAt.Sub(now)
+			}
+			if reset < 24*60*60 {
+				return time.Duration(reset) * time.Second
+			}
+		}
+	}
+	return 0
+}
+
+// observeRateLimit exposes the rate limit headers of a response as metrics
+func (c *dmsClient) observeRateLimit(header http.Header) {
+	limit, err := strconv.ParseFloat(header.Get(headerRateLimitLimit), 64)
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.ParseFloat(header.Get(headerRateLimitRemaining), 64)
+	if err != nil {
+		return
+	}
+	c.metricsCollector.ObserveSnitchRateLimit(limit, remaining)
+}
+
+// rewindBody resets the body of a request about to be sent again
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// wait blocks for d, or until the request is cancelled
+func wait(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
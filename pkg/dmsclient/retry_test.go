@@ -0,0 +1,141 @@
+package dmsclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxRetries: 2,
+	BaseDelay:  time.Millisecond,
+	MaxDelay:   50 * time.Millisecond,
+}
+
+// newTestServer answers the requests with the given responses in order, repeating the last one
+func newTestServer(responses ...func(w http.ResponseWriter)) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := requests
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		requests++
+		responses[i](w)
+	}))
+	return server, &requests
+}
+
+func respond(statusCode int, body string, header ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func newTestClient(t *testing.T, server *httptest.Server) Client {
+	c, err := NewClientWithOptions("token", localmetrics.NewMetricsCollector(), WithBaseURL(server.URL), WithRetryPolicy(testRetryPolicy))
+	assert.NoError(t, err)
+	return c
+}
+
+func TestRetryUnavailable(t *testing.T) {
+	server, requests := newTestServer(
+		respond(http.StatusServiceUnavailable, ""),
+		respond(http.StatusOK, `[{"token":"abc"}]`, headerRateLimitLimit, "100", headerRateLimitRemaining, "99"),
+	)
+	defer server.Close()
+
+	snitches, err := newTestClient(t, server).ListAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Snitch{{Token: "abc"}}, snitches)
+	assert.Equal(t, 2, *requests)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	server, requests := newTestServer(respond(http.StatusBadGateway, ""))
+	defer server.Close()
+
+	_, err := newTestClient(t, server).ListAll()
+	assert.Error(t, err)
+	_, throttled := apierror.RetryAfter(err)
+	assert.False(t, throttled)
+	assert.Equal(t, testRetryPolicy.MaxRetries+1, *requests)
+}
+
+func TestNoRetryOfUnsafeRequest(t *testing.T) {
+	server, requests := newTestServer(respond(http.StatusServiceUnavailable, ""))
+	defer server.Close()
+
+	_, err := newTestClient(t, server).Create(Snitch{Name: "snitch"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, *requests)
+}
+
+func TestRetryRateLimitedCreate(t *testing.T) {
+	server, requests := newTestServer(
+		respond(http.StatusTooManyRequests, ""),
+		respond(http.StatusCreated, `{"token":"abc","name":"snitch"}`),
+	)
+	defer server.Close()
+
+	snitch, err := newTestClient(t, server).Create(Snitch{Name: "snitch"})
+	assert.NoError(t, err)
+	assert.Equal(t, Snitch{Token: "abc", Name: "snitch"}, snitch)
+	assert.Equal(t, 2, *requests)
+}
+
+func TestThrottledBeyondMaxDelay(t *testing.T) {
+	server, requests := newTestServer(respond(http.StatusTooManyRequests, "", headerRetryAfter, "120"))
+	defer server.Close()
+
+	_, err := newTestClient(t, server).ListAll()
+	retryAfter, throttled := apierror.RetryAfter(err)
+	assert.True(t, throttled)
+	assert.Equal(t, 120*time.Second, retryAfter)
+	assert.Equal(t, 1, *requests)
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		header   []string
+		expected time.Duration
+	}{
+		{name: "none", expected: 0},
+		{name: "seconds", header: []string{headerRetryAfter, "30"}, expected: 30 * time.Second},
+		{name: "date", header: []string{headerRetryAfter, now.Add(time.Minute).Format(http.TimeFormat)}, expected: time.Minute},
+		{name: "past date", header: []string{headerRetryAfter, now.Add(-time.Minute).Format(http.TimeFormat)}, expected: 0},
+		{name: "reset timestamp", header: []string{headerRateLimitRemaining, "0", headerRateLimitReset, "1577836845"}, expected: 45 * time.Second},
+		{name: "reset seconds", header: []string{headerRateLimitRemaining, "0", headerRateLimitReset, "20"}, expected: 20 * time.Second},
+		{name: "quota left", header: []string{headerRateLimitRemaining, "5", headerRateLimitReset, "20"}, expected: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			for i := 0; i+1 < len(test.header); i += 2 {
+				header.Set(test.header[i], test.header[i+1])
+			}
+			assert.Equal(t, test.expected, retryAfter(header, now))
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := policy.backoff(attempt)
+		assert.True(t, delay >= 500*time.Millisecond, "attempt %d: %s", attempt, delay)
+		assert.True(t, delay <= policy.MaxDelay, "attempt %d: %s", attempt, delay)
+	}
+}
@@ -0,0 +1,261 @@
+package healthchecksclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+const (
+	// providerName names Healthchecks in the errors of the client
+	providerName = "healthchecks"
+
+	// DefaultBaseURL is the public Healthchecks.io instance
+	DefaultBaseURL = "https://healthchecks.io"
+
+	checksPath = "api/v3/checks/"
+)
+
+// Client is a wrapper interface for the healthchecksClient to allow for easier testing
+type Client interface {
+	ListAll() ([]Check, error)
+	Get(uuid string) (Check, error)
+	Create(newCheck Check) (Check, error)
+	Update(uuid string, updateCheck Check) (Check, error)
+	Delete(uuid string) error
+	Pause(uuid string) error
+	Ping(pingURL string) error
+}
+
+// Check Struct
+// Fields are omitted when empty so a Check only holding the changed fields
+// can be sent to Update without clearing the others.
+type Check struct {
+	UUID      string `json:"uuid,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Slug      string `json:"slug,omitempty"`
+	Tags      string `json:"tags,omitempty"`
+	Desc      string `json:"desc,omitempty"`
+	Timeout   int    `json:"timeout,omitempty"`
+	Grace     int    `json:"grace,omitempty"`
+	Status    string `json:"status,omitempty"`
+	LastPing  string `json:"last_ping,omitempty"`
+	PingURL   string `json:"ping_url,omitempty"`
+	UpdateURL string `json:"update_url,omitempty"`
+}
+
+// ID returns the uuid of the check. Older Healthchecks releases leave out the
+// uuid field, in which case it is taken from the update URL.
+func (c Check) ID() string {
+	if c.UUID != "" || c.UpdateURL == "" {
+		return c.UUID
+	}
+	return path.Base(strings.TrimSuffix(c.UpdateURL, "/"))
+}
+
+type checkList struct {
+	Checks []Check `json:"checks"`
+}
+
+// healthchecksClient wraps http client
+type healthchecksClient struct {
+	apiKey           string
+	BaseURL          *url.URL
+	httpClient       *http.Client
+	metricsCollector *localmetrics.MetricsCollector
+}
+
+// NewClient creates an API client for the Healthchecks instance at baseURL,
+// or for Healthchecks.io when baseURL is empty
+func NewClient(apiKey string, baseURL string, httpClient *http.Client, collector *localmetrics.MetricsCollector) (Client, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing healthchecks base URL: %v", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("Error parsing healthchecks base URL: %q is not an absolute URL", baseURL)
+	}
+	// keep the path of self-hosted instances served below the root
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	return &healthchecksClient{
+		apiKey:           apiKey,
+		BaseURL:          u,
+		httpClient:       httpClient,
+		metricsCollector: collector,
+	}, nil
+}
+
+func (c *healthchecksClient) newRequest(method, path string, body interface{}) (*http.Request, error) {
+	rel := &url.URL{Path: path}
+	u := c.BaseURL.ResolveReference(rel)
+	var buf io.ReadWriter
+
+	if body != nil {
+		buf = new(bytes.Buffer)
+		err := json.NewEncoder(buf).Encode(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, u.String(), buf)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "golang httpClient")
+	req.Header.Set("X-Api-Key", c.apiKey)
+	return req, nil
+}
+
+// do sends the request and turns any response outside of 2xx into an *apierror.Error
+func (c *healthchecksClient) do(req *http.Request, operation string) (*http.Response, error) {
+	start := time.Now()
+	defer func() {
+		c.metricsCollector.ObserveSnitchCallDuration(time.Since(start).Seconds(), operation)
+	}()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.metricsCollector.ObserveSnitchCallError()
+		return nil, fmt.Errorf("Error calling the API endpoint: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		c.metricsCollector.ObserveSnitchCallError()
+		return nil, apierror.New(providerName, operation, resp)
+	}
+
+	return resp, nil
+}
+
+// doCheck sends the request and decodes the check in the response
+func (c *healthchecksClient) doCheck(req *http.Request, operation string) (Check, error) {
+	var check Check
+
+	resp, err := c.do(req, operation)
+	if err != nil {
+		return check, err
+	}
+	defer resp.Body.Close()
+
+	decodeErr := json.NewDecoder(resp.Body).Decode(&check)
+	if decodeErr != nil {
+		err = fmt.Errorf("Error decoding check: %v", decodeErr)
+	}
+	return check, err
+}
+
+// ListAll checks of the project the API key belongs to
+func (c *healthchecksClient) ListAll() ([]Check, error) {
+	req, err := c.newRequest("GET", checksPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, "list_all")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var checks checkList
+	decodeErr := json.NewDecoder(resp.Body).Decode(&checks)
+	if decodeErr != nil {
+		err = fmt.Errorf("Error listing all checks: %v", decodeErr)
+	}
+
+	return checks.Checks, err
+}
+
+// Get a single check
+func (c *healthchecksClient) Get(uuid string) (Check, error) {
+	req, err := c.newRequest("GET", checksPath+uuid, nil)
+	if err != nil {
+		return Check{}, err
+	}
+	return c.doCheck(req, "describe")
+}
+
+// Create a check
+func (c *healthchecksClient) Create(newCheck Check) (Check, error) {
+	req, err := c.newRequest("POST", checksPath, newCheck)
+	if err != nil {
+		return Check{}, err
+	}
+	return c.doCheck(req, "create")
+}
+
+// Update the check. Fields left empty in updateCheck keep their value.
+func (c *healthchecksClient) Update(uuid string, updateCheck Check) (Check, error) {
+	req, err := c.newRequest("POST", checksPath+uuid, updateCheck)
+	if err != nil {
+		return Check{}, err
+	}
+	return c.doCheck(req, "update")
+}
+
+// Delete a check
+func (c *healthchecksClient) Delete(uuid string) error {
+	req, err := c.newRequest("DELETE", checksPath+uuid, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, "delete")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Pause the check until its next ping
+func (c *healthchecksClient) Pause(uuid string) error {
+	req, err := c.newRequest("POST", checksPath+uuid+"/pause", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, "pause")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Ping the check with a basic GET call to its ping url
+func (c *healthchecksClient) Ping(pingURL string) error {
+	req, err := http.NewRequest("GET", pingURL, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", "golang httpClient")
+
+	resp, err := c.do(req, "check_in")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
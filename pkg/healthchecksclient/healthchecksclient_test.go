@@ -0,0 +1,85 @@
+package healthchecksclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+func TestClient(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /hc/api/v3/checks/":
+			_, _ = w.Write([]byte(`{"checks":[{"name":"snitch","tags":"a b","timeout":900,"status":"new","ping_url":"https://hc-ping.com/0123","update_url":"https://hc.example.com/api/v3/checks/0123"}]}`))
+		case "POST /hc/api/v3/checks/":
+			var check Check
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&check))
+			check.UUID = "0123"
+			w.WriteHeader(http.StatusCreated)
+			assert.NoError(t, json.NewEncoder(w).Encode(check))
+		case "POST /hc/api/v3/checks/0123/pause":
+			_, _ = w.Write([]byte(`{"uuid":"0123","status":"paused"}`))
+		case "DELETE /hc/api/v3/checks/0123":
+			_, _ = w.Write([]byte(`{"uuid":"0123"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient("key", server.URL+"/hc", nil, localmetrics.NewMetricsCollector())
+	assert.NoError(t, err)
+
+	checks, err := c.ListAll()
+	assert.NoError(t, err)
+	if assert.Len(t, checks, 1) {
+		assert.Equal(t, "0123", checks[0].ID())
+		assert.Equal(t, 900, checks[0].Timeout)
+	}
+
+	created, err := c.Create(Check{Name: "snitch", Timeout: 900})
+	assert.NoError(t, err)
+	assert.Equal(t, Check{UUID: "0123", Name: "snitch", Timeout: 900}, created)
+
+	assert.NoError(t, c.Pause("0123"))
+	assert.NoError(t, c.Delete("0123"))
+
+	_, err = c.Get("4567")
+	assert.True(t, apierror.IsNotFound(err))
+
+	unauthorized, err := NewClient("wrong", server.URL+"/hc", nil, localmetrics.NewMetricsCollector())
+	assert.NoError(t, err)
+	_, err = unauthorized.ListAll()
+	assert.EqualError(t, err, "Error calling the API endpoint: list_all returned status code 401: please check the healthchecks credentials")
+	assert.True(t, apierror.IsUnauthorized(err))
+
+	assert.Equal(t, []string{
+		"GET /hc/api/v3/checks/",
+		"POST /hc/api/v3/checks/",
+		"POST /hc/api/v3/checks/0123/pause",
+		"DELETE /hc/api/v3/checks/0123",
+		"GET /hc/api/v3/checks/4567",
+		"GET /hc/api/v3/checks/",
+	}, requests)
+}
+
+func TestNewClientBaseURL(t *testing.T) {
+	_, err := NewClient("key", "", nil, nil)
+	assert.NoError(t, err)
+
+	_, err = NewClient("key", "healthchecks.example.com", nil, nil)
+	assert.Error(t, err)
+}
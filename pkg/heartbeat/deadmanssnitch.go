@@ -0,0 +1,121 @@
+package heartbeat
+
+import (
+	"fmt"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+)
+
+// dmsProvider manages heartbeats as snitches in Dead Man's Snitch
+type dmsProvider struct {
+	client dmsclient.Client
+}
+
+// NewDMSProvider creates a Provider backed by the Dead Man's Snitch client
+func NewDMSProvider(client dmsclient.Client) Provider {
+	return &dmsProvider{client: client}
+}
+
+func fromSnitch(s dmsclient.Snitch) Heartbeat {
+	return Heartbeat{
+		Token:      s.Token,
+		Name:       s.Name,
+		Tags:       s.Tags,
+		Notes:      s.Notes,
+		Interval:   s.CurrentInterval(),
+		AlertType:  s.AlertType,
+		Status:     s.Status,
+		CheckInURL: s.CheckInURL,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+func fromSnitches(snitches []dmsclient.Snitch) []Heartbeat {
+	var heartbeats []Heartbeat
+	for _, s := range snitches {
+		heartbeats = append(heartbeats, fromSnitch(s))
+	}
+	return heartbeats
+}
+
+func toSnitch(h Heartbeat) dmsclient.Snitch {
+	return dmsclient.Snitch{
+		Token:      h.Token,
+		Name:       h.Name,
+		Tags:       h.Tags,
+		Notes:      h.Notes,
+		Interval:   h.Interval,
+		AlertType:  h.AlertType,
+		CheckInURL: h.CheckInURL,
+	}
+}
+
+// ListAll snitches
+func (p *dmsProvider) ListAll() ([]Heartbeat, error) {
+	snitches, err := p.client.ListAll()
+	return fromSnitches(snitches), err
+}
+
+// FindByName returns the snitches called name
+func (p *dmsProvider) FindByName(name string) ([]Heartbeat, error) {
+	snitches, err := p.client.FindSnitchesByName(name)
+	return fromSnitches(snitches), err
+}
+
+// FindByToken returns the snitch identified by token, or nil when it doesn't exist
+func (p *dmsProvider) FindByToken(token string) (*Heartbeat, error) {
+	snitch, err := p.client.List(token)
+	if apierror.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h := fromSnitch(snitch)
+	return &h, nil
+}
+
+// Create a snitch
+func (p *dmsProvider) Create(newHeartbeat Heartbeat) (Heartbeat, error) {
+	snitch, err := p.client.Create(toSnitch(newHeartbeat))
+	return fromSnitch(snitch), err
+}
+
+// Update the snitch
+func (p *dmsProvider) Update(updateHeartbeat Heartbeat) (Heartbeat, error) {
+	snitch, err := p.client.Update(toSnitch(updateHeartbeat))
+	return fromSnitch(snitch), err
+}
+
+// Delete a snitch
+func (p *dmsProvider) Delete(token string) error {
+	deleted, err := p.client.Delete(token)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("Error deleting snitch %s", token)
+	}
+	return nil
+}
+
+// Pause a snitch
+func (p *dmsProvider) Pause(token string) error {
+	return p.client.Pause(token)
+}
+
+// Unpause a snitch
+func (p *dmsProvider) Unpause(token string) error {
+	return p.client.Unpause(token)
+}
+
+// CheckIn the snitch
+func (p *dmsProvider) CheckIn(h Heartbeat) error {
+	return p.client.CheckIn(toSnitch(h))
+}
+
+// CanRename is true, snitches are identified by their token
+func (p *dmsProvider) CanRename() bool {
+	return true
+}
@@ -0,0 +1,148 @@
+package heartbeat
+
+import (
+	"strings"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/healthchecksclient"
+)
+
+// intervalTimeouts maps the snitch intervals to the timeout, in seconds, of a Healthchecks check
+var intervalTimeouts = map[string]int{
+	"15_minute": 15 * 60,
+	"30_minute": 30 * 60,
+	"hourly":    60 * 60,
+	"daily":     24 * 60 * 60,
+	"weekly":    7 * 24 * 60 * 60,
+	"monthly":   30 * 24 * 60 * 60,
+}
+
+// checkStatuses maps the statuses of a Healthchecks check to the Heartbeat statuses
+var checkStatuses = map[string]string{
+	"new":     StatusPending,
+	"started": StatusHealthy,
+	"up":      StatusHealthy,
+	"grace":   StatusHealthy,
+	"down":    StatusFailed,
+	"paused":  StatusPaused,
+}
+
+// healthchecksProvider manages heartbeats as checks in Healthchecks
+type healthchecksProvider struct {
+	client healthchecksclient.Client
+}
+
+// NewHealthchecksProvider creates a Provider backed by the Healthchecks client
+func NewHealthchecksProvider(client healthchecksclient.Client) Provider {
+	return &healthchecksProvider{client: client}
+}
+
+func fromCheck(c healthchecksclient.Check) Heartbeat {
+	h := Heartbeat{
+		Token:      c.ID(),
+		Name:       c.Name,
+		Tags:       strings.Fields(c.Tags),
+		Notes:      c.Desc,
+		Status:     c.Status,
+		CheckInURL: c.PingURL,
+	}
+	for interval, timeout := range intervalTimeouts {
+		if timeout == c.Timeout {
+			h.Interval = interval
+		}
+	}
+	if status, ok := checkStatuses[c.Status]; ok {
+		h.Status = status
+	}
+	return h
+}
+
+// toCheck converts a Heartbeat to a check. The alert type is dropped, Healthchecks
+// always alerts once a check is late.
+func toCheck(h Heartbeat) healthchecksclient.Check {
+	return healthchecksclient.Check{
+		Name:    h.Name,
+		Tags:    strings.Join(h.Tags, " "),
+		Desc:    h.Notes,
+		Timeout: intervalTimeouts[h.Interval],
+	}
+}
+
+// ListAll checks
+func (p *healthchecksProvider) ListAll() ([]Heartbeat, error) {
+	checks, err := p.client.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var heartbeats []Heartbeat
+	for _, c := range checks {
+		heartbeats = append(heartbeats, fromCheck(c))
+	}
+	return heartbeats, nil
+}
+
+// FindByName returns the checks called name
+func (p *healthchecksProvider) FindByName(name string) ([]Heartbeat, error) {
+	return findByName(p, name)
+}
+
+// FindByToken returns the check identified by its UUID, or nil when it doesn't exist
+func (p *healthchecksProvider) FindByToken(token string) (*Heartbeat, error) {
+	check, err := p.client.Get(token)
+	if apierror.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h := fromCheck(check)
+	return &h, nil
+}
+
+// Create a check
+func (p *healthchecksProvider) Create(newHeartbeat Heartbeat) (Heartbeat, error) {
+	check, err := p.client.Create(toCheck(newHeartbeat))
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	return fromCheck(check), nil
+}
+
+// Update the check
+func (p *healthchecksProvider) Update(updateHeartbeat Heartbeat) (Heartbeat, error) {
+	check, err := p.client.Update(updateHeartbeat.Token, toCheck(updateHeartbeat))
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	return fromCheck(check), nil
+}
+
+// Delete a check
+func (p *healthchecksProvider) Delete(token string) error {
+	return p.client.Delete(token)
+}
+
+// Pause a check
+func (p *healthchecksProvider) Pause(token string) error {
+	return p.client.Pause(token)
+}
+
+// Unpause a check. Healthchecks resumes a paused check on its next ping, so Unpause pings it.
+func (p *healthchecksProvider) Unpause(token string) error {
+	check, err := p.client.Get(token)
+	if err != nil {
+		return err
+	}
+	return p.client.Ping(check.PingURL)
+}
+
+// CheckIn pings the check
+func (p *healthchecksProvider) CheckIn(h Heartbeat) error {
+	return p.client.Ping(h.CheckInURL)
+}
+
+// CanRename is true, checks are identified by their UUID
+func (p *healthchecksProvider) CanRename() bool {
+	return true
+}
@@ -0,0 +1,77 @@
+package heartbeat
+
+import "errors"
+
+// Statuses a Heartbeat reports, following the vocabulary of Dead Man's Snitch
+const (
+	StatusPending = "pending"
+	StatusHealthy = "healthy"
+	StatusFailed  = "failed"
+	StatusPaused  = "paused"
+)
+
+// ErrRenameUnsupported is returned by Update when renaming a heartbeat of a provider that identifies heartbeats by name
+var ErrRenameUnsupported = errors.New("Unable to rename the heartbeat: the provider identifies heartbeats by name")
+
+// Provider is the interface the operator uses to manage heartbeats, regardless of the service hosting them
+type Provider interface {
+	ListAll() ([]Heartbeat, error)
+	FindByName(name string) ([]Heartbeat, error)
+	FindByToken(token string) (*Heartbeat, error)
+	Create(newHeartbeat Heartbeat) (Heartbeat, error)
+	Update(updateHeartbeat Heartbeat) (Heartbeat, error)
+	Delete(token string) error
+	Pause(token string) error
+	Unpause(token string) error
+	CheckIn(h Heartbeat) error
+	// CanRename reports whether Update can change the name of a heartbeat
+	CanRename() bool
+}
+
+// Heartbeat is a monitor expecting regular check ins, i.e. a snitch in Dead Man's Snitch,
+// a check in Healthchecks or a heartbeat in Opsgenie.
+// Empty fields are left alone by Update. Providers that have no notion of a field,
+// like the alert type in Healthchecks, leave it empty when reading a Heartbeat.
+type Heartbeat struct {
+	// Token identifies the heartbeat at the provider
+	Token      string
+	Name       string
+	Tags       []string
+	Notes      string
+	Interval   string
+	AlertType  string
+	Status     string
+	CheckInURL string
+	// CreatedAt is when the heartbeat was created, for providers that report it
+	CreatedAt string
+	// CheckInAuthType and CheckInCredentials make up the Authorization header checking in
+	// requires, for providers that don't accept anonymous check ins
+	CheckInAuthType    string
+	CheckInCredentials string
+}
+
+// NewHeartbeat creates a new Heartbeat only requiring a few items
+func NewHeartbeat(name string, tags []string, interval string, alertType string) Heartbeat {
+	return Heartbeat{
+		Name:      name,
+		Tags:      tags,
+		Interval:  interval,
+		AlertType: alertType,
+	}
+}
+
+// findByName filters the heartbeats listed by a provider down to the ones called name
+func findByName(p Provider, name string) ([]Heartbeat, error) {
+	var found []Heartbeat
+	listed, err := p.ListAll()
+	if err != nil {
+		return found, err
+	}
+
+	for _, h := range listed {
+		if h.Name == name {
+			found = append(found, h)
+		}
+	}
+	return found, nil
+}
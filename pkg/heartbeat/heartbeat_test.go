@@ -0,0 +1,220 @@
+package heartbeat
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/dmsclient"
+	mockdms "github.com/openshift/deadmanssnitch-operator/pkg/dmsclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/healthchecksclient"
+	mockhc "github.com/openshift/deadmanssnitch-operator/pkg/healthchecksclient/mock"
+	"github.com/openshift/deadmanssnitch-operator/pkg/opsgenieclient"
+	mockog "github.com/openshift/deadmanssnitch-operator/pkg/opsgenieclient/mock"
+)
+
+func TestDMSProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockClient := mockdms.NewMockClient(mockCtrl)
+	provider := NewDMSProvider(mockClient)
+
+	mockClient.EXPECT().ListAll().Return([]dmsclient.Snitch{
+		{
+			Token:      "abc",
+			Name:       "snitch",
+			Tags:       []string{"a"},
+			Status:     "pending",
+			CheckInURL: "https://nosnch.in/abc",
+			AlertType:  "basic",
+			Type:       &dmsclient.SnitchType{Interval: "hourly"},
+		},
+	}, nil)
+	heartbeats, err := provider.ListAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Heartbeat{
+		{
+			Token:      "abc",
+			Name:       "snitch",
+			Tags:       []string{"a"},
+			Interval:   "hourly",
+			AlertType:  "basic",
+			Status:     StatusPending,
+			CheckInURL: "https://nosnch.in/abc",
+		},
+	}, heartbeats)
+
+	mockClient.EXPECT().List("abc").Return(dmsclient.Snitch{Token: "abc", Name: "snitch", CreatedAt: "2020-01-01T00:00:00.000Z"}, nil)
+	found, err := provider.FindByToken("abc")
+	assert.NoError(t, err)
+	assert.Equal(t, &Heartbeat{Token: "abc", Name: "snitch", CreatedAt: "2020-01-01T00:00:00.000Z"}, found)
+
+	mockClient.EXPECT().List("gone").Return(dmsclient.Snitch{}, &dmsclient.APIError{StatusCode: http.StatusNotFound})
+	found, err = provider.FindByToken("gone")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	mockClient.EXPECT().Update(dmsclient.Snitch{Token: "abc", Interval: "daily"}).Return(dmsclient.Snitch{Token: "abc"}, nil)
+	_, err = provider.Update(Heartbeat{Token: "abc", Interval: "daily"})
+	assert.NoError(t, err)
+
+	mockClient.EXPECT().Delete("abc").Return(false, nil)
+	assert.Error(t, provider.Delete("abc"))
+}
+
+func TestHealthchecksProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockClient := mockhc.NewMockClient(mockCtrl)
+	provider := NewHealthchecksProvider(mockClient)
+
+	mockClient.EXPECT().Create(healthchecksclient.Check{
+		Name:    "snitch",
+		Tags:    "a b",
+		Desc:    "notes",
+		Timeout: 900,
+	}).Return(healthchecksclient.Check{
+		Name:      "snitch",
+		Tags:      "a b",
+		Desc:      "notes",
+		Timeout:   900,
+		Status:    "new",
+		PingURL:   "https://hc-ping.com/0123",
+		UpdateURL: "https://healthchecks.io/api/v3/checks/0123",
+	}, nil)
+	h := NewHeartbeat("snitch", []string{"a", "b"}, "15_minute", "smart")
+	h.Notes = "notes"
+	created, err := provider.Create(h)
+	assert.NoError(t, err)
+	assert.Equal(t, Heartbeat{
+		Token:      "0123",
+		Name:       "snitch",
+		Tags:       []string{"a", "b"},
+		Notes:      "notes",
+		Interval:   "15_minute",
+		Status:     StatusPending,
+		CheckInURL: "https://hc-ping.com/0123",
+	}, created)
+
+	mockClient.EXPECT().ListAll().Return([]healthchecksclient.Check{
+		{UUID: "0123", Name: "snitch", Status: "down"},
+		{UUID: "4567", Name: "other", Status: "up"},
+	}, nil)
+	found, err := provider.FindByName("snitch")
+	assert.NoError(t, err)
+	assert.Equal(t, []Heartbeat{{Token: "0123", Name: "snitch", Tags: []string{}, Status: StatusFailed}}, found)
+
+	mockClient.EXPECT().Get("0123").Return(healthchecksclient.Check{UUID: "0123", Name: "snitch", Status: "up"}, nil)
+	byToken, err := provider.FindByToken("0123")
+	assert.NoError(t, err)
+	assert.Equal(t, &Heartbeat{Token: "0123", Name: "snitch", Tags: []string{}, Status: StatusHealthy}, byToken)
+
+	// a check running a job since its start signal is as healthy as one that was pinged
+	mockClient.EXPECT().Get("89ab").Return(healthchecksclient.Check{UUID: "89ab", Name: "started", Status: "started"}, nil)
+	started, err := provider.FindByToken("89ab")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusHealthy, started.Status)
+
+	mockClient.EXPECT().Get("4567").Return(healthchecksclient.Check{}, &apierror.Error{Provider: "healthchecks", Operation: "describe", StatusCode: http.StatusNotFound})
+	missing, err := provider.FindByToken("4567")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	mockClient.EXPECT().Update("0123", healthchecksclient.Check{Timeout: 3600}).Return(healthchecksclient.Check{UUID: "0123"}, nil)
+	_, err = provider.Update(Heartbeat{Token: "0123", Interval: "hourly"})
+	assert.NoError(t, err)
+
+	mockClient.EXPECT().Ping("https://hc-ping.com/0123").Return(nil)
+	assert.NoError(t, provider.CheckIn(created))
+
+	mockClient.EXPECT().Get("0123").Return(healthchecksclient.Check{UUID: "0123", PingURL: "https://hc-ping.com/0123"}, nil)
+	mockClient.EXPECT().Ping("https://hc-ping.com/0123").Return(nil)
+	assert.NoError(t, provider.Unpause("0123"))
+}
+
+func TestOpsgenieProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockClient := mockog.NewMockClient(mockCtrl)
+	provider := NewOpsgenieProvider(mockClient, "ping-key")
+	pingURL := "https://api.opsgenie.com/v2/heartbeats/snitch/ping"
+	mockClient.EXPECT().PingURL("snitch").Return(pingURL).AnyTimes()
+
+	enabled := true
+	mockClient.EXPECT().Create(opsgenieclient.Heartbeat{
+		Name:         "snitch",
+		Description:  "notes",
+		AlertTags:    []string{"a"},
+		Interval:     1,
+		IntervalUnit: "hours",
+		Enabled:      &enabled,
+	}).Return(opsgenieclient.Heartbeat{Name: "snitch", Enabled: &enabled}, nil)
+	h := NewHeartbeat("snitch", []string{"a"}, "hourly", "basic")
+	h.Notes = "notes"
+	created, err := provider.Create(h)
+	assert.NoError(t, err)
+	assert.Equal(t, Heartbeat{
+		Token:              "snitch",
+		Name:               "snitch",
+		Tags:               []string{"a"},
+		Notes:              "notes",
+		Interval:           "hourly",
+		Status:             StatusPending,
+		CheckInURL:         pingURL,
+		CheckInAuthType:    "GenieKey",
+		CheckInCredentials: "ping-key",
+	}, created)
+
+	disabled := false
+	mockClient.EXPECT().ListAll().Return([]opsgenieclient.Heartbeat{
+		{Name: "snitch", Interval: 7, IntervalUnit: "days", Expired: true, Enabled: &disabled},
+	}, nil)
+	listed, err := provider.ListAll()
+	assert.NoError(t, err)
+	if assert.Len(t, listed, 1) {
+		assert.Equal(t, "weekly", listed[0].Interval)
+		assert.Equal(t, StatusPaused, listed[0].Status)
+	}
+
+	mockClient.EXPECT().Update(opsgenieclient.Heartbeat{Name: "snitch", Interval: 15, IntervalUnit: "minutes"}).Return(opsgenieclient.Heartbeat{Name: "snitch"}, nil)
+	_, err = provider.Update(Heartbeat{Token: "snitch", Interval: "15_minute"})
+	assert.NoError(t, err)
+
+	// the name is the token, so a heartbeat is patched under the name it has and can't be renamed
+	mockClient.EXPECT().Update(opsgenieclient.Heartbeat{Name: "snitch", AlertTags: []string{"b"}}).Return(opsgenieclient.Heartbeat{Name: "snitch"}, nil)
+	_, err = provider.Update(Heartbeat{Token: "snitch", Name: "snitch", Tags: []string{"b"}})
+	assert.NoError(t, err)
+	_, err = provider.Update(Heartbeat{Token: "snitch", Name: "renamed"})
+	assert.Equal(t, ErrRenameUnsupported, err)
+
+	mockClient.EXPECT().Get("snitch").Return(opsgenieclient.Heartbeat{Name: "snitch", Interval: 1, IntervalUnit: "hours"}, nil)
+	byToken, err := provider.FindByToken("snitch")
+	assert.NoError(t, err)
+	if assert.NotNil(t, byToken) {
+		assert.Equal(t, "hourly", byToken.Interval)
+		assert.Equal(t, pingURL, byToken.CheckInURL)
+	}
+
+	mockClient.EXPECT().Get("gone").Return(opsgenieclient.Heartbeat{}, &apierror.Error{Provider: "opsgenie", Operation: "describe", StatusCode: http.StatusNotFound})
+	missing, err := provider.FindByToken("gone")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	mockClient.EXPECT().Disable("snitch").Return(nil)
+	assert.NoError(t, provider.Pause("snitch"))
+
+	mockClient.EXPECT().Enable("snitch").Return(nil)
+	assert.NoError(t, provider.Unpause("snitch"))
+}
+
+func TestCanRename(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	assert.True(t, NewDMSProvider(mockdms.NewMockClient(mockCtrl)).CanRename())
+	assert.True(t, NewHealthchecksProvider(mockhc.NewMockClient(mockCtrl)).CanRename())
+	assert.False(t, NewOpsgenieProvider(mockog.NewMockClient(mockCtrl), "ping-key").CanRename())
+}
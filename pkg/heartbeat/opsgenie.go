@@ -0,0 +1,172 @@
+package heartbeat
+
+import (
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/opsgenieclient"
+)
+
+// opsgenieInterval is the interval of an Opsgenie heartbeat, a number of units
+type opsgenieInterval struct {
+	interval int
+	unit     string
+}
+
+// opsgenieIntervals maps the snitch intervals to the intervals of an Opsgenie heartbeat
+var opsgenieIntervals = map[string]opsgenieInterval{
+	"15_minute": {15, "minutes"},
+	"30_minute": {30, "minutes"},
+	"hourly":    {1, "hours"},
+	"daily":     {1, "days"},
+	"weekly":    {7, "days"},
+	"monthly":   {30, "days"},
+}
+
+// opsgenieProvider manages heartbeats in Opsgenie. Opsgenie identifies heartbeats
+// by name, so the name doubles as the token.
+type opsgenieProvider struct {
+	client opsgenieclient.Client
+	// pingAPIKey is handed to the clusters to ping their heartbeat
+	pingAPIKey string
+}
+
+// NewOpsgenieProvider creates a Provider backed by the Opsgenie client. The clusters
+// authenticate their pings with pingAPIKey.
+func NewOpsgenieProvider(client opsgenieclient.Client, pingAPIKey string) Provider {
+	return &opsgenieProvider{client: client, pingAPIKey: pingAPIKey}
+}
+
+func (p *opsgenieProvider) fromOpsgenie(o opsgenieclient.Heartbeat) Heartbeat {
+	h := Heartbeat{
+		Token:              o.Name,
+		Name:               o.Name,
+		Tags:               o.AlertTags,
+		Notes:              o.Description,
+		Status:             StatusHealthy,
+		CheckInURL:         p.client.PingURL(o.Name),
+		CheckInAuthType:    opsgenieclient.AuthorizationType,
+		CheckInCredentials: p.pingAPIKey,
+	}
+	for interval, oi := range opsgenieIntervals {
+		if oi.interval == o.Interval && oi.unit == o.IntervalUnit {
+			h.Interval = interval
+		}
+	}
+	if o.Expired {
+		h.Status = StatusFailed
+	}
+	if o.Enabled != nil && !*o.Enabled {
+		h.Status = StatusPaused
+	}
+	return h
+}
+
+// toOpsgenie converts a Heartbeat to an Opsgenie heartbeat. The alert type is dropped,
+// Opsgenie always alerts once a heartbeat expires.
+func toOpsgenie(h Heartbeat) opsgenieclient.Heartbeat {
+	o := opsgenieclient.Heartbeat{
+		Name:        h.Name,
+		Description: h.Notes,
+		AlertTags:   h.Tags,
+	}
+	if h.Name == "" {
+		o.Name = h.Token
+	}
+	if oi, ok := opsgenieIntervals[h.Interval]; ok {
+		o.Interval = oi.interval
+		o.IntervalUnit = oi.unit
+	}
+	return o
+}
+
+// ListAll heartbeats
+func (p *opsgenieProvider) ListAll() ([]Heartbeat, error) {
+	listed, err := p.client.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var heartbeats []Heartbeat
+	for _, o := range listed {
+		heartbeats = append(heartbeats, p.fromOpsgenie(o))
+	}
+	return heartbeats, nil
+}
+
+// FindByName returns the heartbeats called name
+func (p *opsgenieProvider) FindByName(name string) ([]Heartbeat, error) {
+	return findByName(p, name)
+}
+
+// FindByToken returns the heartbeat identified by its name, or nil when it doesn't exist
+func (p *opsgenieProvider) FindByToken(token string) (*Heartbeat, error) {
+	o, err := p.client.Get(token)
+	if apierror.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h := p.fromOpsgenie(o)
+	return &h, nil
+}
+
+// Create a heartbeat. Opsgenie only returns the name and state of the new heartbeat,
+// the other fields are taken from newHeartbeat.
+func (p *opsgenieProvider) Create(newHeartbeat Heartbeat) (Heartbeat, error) {
+	enabled := true
+	o := toOpsgenie(newHeartbeat)
+	o.Enabled = &enabled
+
+	created, err := p.client.Create(o)
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	o.Enabled = created.Enabled
+	o.Expired = created.Expired
+
+	h := p.fromOpsgenie(o)
+	// nothing pinged the heartbeat yet
+	h.Status = StatusPending
+	return h, nil
+}
+
+// Update the heartbeat identified by the token. The name can't be changed, as it doubles as the token.
+func (p *opsgenieProvider) Update(updateHeartbeat Heartbeat) (Heartbeat, error) {
+	if updateHeartbeat.Token != "" && updateHeartbeat.Name != "" && updateHeartbeat.Name != updateHeartbeat.Token {
+		return Heartbeat{}, ErrRenameUnsupported
+	}
+	o := toOpsgenie(updateHeartbeat)
+	if updateHeartbeat.Token != "" {
+		o.Name = updateHeartbeat.Token
+	}
+	updated, err := p.client.Update(o)
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	return p.fromOpsgenie(updated), nil
+}
+
+// Delete a heartbeat
+func (p *opsgenieProvider) Delete(token string) error {
+	return p.client.Delete(token)
+}
+
+// Pause a heartbeat by disabling it
+func (p *opsgenieProvider) Pause(token string) error {
+	return p.client.Disable(token)
+}
+
+// Unpause a heartbeat by enabling it
+func (p *opsgenieProvider) Unpause(token string) error {
+	return p.client.Enable(token)
+}
+
+// CheckIn pings the heartbeat
+func (p *opsgenieProvider) CheckIn(h Heartbeat) error {
+	return p.client.Ping(h.Token)
+}
+
+// CanRename is false, Opsgenie identifies heartbeats by name so a heartbeat keeps the name it was created with
+func (p *opsgenieProvider) CanRename() bool {
+	return false
+}
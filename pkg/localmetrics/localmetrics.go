@@ -26,15 +26,28 @@ var (
 )
 
 const (
-	operatorName      = "deadmanssnitch-operator"
-	snitchMethodLabel = "method"
+	operatorName           = "deadmanssnitch-operator"
+	snitchMethodLabel      = "method"
+	snitchFieldLabel       = "field"
+	snitchCacheResultLabel = "result"
+	dmsiLabel              = "dmsi"
 )
 
 type MetricsCollector struct {
-	ReconcileDuration  prometheus.Histogram
-	apiCallDuration    *prometheus.HistogramVec
-	snitchCallErrors   prometheus.Counter
-	snitchCallDuration *prometheus.HistogramVec
+	ReconcileDuration        prometheus.Histogram
+	apiCallDuration          *prometheus.HistogramVec
+	snitchCallErrors         prometheus.Counter
+	snitchCallDuration       *prometheus.HistogramVec
+	snitchCallRetries        *prometheus.CounterVec
+	snitchThrottled          *prometheus.CounterVec
+	snitchRateLimit          prometheus.Gauge
+	snitchRateLimitRemaining prometheus.Gauge
+	snitchCacheLookups       *prometheus.CounterVec
+	snitchDrift              *prometheus.CounterVec
+	duplicateSnitchesFound   prometheus.Counter
+	duplicateSnitchesRemoved prometheus.Counter
+	clusterReconcileErrors   *prometheus.CounterVec
+	failedClusters           *prometheus.GaugeVec
 }
 
 func (m MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -42,6 +55,16 @@ func (m MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
 	m.apiCallDuration.Describe(ch)
 	m.snitchCallDuration.Describe(ch)
 	m.snitchCallErrors.Describe(ch)
+	m.snitchCallRetries.Describe(ch)
+	m.snitchThrottled.Describe(ch)
+	m.snitchRateLimit.Describe(ch)
+	m.snitchRateLimitRemaining.Describe(ch)
+	m.snitchCacheLookups.Describe(ch)
+	m.snitchDrift.Describe(ch)
+	m.duplicateSnitchesFound.Describe(ch)
+	m.duplicateSnitchesRemoved.Describe(ch)
+	m.clusterReconcileErrors.Describe(ch)
+	m.failedClusters.Describe(ch)
 }
 
 func (m MetricsCollector) Collect(ch chan<- prometheus.Metric) {
@@ -49,6 +72,16 @@ func (m MetricsCollector) Collect(ch chan<- prometheus.Metric) {
 	m.apiCallDuration.Collect(ch)
 	m.snitchCallErrors.Collect(ch)
 	m.snitchCallDuration.Collect(ch)
+	m.snitchCallRetries.Collect(ch)
+	m.snitchThrottled.Collect(ch)
+	m.snitchRateLimit.Collect(ch)
+	m.snitchRateLimitRemaining.Collect(ch)
+	m.snitchCacheLookups.Collect(ch)
+	m.snitchDrift.Collect(ch)
+	m.duplicateSnitchesFound.Collect(ch)
+	m.duplicateSnitchesRemoved.Collect(ch)
+	m.clusterReconcileErrors.Collect(ch)
+	m.failedClusters.Collect(ch)
 }
 
 func NewMetricsCollector() *MetricsCollector {
@@ -79,6 +112,56 @@ func NewMetricsCollector() *MetricsCollector {
 			Help:        "Distribution of the timings of API calls to DMS in seconds",
 			ConstLabels: prometheus.Labels{"name": operatorName},
 		}, []string{snitchMethodLabel}),
+		snitchCallRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dms_operator_snitch_api_call_retries_total",
+			Help:        "Counter of the calls to the DMS API sent again after a failed attempt",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}, []string{snitchMethodLabel}),
+		snitchThrottled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dms_operator_snitch_api_throttled_total",
+			Help:        "Counter of the calls to the DMS API rejected by its rate limit",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}, []string{snitchMethodLabel}),
+		snitchRateLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "dms_operator_snitch_api_rate_limit",
+			Help:        "Number of calls to the DMS API allowed in the current rate limit window, as last reported by DMS",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}),
+		snitchRateLimitRemaining: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "dms_operator_snitch_api_rate_limit_remaining",
+			Help:        "Number of calls to the DMS API left in the current rate limit window, as last reported by DMS",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}),
+		snitchCacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dms_operator_snitch_cache_lookups_total",
+			Help:        "Counter of the lookups of snitches in the cached DMS listing, by whether the cache could answer them",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}, []string{snitchCacheResultLabel}),
+		snitchDrift: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dms_operator_snitch_drift_corrected_total",
+			Help:        "Counter of the snitch fields found to differ from the desired state and corrected in DMS",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}, []string{snitchFieldLabel}),
+		duplicateSnitchesFound: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "dms_operator_duplicate_snitches_found_total",
+			Help:        "Counter of the extra snitches found named like the snitch of a cluster, deleted or tagged as duplicates",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}),
+		duplicateSnitchesRemoved: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "dms_operator_duplicate_snitches_removed_total",
+			Help:        "Counter of the duplicate snitches of a cluster deleted from DMS",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}),
+		clusterReconcileErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dms_operator_clusterdeployment_reconcile_errors_total",
+			Help:        "Counter of the ClusterDeployments that failed to reconcile, by DeadmansSnitchIntegration",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}, []string{dmsiLabel}),
+		failedClusters: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "dms_operator_failed_clusterdeployments",
+			Help:        "Number of ClusterDeployments that failed their last reconcile, by DeadmansSnitchIntegration",
+			ConstLabels: prometheus.Labels{"name": operatorName},
+		}, []string{dmsiLabel}),
 	}
 }
 
@@ -111,6 +194,57 @@ func (m *MetricsCollector) ObserveSnitchCallError() {
 	m.snitchCallErrors.Inc()
 }
 
+// ObserveSnitchCallRetry increments the retry counter of an operation sent again to the Dead Man Snitch API
+func (m *MetricsCollector) ObserveSnitchCallRetry(operation string) {
+	m.snitchCallRetries.With(prometheus.Labels{snitchMethodLabel: operation}).Inc()
+}
+
+// ObserveSnitchThrottled increments the counter of operations rate limited by the Dead Man Snitch API
+func (m *MetricsCollector) ObserveSnitchThrottled(operation string) {
+	m.snitchThrottled.With(prometheus.Labels{snitchMethodLabel: operation}).Inc()
+}
+
+// ObserveSnitchRateLimit records the rate limit quota reported by the Dead Man Snitch API
+func (m *MetricsCollector) ObserveSnitchRateLimit(limit float64, remaining float64) {
+	m.snitchRateLimit.Set(limit)
+	m.snitchRateLimitRemaining.Set(remaining)
+}
+
+// ObserveSnitchCacheLookup increments the hit or miss counter of the cached Dead Man Snitch listing
+func (m *MetricsCollector) ObserveSnitchCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.snitchCacheLookups.With(prometheus.Labels{snitchCacheResultLabel: result}).Inc()
+}
+
+// ObserveSnitchDrift increments the drift counter for a snitch field that was corrected in Dead Man Snitch
+func (m *MetricsCollector) ObserveSnitchDrift(field string) {
+	m.snitchDrift.With(prometheus.Labels{snitchFieldLabel: field}).Inc()
+}
+
+// ObserveDuplicateSnitchFound increments the counter of extra snitches found for a cluster
+func (m *MetricsCollector) ObserveDuplicateSnitchFound() {
+	m.duplicateSnitchesFound.Inc()
+}
+
+// ObserveDuplicateSnitchRemoved increments the counter of duplicate snitches deleted from Dead Man Snitch
+func (m *MetricsCollector) ObserveDuplicateSnitchRemoved() {
+	m.duplicateSnitchesRemoved.Inc()
+}
+
+// ObserveClusterDeploymentReconcileError increments the error counter of the DeadmansSnitchIntegration
+// for a ClusterDeployment that failed to reconcile
+func (m *MetricsCollector) ObserveClusterDeploymentReconcileError(dmsi string) {
+	m.clusterReconcileErrors.With(prometheus.Labels{dmsiLabel: dmsi}).Inc()
+}
+
+// SetFailedClusterDeployments records how many ClusterDeployments of the DeadmansSnitchIntegration failed their last reconcile
+func (m *MetricsCollector) SetFailedClusterDeployments(dmsi string, failed int) {
+	m.failedClusters.With(prometheus.Labels{dmsiLabel: dmsi}).Set(float64(failed))
+}
+
 // resourceFrom normalizes an API request URL, including removing individual namespace and
 // resource names, to yield a string of the form:
 //     $group/$version/$kind[/{NAME}[/...]]
@@ -0,0 +1,166 @@
+// Package maintenance evaluates the schedules of DeadmansSnitchMaintenanceWindows, and tracks which
+// windows hold the snitch of a ClusterDeployment paused.
+package maintenance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// PausedAnnotation is set on the hub secret of a cluster whose snitch is paused by maintenance windows.
+	// It holds the comma separated keys of the windows, the snitch is unpaused once the last one closes.
+	PausedAnnotation = "dms.managed.openshift.io/paused-for-maintenance"
+
+	// maxOccurrences bounds how many occurrences of a schedule are chained into a single open window
+	maxOccurrences = 1000
+)
+
+// State is where a maintenance window stands at a point in time
+type State struct {
+	// Active is true while the window is open
+	Active bool
+	// Start and End bound the open window. Occurrences of a schedule that overlap are merged into one window.
+	Start time.Time
+	End   time.Time
+	// Next is when a closed window opens again, zero when it won't
+	Next time.Time
+}
+
+// Completed reports whether the window won't open again
+func (s State) Completed() bool {
+	return !s.Active && s.Next.IsZero()
+}
+
+// ParseSchedule parses a cron expression with five fields, or a descriptor like "@weekly",
+// optionally prefixed with a time zone as in "CRON_TZ=Europe/Berlin 0 2 * * 6"
+func ParseSchedule(schedule string) (cron.Schedule, error) {
+	return cron.ParseStandard(schedule)
+}
+
+// Validate checks that the schedule of a window can be evaluated
+func Validate(spec deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec) error {
+	if (spec.Schedule == "") == (spec.StartTime == nil) {
+		return fmt.Errorf("exactly one of schedule and startTime must be set")
+	}
+	if spec.Duration.Duration <= 0 {
+		return fmt.Errorf("duration must be positive, got %s", spec.Duration.Duration)
+	}
+	if spec.Schedule != "" {
+		if _, err := ParseSchedule(spec.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule %q: %v", spec.Schedule, err)
+		}
+	}
+	if _, err := metav1.LabelSelectorAsSelector(&spec.ClusterDeploymentSelector); err != nil {
+		return fmt.Errorf("invalid clusterDeploymentSelector: %v", err)
+	}
+	return nil
+}
+
+// Evaluate returns where a window stands at now. It only depends on the spec and the time,
+// so an operator restarting in the middle of a window picks it up where it was.
+func Evaluate(spec deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec, now time.Time) (State, error) {
+	if err := Validate(spec); err != nil {
+		return State{}, err
+	}
+	duration := spec.Duration.Duration
+
+	if spec.StartTime != nil {
+		start := spec.StartTime.Time
+		end := start.Add(duration)
+		switch {
+		case now.Before(start):
+			return State{Next: start}, nil
+		case now.Before(end):
+			return State{Active: true, Start: start, End: end}, nil
+		default:
+			return State{Start: start, End: end}, nil
+		}
+	}
+
+	schedule, _ := ParseSchedule(spec.Schedule)
+	// the earliest occurrence still open at now, if any
+	start := schedule.Next(now.Add(-duration))
+	if start.IsZero() || start.After(now) {
+		return State{Next: start}, nil
+	}
+	end := start.Add(duration)
+	// later occurrences opening before the window closes keep it open
+	for i, next := 0, schedule.Next(start); i < maxOccurrences && !next.IsZero() && next.Before(end); i, next = i+1, schedule.Next(next) {
+		end = next.Add(duration)
+	}
+	return State{Active: true, Start: start, End: end}, nil
+}
+
+// Key identifies a window in PausedAnnotation
+func Key(window *deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow) string {
+	return window.Namespace + "/" + window.Name
+}
+
+// ActiveWindows returns the sorted keys of the windows open at now whose selector matches clusterLabels.
+// Windows that can't be evaluated or are being deleted are left out.
+func ActiveWindows(windows []deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow, clusterLabels map[string]string, now time.Time) []string {
+	keys := []string{}
+	for i := range windows {
+		window := &windows[i]
+		if window.DeletionTimestamp != nil {
+			continue
+		}
+		state, err := Evaluate(window.Spec, now)
+		if err != nil || !state.Active {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&window.Spec.ClusterDeploymentSelector)
+		if err != nil || !selector.Matches(labels.Set(clusterLabels)) {
+			continue
+		}
+		keys = append(keys, Key(window))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Holders returns the sorted keys of the windows PausedAnnotation records in annotations
+func Holders(annotations map[string]string) []string {
+	keys := []string{}
+	for _, key := range strings.Split(annotations[PausedAnnotation], ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetHolders records keys in PausedAnnotation of annotations, removing it when keys is empty.
+// It returns the annotations, allocated when nil.
+func SetHolders(annotations map[string]string, keys []string) map[string]string {
+	if len(keys) == 0 {
+		delete(annotations, PausedAnnotation)
+		return annotations
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	annotations[PausedAnnotation] = strings.Join(sorted, ",")
+	return annotations
+}
+
+// IsHolder reports whether the window with key holds the snitch of the secret with annotations paused
+func IsHolder(annotations map[string]string, key string) bool {
+	for _, holder := range Holders(annotations) {
+		if holder == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testWindow(name string, spec deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec) deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow {
+	return deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "deadmanssnitch-operator"},
+		Spec:       spec,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// Saturdays from 02:00 to 04:00 UTC
+	weekly := deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+		Schedule: "0 2 * * 6",
+		Duration: metav1.Duration{Duration: 2 * time.Hour},
+	}
+	startTime := metav1.NewTime(at("2021-06-05T02:00:00Z"))
+	oneOff := deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+		StartTime: &startTime,
+		Duration:  metav1.Duration{Duration: 30 * time.Minute},
+	}
+	// every 10 minutes for 15 minutes, so the occurrences overlap
+	overlapping := deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+		Schedule: "*/10 * * * *",
+		Duration: metav1.Duration{Duration: 15 * time.Minute},
+	}
+
+	tests := []struct {
+		name          string
+		spec          deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec
+		now           string
+		expectedState State
+		completed     bool
+	}{
+		{
+			name:          "recurring before opening",
+			spec:          weekly,
+			now:           "2021-06-05T01:59:00Z",
+			expectedState: State{Next: at("2021-06-05T02:00:00Z")},
+		},
+		{
+			name:          "recurring at opening",
+			spec:          weekly,
+			now:           "2021-06-05T02:00:00Z",
+			expectedState: State{Active: true, Start: at("2021-06-05T02:00:00Z"), End: at("2021-06-05T04:00:00Z")},
+		},
+		{
+			name:          "recurring while open",
+			spec:          weekly,
+			now:           "2021-06-05T03:59:59Z",
+			expectedState: State{Active: true, Start: at("2021-06-05T02:00:00Z"), End: at("2021-06-05T04:00:00Z")},
+		},
+		{
+			name:          "recurring at closing",
+			spec:          weekly,
+			now:           "2021-06-05T04:00:00Z",
+			expectedState: State{Next: at("2021-06-12T02:00:00Z")},
+		},
+		{
+			name: "recurring in a time zone",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+				Schedule: "CRON_TZ=Asia/Tokyo 0 2 * * 6",
+				Duration: metav1.Duration{Duration: time.Hour},
+			},
+			now:           "2021-06-04T17:30:00Z",
+			expectedState: State{Active: true, Start: at("2021-06-04T17:00:00Z"), End: at("2021-06-04T18:00:00Z")},
+		},
+		{
+			name:          "overlapping occurrences",
+			spec:          overlapping,
+			now:           "2021-06-05T02:12:00Z",
+			expectedState: State{Active: true, Start: at("2021-06-05T02:00:00Z"), End: at("2021-06-05T02:00:00Z").Add(maxOccurrences*10*time.Minute + 15*time.Minute)},
+		},
+		{
+			name:          "one-off before opening",
+			spec:          oneOff,
+			now:           "2021-06-01T00:00:00Z",
+			expectedState: State{Next: at("2021-06-05T02:00:00Z")},
+		},
+		{
+			name:          "one-off while open",
+			spec:          oneOff,
+			now:           "2021-06-05T02:10:00Z",
+			expectedState: State{Active: true, Start: at("2021-06-05T02:00:00Z"), End: at("2021-06-05T02:30:00Z")},
+		},
+		{
+			name:          "one-off after closing",
+			spec:          oneOff,
+			now:           "2021-06-05T02:30:00Z",
+			expectedState: State{Start: at("2021-06-05T02:00:00Z"), End: at("2021-06-05T02:30:00Z")},
+			completed:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state, err := Evaluate(test.spec, at(test.now))
+			assert.NoError(t, err)
+			assert.True(t, test.expectedState.Start.Equal(state.Start), "start %s", state.Start)
+			assert.True(t, test.expectedState.End.Equal(state.End), "end %s", state.End)
+			assert.True(t, test.expectedState.Next.Equal(state.Next), "next %s", state.Next)
+			assert.Equal(t, test.expectedState.Active, state.Active)
+			assert.Equal(t, test.completed, state.Completed())
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	startTime := metav1.NewTime(at("2021-06-05T02:00:00Z"))
+	tests := []struct {
+		name          string
+		spec          deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec
+		expectedError string
+	}{
+		{
+			name:          "no schedule",
+			spec:          deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{Duration: metav1.Duration{Duration: time.Hour}},
+			expectedError: "exactly one of schedule and startTime must be set",
+		},
+		{
+			name: "both schedules",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+				Schedule:  "@daily",
+				StartTime: &startTime,
+				Duration:  metav1.Duration{Duration: time.Hour},
+			},
+			expectedError: "exactly one of schedule and startTime must be set",
+		},
+		{
+			name:          "no duration",
+			spec:          deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{Schedule: "@daily"},
+			expectedError: "duration must be positive, got 0s",
+		},
+		{
+			name: "invalid cron expression",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+				Schedule: "0 25 * * *",
+				Duration: metav1.Duration{Duration: time.Hour},
+			},
+			expectedError: `invalid schedule "0 25 * * *": end of range (25) above maximum (23): 25`,
+		},
+		{
+			name: "valid",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+				Schedule: "@weekly",
+				Duration: metav1.Duration{Duration: time.Hour},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate(test.spec)
+			if test.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.expectedError)
+		})
+	}
+}
+
+func TestActiveWindows(t *testing.T) {
+	now := at("2021-06-05T03:00:00Z")
+	selector := metav1.LabelSelector{MatchLabels: map[string]string{"fleet": "canary"}}
+	open := deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+		Schedule:                  "0 2 * * 6",
+		Duration:                  metav1.Duration{Duration: 2 * time.Hour},
+		ClusterDeploymentSelector: selector,
+	}
+	closed := deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{
+		Schedule:                  "0 2 * * 0",
+		Duration:                  metav1.Duration{Duration: 2 * time.Hour},
+		ClusterDeploymentSelector: selector,
+	}
+	otherFleet := open
+	otherFleet.ClusterDeploymentSelector = metav1.LabelSelector{MatchLabels: map[string]string{"fleet": "stable"}}
+	deleted := testWindow("deleted", open)
+	deleted.DeletionTimestamp = &metav1.Time{Time: now}
+
+	windows := []deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindow{
+		testWindow("weekly", open),
+		testWindow("closed", closed),
+		testWindow("other-fleet", otherFleet),
+		testWindow("invalid", deadmanssnitchv1alpha1.DeadmansSnitchMaintenanceWindowSpec{ClusterDeploymentSelector: selector}),
+		deleted,
+		testWindow("another-weekly", open),
+	}
+
+	assert.Equal(t, []string{"deadmanssnitch-operator/another-weekly", "deadmanssnitch-operator/weekly"},
+		ActiveWindows(windows, map[string]string{"fleet": "canary"}, now))
+	assert.Equal(t, []string{}, ActiveWindows(windows, map[string]string{"fleet": "canary"}, now.Add(time.Hour)))
+}
+
+func TestHolders(t *testing.T) {
+	annotations := SetHolders(nil, []string{"ns/b", "ns/a"})
+	assert.Equal(t, "ns/a,ns/b", annotations[PausedAnnotation])
+	assert.Equal(t, []string{"ns/a", "ns/b"}, Holders(annotations))
+	assert.True(t, IsHolder(annotations, "ns/b"))
+	assert.False(t, IsHolder(annotations, "ns/c"))
+
+	annotations = SetHolders(annotations, []string{})
+	_, ok := annotations[PausedAnnotation]
+	assert.False(t, ok)
+	assert.Equal(t, []string{}, Holders(annotations))
+}
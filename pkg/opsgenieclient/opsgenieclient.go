@@ -0,0 +1,265 @@
+package opsgenieclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+const (
+	// providerName names Opsgenie in the errors of the client
+	providerName = "opsgenie"
+
+	// DefaultBaseURL is the Opsgenie API in the US region, "https://api.eu.opsgenie.com" serves the EU region
+	DefaultBaseURL = "https://api.opsgenie.com"
+
+	// AuthorizationType is the scheme of the Authorization header Opsgenie expects, followed by the API key
+	AuthorizationType = "GenieKey"
+
+	heartbeatsPath = "v2/heartbeats"
+)
+
+// Client is a wrapper interface for the opsgenieClient to allow for easier testing
+type Client interface {
+	ListAll() ([]Heartbeat, error)
+	Get(name string) (Heartbeat, error)
+	Create(newHeartbeat Heartbeat) (Heartbeat, error)
+	Update(updateHeartbeat Heartbeat) (Heartbeat, error)
+	Delete(name string) error
+	Enable(name string) error
+	Disable(name string) error
+	Ping(name string) error
+	PingURL(name string) string
+}
+
+// Heartbeat Struct
+// Fields are omitted when empty so a Heartbeat only holding the changed fields
+// can be sent to Update without clearing the others.
+type Heartbeat struct {
+	Name          string   `json:"name,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Interval      int      `json:"interval,omitempty"`
+	IntervalUnit  string   `json:"intervalUnit,omitempty"`
+	Enabled       *bool    `json:"enabled,omitempty"`
+	Expired       bool     `json:"expired,omitempty"`
+	AlertMessage  string   `json:"alertMessage,omitempty"`
+	AlertTags     []string `json:"alertTags,omitempty"`
+	AlertPriority string   `json:"alertPriority,omitempty"`
+}
+
+type heartbeatResponse struct {
+	Data Heartbeat `json:"data"`
+}
+
+type heartbeatListResponse struct {
+	Data struct {
+		Heartbeats []Heartbeat `json:"heartbeats"`
+	} `json:"data"`
+}
+
+// opsgenieClient wraps http client
+type opsgenieClient struct {
+	apiKey           string
+	BaseURL          *url.URL
+	httpClient       *http.Client
+	metricsCollector *localmetrics.MetricsCollector
+}
+
+// NewClient creates an API client for the Opsgenie API at baseURL,
+// or for the US region when baseURL is empty
+func NewClient(apiKey string, baseURL string, httpClient *http.Client, collector *localmetrics.MetricsCollector) (Client, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing opsgenie base URL: %v", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("Error parsing opsgenie base URL: %q is not an absolute URL", baseURL)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	return &opsgenieClient{
+		apiKey:           apiKey,
+		BaseURL:          u,
+		httpClient:       httpClient,
+		metricsCollector: collector,
+	}, nil
+}
+
+// heartbeatURL returns the URL of a heartbeat, or of an action on it
+func (c *opsgenieClient) heartbeatURL(name string, action string) string {
+	p := heartbeatsPath
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	if action != "" {
+		p += "/" + action
+	}
+	rel, _ := url.Parse(p)
+	return c.BaseURL.ResolveReference(rel).String()
+}
+
+func (c *opsgenieClient) newRequest(method, u string, body interface{}) (*http.Request, error) {
+	var buf io.ReadWriter
+
+	if body != nil {
+		buf = new(bytes.Buffer)
+		err := json.NewEncoder(buf).Encode(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, u, buf)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "golang httpClient")
+	req.Header.Set("Authorization", AuthorizationType+" "+c.apiKey)
+	return req, nil
+}
+
+// do sends the request and turns any response outside of 2xx into an *apierror.Error
+func (c *opsgenieClient) do(req *http.Request, operation string) (*http.Response, error) {
+	start := time.Now()
+	defer func() {
+		c.metricsCollector.ObserveSnitchCallDuration(time.Since(start).Seconds(), operation)
+	}()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.metricsCollector.ObserveSnitchCallError()
+		return nil, fmt.Errorf("Error calling the API endpoint: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		c.metricsCollector.ObserveSnitchCallError()
+		return nil, apierror.New(providerName, operation, resp)
+	}
+
+	return resp, nil
+}
+
+// doHeartbeat sends the request and decodes the heartbeat in the response
+func (c *opsgenieClient) doHeartbeat(req *http.Request, operation string) (Heartbeat, error) {
+	resp, err := c.do(req, operation)
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	defer resp.Body.Close()
+
+	var hr heartbeatResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&hr)
+	if decodeErr != nil {
+		err = fmt.Errorf("Error decoding heartbeat: %v", decodeErr)
+	}
+	return hr.Data, err
+}
+
+// doAction sends a request that carries no heartbeat in its response
+func (c *opsgenieClient) doAction(method string, name string, action string, operation string) error {
+	req, err := c.newRequest(method, c.heartbeatURL(name, action), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, operation)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ListAll heartbeats
+func (c *opsgenieClient) ListAll() ([]Heartbeat, error) {
+	req, err := c.newRequest("GET", c.heartbeatURL("", ""), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, "list_all")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var hlr heartbeatListResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&hlr)
+	if decodeErr != nil {
+		err = fmt.Errorf("Error listing all heartbeats: %v", decodeErr)
+	}
+	return hlr.Data.Heartbeats, err
+}
+
+// Get a single heartbeat
+func (c *opsgenieClient) Get(name string) (Heartbeat, error) {
+	req, err := c.newRequest("GET", c.heartbeatURL(name, ""), nil)
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	return c.doHeartbeat(req, "describe")
+}
+
+// Create a heartbeat. Opsgenie only returns the name and state of the new heartbeat.
+func (c *opsgenieClient) Create(newHeartbeat Heartbeat) (Heartbeat, error) {
+	req, err := c.newRequest("POST", c.heartbeatURL("", ""), newHeartbeat)
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	return c.doHeartbeat(req, "create")
+}
+
+// Update the heartbeat. The name identifies the heartbeat and can't be changed.
+func (c *opsgenieClient) Update(updateHeartbeat Heartbeat) (Heartbeat, error) {
+	name := updateHeartbeat.Name
+	updateHeartbeat.Name = ""
+	req, err := c.newRequest("PATCH", c.heartbeatURL(name, ""), updateHeartbeat)
+	if err != nil {
+		return Heartbeat{}, err
+	}
+	return c.doHeartbeat(req, "update")
+}
+
+// Delete a heartbeat
+func (c *opsgenieClient) Delete(name string) error {
+	return c.doAction("DELETE", name, "", "delete")
+}
+
+// Enable a heartbeat, so Opsgenie alerts when it expires
+func (c *opsgenieClient) Enable(name string) error {
+	return c.doAction("POST", name, "enable", "unpause")
+}
+
+// Disable a heartbeat, so Opsgenie stops alerting when it expires
+func (c *opsgenieClient) Disable(name string) error {
+	return c.doAction("POST", name, "disable", "pause")
+}
+
+// Ping the heartbeat
+func (c *opsgenieClient) Ping(name string) error {
+	return c.doAction("GET", name, "ping", "check_in")
+}
+
+// PingURL returns the URL a heartbeat is pinged at. Pinging requires the
+// Authorization header "GenieKey <api key>".
+func (c *opsgenieClient) PingURL(name string) string {
+	return c.heartbeatURL(name, "ping")
+}
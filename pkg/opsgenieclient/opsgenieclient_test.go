@@ -0,0 +1,77 @@
+package opsgenieclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/deadmanssnitch-operator/pkg/apierror"
+	"github.com/openshift/deadmanssnitch-operator/pkg/localmetrics"
+)
+
+func TestClient(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		if r.Header.Get("Authorization") != "GenieKey key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /v2/heartbeats":
+			_, _ = w.Write([]byte(`{"data":{"heartbeats":[{"name":"a.b-c","interval":15,"intervalUnit":"minutes","enabled":true,"expired":false}]}}`))
+		case "POST /v2/heartbeats":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data":{"name":"a.b-c","enabled":true,"expired":false}}`))
+		case "PATCH /v2/heartbeats/a.b-c":
+			_, _ = w.Write([]byte(`{"data":{"name":"a.b-c","enabled":true,"expired":false}}`))
+		case "POST /v2/heartbeats/a%20b/disable", "GET /v2/heartbeats/a.b-c/ping", "DELETE /v2/heartbeats/a.b-c":
+			_, _ = w.Write([]byte(`{"result":"Request will be processed"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient("key", server.URL, nil, localmetrics.NewMetricsCollector())
+	assert.NoError(t, err)
+
+	heartbeats, err := c.ListAll()
+	assert.NoError(t, err)
+	if assert.Len(t, heartbeats, 1) {
+		assert.Equal(t, "a.b-c", heartbeats[0].Name)
+		assert.Equal(t, 15, heartbeats[0].Interval)
+	}
+
+	created, err := c.Create(Heartbeat{Name: "a.b-c", Interval: 15, IntervalUnit: "minutes"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a.b-c", created.Name)
+
+	_, err = c.Update(Heartbeat{Name: "a.b-c", Interval: 30})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Disable("a b"))
+	assert.NoError(t, c.Ping("a.b-c"))
+	assert.NoError(t, c.Delete("a.b-c"))
+	assert.True(t, apierror.IsNotFound(c.Enable("missing")))
+	assert.Equal(t, server.URL+"/v2/heartbeats/a.b-c/ping", c.PingURL("a.b-c"))
+
+	unauthorized, err := NewClient("wrong", server.URL, nil, localmetrics.NewMetricsCollector())
+	assert.NoError(t, err)
+	_, err = unauthorized.ListAll()
+	assert.EqualError(t, err, "Error calling the API endpoint: list_all returned status code 401: please check the opsgenie credentials")
+	assert.True(t, apierror.IsUnauthorized(err))
+
+	assert.Equal(t, []string{
+		"GET /v2/heartbeats",
+		"POST /v2/heartbeats",
+		"PATCH /v2/heartbeats/a.b-c",
+		"POST /v2/heartbeats/a%20b/disable",
+		"GET /v2/heartbeats/a.b-c/ping",
+		"DELETE /v2/heartbeats/a.b-c",
+		"POST /v2/heartbeats/missing/enable",
+		"GET /v2/heartbeats",
+	}, requests)
+}
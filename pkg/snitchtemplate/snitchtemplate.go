@@ -0,0 +1,147 @@
+// Package snitchtemplate renders the snitch name, notes and tags of a DeadmansSnitchIntegration
+// from Go templates reading the ClusterDeployment the snitch is created for.
+package snitchtemplate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Data is what the templates can read about a ClusterDeployment, i.e. "{{.ClusterName}}.{{.BaseDomain}}"
+// or "{{index .Labels \"api.openshift.com/environment\"}}"
+type Data struct {
+	// Name and Namespace of the ClusterDeployment
+	Name      string
+	Namespace string
+	// ClusterName and BaseDomain from the spec of the ClusterDeployment
+	ClusterName string
+	BaseDomain  string
+	// ClusterID is the external ID of the cluster, InternalClusterID the ID in the namespace of the ClusterDeployment
+	ClusterID         string
+	InternalClusterID string
+	InfraID           string
+	// Region and Platform the cluster runs in, i.e. "us-east-1" and "aws"
+	Region   string
+	Platform string
+	// SnitchNamePostFix of the DeadmansSnitchIntegration
+	SnitchNamePostFix string
+	Labels            map[string]string
+	Annotations       map[string]string
+}
+
+// sampleData is rendered by Validate to find references to fields Data doesn't have
+var sampleData = Data{
+	Name:              "mycluster",
+	Namespace:         "uhc-production-0123456789abcdef",
+	ClusterName:       "mycluster",
+	BaseDomain:        "example.com",
+	ClusterID:         "00000000-0000-0000-0000-000000000000",
+	InternalClusterID: "0123456789abcdef",
+	InfraID:           "mycluster-abcde",
+	Region:            "us-east-1",
+	Platform:          "aws",
+	SnitchNamePostFix: "osd",
+	Labels:            map[string]string{},
+	Annotations:       map[string]string{},
+}
+
+// funcs take the piped value last, i.e. {{.Namespace | trimPrefix "uhc-"}}
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trimPrefix": func(prefix, s string) string {
+		return strings.TrimPrefix(s, prefix)
+	},
+	"trimSuffix": func(suffix, s string) string {
+		return strings.TrimSuffix(s, suffix)
+	},
+	"replace": func(old, replacement, s string) string {
+		return strings.Replace(s, old, replacement, -1)
+	},
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+}
+
+func parse(name, text string) (*template.Template, error) {
+	// missing labels and annotations render empty instead of "<no value>"
+	return template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+}
+
+// Render executes the template text against data
+func Render(text string, data Data) (string, error) {
+	tmpl, err := parse("snitch", text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// renderTag renders a tag template against data. Tags containing whitespace are rejected, Healthchecks keeps the tags
+// of a check in a single space separated field and would split them into several tags.
+func renderTag(tag string, data Data) (string, error) {
+	r, err := Render(tag, data)
+	if err != nil {
+		return "", err
+	}
+	if strings.IndexFunc(r, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("renders %q, tags can't contain whitespace", r)
+	}
+	return r, nil
+}
+
+// RenderTags renders each tag template against data, dropping the tags that render empty
+func RenderTags(tags []string, data Data) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+	rendered := []string{}
+	for _, tag := range tags {
+		r, err := renderTag(tag, data)
+		if err != nil {
+			return nil, fmt.Errorf("tag %q: %w", tag, err)
+		}
+		if r != "" {
+			rendered = append(rendered, r)
+		}
+	}
+	return rendered, nil
+}
+
+// Validate parses the snitch name, notes and tag templates of spec and renders them against sample data,
+// so templates referencing unknown fields or calling functions wrongly are rejected before any snitch is created
+func Validate(spec deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.SnitchNameTemplate != "" {
+		name, err := Render(spec.SnitchNameTemplate, sampleData)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("snitchNameTemplate"), spec.SnitchNameTemplate, err.Error()))
+		} else if name == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("snitchNameTemplate"), spec.SnitchNameTemplate, "renders an empty snitch name"))
+		}
+	}
+	if spec.NotesTemplate != "" {
+		if _, err := Render(spec.NotesTemplate, sampleData); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("notesTemplate"), spec.NotesTemplate, err.Error()))
+		}
+	}
+	for i, tag := range spec.Tags {
+		if _, err := renderTag(tag, sampleData); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("tags").Index(i), tag, err.Error()))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,156 @@
+package snitchtemplate
+
+import (
+	"testing"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func testData() Data {
+	return Data{
+		Name:              "test-cd",
+		Namespace:         "uhc-production-abcdef",
+		ClusterName:       "test-cluster",
+		BaseDomain:        "base.domain",
+		ClusterID:         "fake-cluster-id",
+		InternalClusterID: "abcdef",
+		Region:            "eu-west-1",
+		Platform:          "aws",
+		SnitchNamePostFix: "osd",
+		Labels:            map[string]string{"api.openshift.com/environment": "production"},
+		Annotations:       map[string]string{"team": "sre"},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "static text",
+			template: "my-snitch",
+			expected: "my-snitch",
+		},
+		{
+			name:     "default snitch name",
+			template: "{{.ClusterName}}.{{.BaseDomain}}-{{.SnitchNamePostFix}}",
+			expected: "test-cluster.base.domain-osd",
+		},
+		{
+			name:     "label and annotation",
+			template: `{{index .Labels "api.openshift.com/environment"}}/{{index .Annotations "team"}}`,
+			expected: "production/sre",
+		},
+		{
+			name:     "missing label",
+			template: `{{index .Labels "missing" | default "none"}}`,
+			expected: "none",
+		},
+		{
+			name:     "functions",
+			template: `{{.Region | replace "-" "_" | upper}} {{.Namespace | trimPrefix "uhc-"}}`,
+			expected: "EU_WEST_1 production-abcdef",
+		},
+		{
+			name:          "unknown field",
+			template:      "{{.Cluster}}",
+			expectedError: true,
+		},
+		{
+			name:          "unterminated action",
+			template:      "{{.ClusterName",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered, err := Render(test.template, testData())
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, rendered)
+		})
+	}
+}
+
+func TestRenderTags(t *testing.T) {
+	tags, err := RenderTags([]string{"static", "{{.Platform}}", `{{index .Labels "missing"}}`}, testData())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"static", "aws"}, tags)
+
+	tags, err = RenderTags(nil, testData())
+	assert.NoError(t, err)
+	assert.Nil(t, tags)
+
+	_, err = RenderTags([]string{"{{.Unknown}}"}, testData())
+	assert.Error(t, err)
+
+	_, err = RenderTags([]string{"static", "{{.Platform}} {{.Region}}"}, testData())
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		spec           deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec
+		expectedFields []string
+	}{
+		{
+			name: "no templates",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec{Tags: []string{"production"}},
+		},
+		{
+			name: "valid templates",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec{
+				SnitchNameTemplate: "{{.ClusterName}}-{{.Region}}",
+				NotesTemplate:      "cluster_id: {{.ClusterID}}",
+				Tags:               []string{"{{.Platform}}"},
+			},
+		},
+		{
+			name: "invalid templates",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec{
+				SnitchNameTemplate: "{{.ClusterName",
+				NotesTemplate:      "{{.Runbook}}",
+				Tags:               []string{"production", "{{lower}}"},
+			},
+			expectedFields: []string{"spec.snitchNameTemplate", "spec.notesTemplate", "spec.tags[1]"},
+		},
+		{
+			name: "tags with whitespace",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec{
+				Tags: []string{"production", "on call", "{{.Platform}}\t{{.Region}}", " padded "},
+			},
+			expectedFields: []string{"spec.tags[1]", "spec.tags[2]"},
+		},
+		{
+			name: "empty snitch name",
+			spec: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec{
+				SnitchNameTemplate: `{{index .Labels "missing"}}`,
+			},
+			expectedFields: []string{"spec.snitchNameTemplate"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := Validate(test.spec, field.NewPath("spec"))
+			fields := []string{}
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if test.expectedFields == nil {
+				test.expectedFields = []string{}
+			}
+			assert.Equal(t, test.expectedFields, fields)
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/openshift/deadmanssnitch-operator/pkg/webhook/deadmanssnitchintegration"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, deadmanssnitchintegration.Add)
+}
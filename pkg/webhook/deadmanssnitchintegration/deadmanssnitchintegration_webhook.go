@@ -0,0 +1,85 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"net/http"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ValidatePath is the path the DeadmansSnitchIntegration validating webhook is served at,
+// it has to match the ValidatingWebhookConfiguration in deploy/webhook.yaml
+const ValidatePath = "/validate-deadmanssnitch-managed-openshift-io-v1alpha1-deadmanssnitchintegration"
+
+var log = logf.Log.WithName("webhook_deadmanssnitchintegration")
+
+// Add registers the DeadmansSnitchIntegration validating webhook with the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register(ValidatePath, &webhook.Admission{Handler: &DeadmansSnitchIntegrationValidator{client: mgr.GetClient()}})
+	return nil
+}
+
+// blank assignments to verify that DeadmansSnitchIntegrationValidator implements admission.Handler and gets a decoder injected
+var _ admission.Handler = &DeadmansSnitchIntegrationValidator{}
+var _ admission.DecoderInjector = &DeadmansSnitchIntegrationValidator{}
+
+// DeadmansSnitchIntegrationValidator rejects DeadmansSnitchIntegrations the operator can't reconcile when they are
+// created or updated, instead of failing on them at reconcile time
+type DeadmansSnitchIntegrationValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectDecoder injects the decoder of the webhook server
+func (v *DeadmansSnitchIntegrationValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle admits the DeadmansSnitchIntegration of the request if it passes validation
+func (v *DeadmansSnitchIntegrationValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	dmsi := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+	err := v.decoder.Decode(req, dmsi)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1beta1.Update {
+		// removing the finalizer of a dmsi being deleted, or changing its metadata or status, must not be held up
+		// by a spec admitted before a rule was added or before another dmsi came to overlap it
+		if dmsi.DeletionTimestamp != nil {
+			return admission.Allowed("")
+		}
+		oldDMSI := &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{}
+		err = v.decoder.DecodeRaw(req.OldObject, oldDMSI)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if equality.Semantic.DeepEqual(dmsi.Spec, oldDMSI.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	// the other DeadmansSnitchIntegrations must not write the same secrets and syncsets
+	dmsiList := &deadmanssnitchv1alpha1.DeadmansSnitchIntegrationList{}
+	err = v.client.List(ctx, dmsiList)
+	if err != nil {
+		log.Error(err, "Unable to list DeadmansSnitchIntegrations")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	allErrs := ValidateDeadmansSnitchIntegration(dmsi, dmsiList.Items)
+	if len(allErrs) > 0 {
+		log.Info("Rejecting DeadmansSnitchIntegration", "Namespace", req.Namespace, "Name", req.Name, "Errors", allErrs.ToAggregate().Error())
+		return admission.Denied(allErrs.ToAggregate().Error())
+	}
+	return admission.Allowed("")
+}
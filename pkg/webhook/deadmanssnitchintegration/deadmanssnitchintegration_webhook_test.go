@@ -0,0 +1,113 @@
+package deadmanssnitchintegration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	dmsapis "github.com/openshift/deadmanssnitch-operator/pkg/apis"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestHandle(t *testing.T) {
+	err := dmsapis.AddToScheme(scheme.Scheme)
+	assert.NoError(t, err)
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.NoError(t, err)
+	existing := testDeadMansSnitchIntegration()
+	existing.Name = "existing-dmsi"
+	existing.Spec.SnitchNamePostFix = "existing"
+	validator := &DeadmansSnitchIntegrationValidator{client: fakekubeclient.NewFakeClient(existing)}
+	err = validator.InjectDecoder(decoder)
+	assert.NoError(t, err)
+
+	valid := testDeadMansSnitchIntegration()
+	invalid := testDeadMansSnitchIntegration()
+	invalid.Spec.SnitchNameTemplate = "{{.ClusterName"
+	overlapping := testDeadMansSnitchIntegration()
+	overlapping.Spec.SnitchNamePostFix = "existing"
+	relabelled := overlapping.DeepCopy()
+	relabelled.Labels = map[string]string{"team": "sre"}
+	retagged := overlapping.DeepCopy()
+	retagged.Spec.Tags = []string{"other"}
+	deleted := invalid.DeepCopy()
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+
+	tests := []struct {
+		name            string
+		object          runtime.Object
+		raw             []byte
+		oldObject       runtime.Object
+		expectedAllowed bool
+	}{
+		{
+			name:            "valid DeadmansSnitchIntegration",
+			object:          valid,
+			expectedAllowed: true,
+		},
+		{
+			name:   "invalid DeadmansSnitchIntegration",
+			object: invalid,
+		},
+		{
+			name:   "DeadmansSnitchIntegration overlapping an existing one",
+			object: overlapping,
+		},
+		{
+			name:            "update of the existing DeadmansSnitchIntegration",
+			object:          existing,
+			expectedAllowed: true,
+		},
+		{
+			name:            "update of an admitted DeadmansSnitchIntegration leaving its spec alone",
+			object:          relabelled,
+			oldObject:       overlapping,
+			expectedAllowed: true,
+		},
+		{
+			name:      "update of an admitted DeadmansSnitchIntegration changing its spec",
+			object:    retagged,
+			oldObject: overlapping,
+		},
+		{
+			name:            "update of a DeadmansSnitchIntegration being deleted",
+			object:          deleted,
+			oldObject:       invalid,
+			expectedAllowed: true,
+		},
+		{
+			name: "undecodable object",
+			raw:  []byte("{"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := test.raw
+			if test.object != nil {
+				raw, err = json.Marshal(test.object)
+				assert.NoError(t, err)
+			}
+			request := admission.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Operation: admissionv1beta1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+			if test.oldObject != nil {
+				request.Operation = admissionv1beta1.Update
+				request.OldObject.Raw, err = json.Marshal(test.oldObject)
+				assert.NoError(t, err)
+			}
+			response := validator.Handle(context.TODO(), request)
+			assert.Equal(t, test.expectedAllowed, response.Allowed)
+		})
+	}
+}
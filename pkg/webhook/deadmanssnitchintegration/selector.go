@@ -0,0 +1,88 @@
+package deadmanssnitchintegration
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// labelConstraint is what a label selector requires of a single label
+type labelConstraint struct {
+	// present is set by Exists and In, absent by DoesNotExist
+	present bool
+	absent  bool
+	// values the label may have, nil if In doesn't restrict it
+	values sets.String
+	// excluded values the label must not have, set by NotIn
+	excluded sets.String
+}
+
+// and returns the constraint on a label matching both c and other
+func (c labelConstraint) and(other labelConstraint) labelConstraint {
+	combined := labelConstraint{
+		present:  c.present || other.present,
+		absent:   c.absent || other.absent,
+		excluded: sets.NewString().Union(c.excluded).Union(other.excluded),
+	}
+	switch {
+	case c.values == nil:
+		combined.values = other.values
+	case other.values == nil:
+		combined.values = c.values
+	default:
+		combined.values = c.values.Intersection(other.values)
+	}
+	return combined
+}
+
+// unsatisfiable is true when no value, nor a missing label, satisfies c
+func (c labelConstraint) unsatisfiable() bool {
+	if c.present && c.absent {
+		return true
+	}
+	return c.values != nil && c.values.Difference(c.excluded).Len() == 0
+}
+
+// labelConstraints returns the constraints of selector by label
+func labelConstraints(selector *metav1.LabelSelector) map[string]labelConstraint {
+	constraints := map[string]labelConstraint{}
+	add := func(key string, constraint labelConstraint) {
+		if existing, ok := constraints[key]; ok {
+			constraint = existing.and(constraint)
+		}
+		constraints[key] = constraint
+	}
+
+	for key, value := range selector.MatchLabels {
+		add(key, labelConstraint{present: true, values: sets.NewString(value)})
+	}
+	for _, expression := range selector.MatchExpressions {
+		switch expression.Operator {
+		case metav1.LabelSelectorOpIn:
+			add(expression.Key, labelConstraint{present: true, values: sets.NewString(expression.Values...)})
+		case metav1.LabelSelectorOpNotIn:
+			add(expression.Key, labelConstraint{excluded: sets.NewString(expression.Values...)})
+		case metav1.LabelSelectorOpExists:
+			add(expression.Key, labelConstraint{present: true})
+		case metav1.LabelSelectorOpDoesNotExist:
+			add(expression.Key, labelConstraint{absent: true})
+		}
+	}
+	return constraints
+}
+
+// selectorsDisjoint is true when no set of labels can match both a and b, as they contradict each other on a label.
+// Selectors that can't be told apart this way are treated as overlapping.
+func selectorsDisjoint(a, b *metav1.LabelSelector) bool {
+	constraintsA, constraintsB := labelConstraints(a), labelConstraints(b)
+	for key, constraint := range constraintsA {
+		if constraint.and(constraintsB[key]).unsatisfiable() {
+			return true
+		}
+	}
+	for _, constraint := range constraintsB {
+		if constraint.unsatisfiable() {
+			return true
+		}
+	}
+	return false
+}
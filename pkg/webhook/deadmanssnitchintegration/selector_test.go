@@ -0,0 +1,94 @@
+package deadmanssnitchintegration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectorsDisjoint(t *testing.T) {
+	managed := metav1.LabelSelector{MatchLabels: map[string]string{"api.openshift.com/managed": "true"}}
+
+	tests := []struct {
+		name     string
+		a        metav1.LabelSelector
+		b        metav1.LabelSelector
+		expected bool
+	}{
+		{
+			name: "same selector",
+			a:    managed,
+			b:    managed,
+		},
+		{
+			name: "empty selector",
+			a:    managed,
+			b:    metav1.LabelSelector{},
+		},
+		{
+			name: "different labels",
+			a:    managed,
+			b:    metav1.LabelSelector{MatchLabels: map[string]string{"api.openshift.com/fedramp": "true"}},
+		},
+		{
+			name:     "different values",
+			a:        managed,
+			b:        metav1.LabelSelector{MatchLabels: map[string]string{"api.openshift.com/managed": "false"}},
+			expected: true,
+		},
+		{
+			name: "overlapping In",
+			a: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "api.openshift.com/managed", Operator: metav1.LabelSelectorOpIn, Values: []string{"true", "yes"}},
+			}},
+			b: managed,
+		},
+		{
+			name: "NotIn",
+			a:    managed,
+			b: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "api.openshift.com/managed", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"true"}},
+			}},
+			expected: true,
+		},
+		{
+			name: "DoesNotExist",
+			a:    managed,
+			b: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "api.openshift.com/managed", Operator: metav1.LabelSelectorOpDoesNotExist},
+			}},
+			expected: true,
+		},
+		{
+			name: "Exists and NotIn",
+			a: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "api.openshift.com/managed", Operator: metav1.LabelSelectorOpExists},
+			}},
+			b: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "api.openshift.com/managed", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"true"}},
+			}},
+		},
+		{
+			name: "NotIn on one of several labels",
+			a: metav1.LabelSelector{MatchLabels: map[string]string{
+				"api.openshift.com/managed":     "true",
+				"api.openshift.com/environment": "staging",
+			}},
+			b: metav1.LabelSelector{
+				MatchLabels: map[string]string{"api.openshift.com/managed": "true"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "api.openshift.com/environment", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"staging", "integration"}},
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, selectorsDisjoint(&test.a, &test.b))
+			assert.Equal(t, test.expected, selectorsDisjoint(&test.b, &test.a))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package deadmanssnitchintegration
+
+import (
+	"fmt"
+	"time"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/openshift/deadmanssnitch-operator/pkg/snitchtemplate"
+	"github.com/openshift/deadmanssnitch-operator/pkg/utils"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// placeholderClusterName stands in for the cluster name when checking the names of the secrets and syncsets
+	// a snitchNamePostFix leads to
+	placeholderClusterName = "cluster"
+	// minResyncPeriod keeps a resync of every clusterdeployment from hitting the heartbeat provider more than once a minute
+	minResyncPeriod = time.Minute
+)
+
+var (
+	supportedIntervals          = []string{"15_minute", "30_minute", "hourly", "daily", "weekly", "monthly"}
+	supportedAlertTypes         = []string{"basic", "smart"}
+	supportedProviders          = []string{deadmanssnitchv1alpha1.ProviderDeadMansSnitch, deadmanssnitchv1alpha1.ProviderHealthchecks, deadmanssnitchv1alpha1.ProviderOpsgenie}
+	supportedHibernationPolicys = []string{deadmanssnitchv1alpha1.HibernationPolicyDelete, deadmanssnitchv1alpha1.HibernationPolicyPause}
+	supportedDeletionPolicies   = []string{deadmanssnitchv1alpha1.DeletionPolicyDelete, deadmanssnitchv1alpha1.DeletionPolicyOrphan, deadmanssnitchv1alpha1.DeletionPolicyPause}
+)
+
+// ValidateDeadmansSnitchIntegration returns the reasons the operator can't reconcile dmsi.
+// others are the existing DeadmansSnitchIntegrations, dmsi must not write the same secrets and syncsets as any of them.
+func ValidateDeadmansSnitchIntegration(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration, others []deadmanssnitchv1alpha1.DeadmansSnitchIntegration) field.ErrorList {
+	specPath := field.NewPath("spec")
+	allErrs := field.ErrorList{}
+
+	selectorPath := specPath.Child("clusterDeploymentSelector")
+	if _, err := metav1.LabelSelectorAsSelector(&dmsi.Spec.ClusterDeploymentSelector); err != nil {
+		allErrs = append(allErrs, field.Invalid(selectorPath, dmsi.Spec.ClusterDeploymentSelector, err.Error()))
+	}
+	allErrs = append(allErrs, validateSecretReference(dmsi.Spec.DmsAPIKeySecretRef.Name, dmsi.Spec.DmsAPIKeySecretRef.Namespace, specPath.Child("dmsAPIKeySecretRef"))...)
+	allErrs = append(allErrs, validateSecretReference(dmsi.Spec.TargetSecretRef.Name, dmsi.Spec.TargetSecretRef.Namespace, specPath.Child("targetSecretRef"))...)
+
+	postFixPath := specPath.Child("snitchNamePostFix")
+	secretName := utils.SecretName(placeholderClusterName, dmsi.Spec.SnitchNamePostFix)
+	for _, msg := range apivalidation.NameIsDNSSubdomain(secretName, false) {
+		allErrs = append(allErrs, field.Invalid(postFixPath, dmsi.Spec.SnitchNamePostFix,
+			fmt.Sprintf("leads to the invalid secret and syncset name %q: %s", secretName, msg)))
+	}
+
+	allErrs = append(allErrs, validateEnum(dmsi.Spec.Interval, supportedIntervals, specPath.Child("interval"))...)
+	allErrs = append(allErrs, validateEnum(dmsi.Spec.AlertType, supportedAlertTypes, specPath.Child("alertType"))...)
+	allErrs = append(allErrs, validateEnum(dmsi.Spec.Provider, supportedProviders, specPath.Child("provider"))...)
+	allErrs = append(allErrs, validateEnum(dmsi.Spec.HibernationPolicy, supportedHibernationPolicys, specPath.Child("hibernationPolicy"))...)
+	allErrs = append(allErrs, validateEnum(dmsi.Spec.DeletionPolicy, supportedDeletionPolicies, specPath.Child("deletionPolicy"))...)
+
+	if dmsi.Spec.ResyncPeriod != nil && dmsi.Spec.ResyncPeriod.Duration < minResyncPeriod {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("resyncPeriod"), dmsi.Spec.ResyncPeriod.Duration.String(),
+			fmt.Sprintf("must be at least %s", minResyncPeriod)))
+	}
+
+	allErrs = append(allErrs, snitchtemplate.Validate(dmsi.Spec, specPath)...)
+
+	for _, other := range others {
+		if other.Namespace == dmsi.Namespace && other.Name == dmsi.Name {
+			continue
+		}
+		// a DeadmansSnitchIntegration being deleted only tears down its resources, so it can be replaced
+		if other.DeletionTimestamp != nil {
+			continue
+		}
+		if other.Spec.SnitchNamePostFix != dmsi.Spec.SnitchNamePostFix {
+			continue
+		}
+		if selectorsDisjoint(&dmsi.Spec.ClusterDeploymentSelector, &other.Spec.ClusterDeploymentSelector) {
+			continue
+		}
+		allErrs = append(allErrs, field.Invalid(selectorPath, dmsi.Spec.ClusterDeploymentSelector,
+			fmt.Sprintf("may select the same clusterdeployments as DeadmansSnitchIntegration %s/%s with the same snitchNamePostFix %q, both would write the secret and syncset %s",
+				other.Namespace, other.Name, dmsi.Spec.SnitchNamePostFix, utils.SecretName("<clusterName>", dmsi.Spec.SnitchNamePostFix))))
+	}
+
+	return allErrs
+}
+
+// validateSecretReference requires the name and namespace of a secret reference to be set and valid
+func validateSecretReference(name, namespace string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	} else {
+		for _, msg := range apivalidation.NameIsDNSSubdomain(name, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), name, msg))
+		}
+	}
+	if namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("namespace"), ""))
+	} else {
+		for _, msg := range apivalidation.ValidateNamespaceName(namespace, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("namespace"), namespace, msg))
+		}
+	}
+	return allErrs
+}
+
+// validateEnum requires an optional field to be empty or one of the supported values
+func validateEnum(value string, supported []string, fldPath *field.Path) field.ErrorList {
+	if value == "" || sets.NewString(supported...).Has(value) {
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(fldPath, value, supported)}
+}
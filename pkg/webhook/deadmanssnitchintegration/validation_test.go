@@ -0,0 +1,183 @@
+package deadmanssnitchintegration
+
+import (
+	"testing"
+	"time"
+
+	deadmanssnitchv1alpha1 "github.com/openshift/deadmanssnitch-operator/pkg/apis/deadmanssnitch/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testDeadMansSnitchIntegration() *deadmanssnitchv1alpha1.DeadmansSnitchIntegration {
+	return &deadmanssnitchv1alpha1.DeadmansSnitchIntegration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-dmsi",
+			Namespace: "deadmanssnitch-operator",
+		},
+		Spec: deadmanssnitchv1alpha1.DeadmansSnitchIntegrationSpec{
+			DmsAPIKeySecretRef: corev1.SecretReference{
+				Name:      "deadmanssnitch-api-key",
+				Namespace: "deadmanssnitch-operator",
+			},
+			ClusterDeploymentSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"api.openshift.com/managed": "true"},
+			},
+			TargetSecretRef: corev1.SecretReference{
+				Name:      "dms-secret",
+				Namespace: "openshift-monitoring",
+			},
+			Tags: []string{"test"},
+		},
+	}
+}
+
+func TestValidateDeadmansSnitchIntegration(t *testing.T) {
+	tests := []struct {
+		name           string
+		mutate         func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration)
+		others         func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration)
+		expectedFields []string
+	}{
+		{
+			name:   "valid",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {},
+		},
+		{
+			name: "valid templates",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.SnitchNameTemplate = "{{.ClusterName}}.{{.BaseDomain}}"
+				dmsi.Spec.NotesTemplate = "cluster_id: {{.ClusterID}}"
+				dmsi.Spec.Tags = []string{"{{.Region}}"}
+			},
+		},
+		{
+			name: "invalid templates",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.SnitchNameTemplate = "{{.ClusterName"
+				dmsi.Spec.NotesTemplate = "{{.Unknown}}"
+				dmsi.Spec.Tags = []string{"test", "{{end}}"}
+			},
+			expectedFields: []string{"spec.snitchNameTemplate", "spec.notesTemplate", "spec.tags[1]"},
+		},
+		{
+			name: "invalid selector",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.ClusterDeploymentSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+					{Key: "api.openshift.com/fedramp", Operator: metav1.LabelSelectorOpIn},
+				}
+			},
+			expectedFields: []string{"spec.clusterDeploymentSelector"},
+		},
+		{
+			name: "missing secret references",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.DmsAPIKeySecretRef = corev1.SecretReference{}
+				dmsi.Spec.TargetSecretRef.Namespace = ""
+			},
+			expectedFields: []string{"spec.dmsAPIKeySecretRef.name", "spec.dmsAPIKeySecretRef.namespace", "spec.targetSecretRef.namespace"},
+		},
+		{
+			name: "invalid secret references",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.TargetSecretRef.Name = "DMS_Secret"
+				dmsi.Spec.TargetSecretRef.Namespace = "openshift.monitoring"
+			},
+			expectedFields: []string{"spec.targetSecretRef.name", "spec.targetSecretRef.namespace"},
+		},
+		{
+			name: "invalid postfix",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.SnitchNamePostFix = "OSD_v4"
+			},
+			expectedFields: []string{"spec.snitchNamePostFix"},
+		},
+		{
+			name: "unsupported values",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.Interval = "5_minute"
+				dmsi.Spec.AlertType = "clever"
+				dmsi.Spec.Provider = "pagerduty"
+				dmsi.Spec.HibernationPolicy = "Orphan"
+				dmsi.Spec.DeletionPolicy = "Retain"
+			},
+			expectedFields: []string{"spec.interval", "spec.alertType", "spec.provider", "spec.hibernationPolicy", "spec.deletionPolicy"},
+		},
+		{
+			name: "resync period too short",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.ResyncPeriod = &metav1.Duration{Duration: 10 * time.Second}
+			},
+			expectedFields: []string{"spec.resyncPeriod"},
+		},
+		{
+			name: "supported values",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				dmsi.Spec.Interval = "hourly"
+				dmsi.Spec.AlertType = "smart"
+				dmsi.Spec.Provider = deadmanssnitchv1alpha1.ProviderHealthchecks
+				dmsi.Spec.HibernationPolicy = deadmanssnitchv1alpha1.HibernationPolicyPause
+				dmsi.Spec.DeletionPolicy = deadmanssnitchv1alpha1.DeletionPolicyOrphan
+				dmsi.Spec.ResyncPeriod = &metav1.Duration{Duration: 30 * time.Minute}
+			},
+		},
+		{
+			name:   "overlapping another DeadmansSnitchIntegration",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {},
+			others: func(other *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				other.Spec.ClusterDeploymentSelector = metav1.LabelSelector{
+					MatchLabels: map[string]string{"api.openshift.com/environment": "production"},
+				}
+			},
+			expectedFields: []string{"spec.clusterDeploymentSelector"},
+		},
+		{
+			name:   "overlapping a DeadmansSnitchIntegration being deleted",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {},
+			others: func(other *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				now := metav1.Now()
+				other.DeletionTimestamp = &now
+			},
+		},
+		{
+			name:   "another DeadmansSnitchIntegration with a different postfix",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {},
+			others: func(other *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				other.Spec.SnitchNamePostFix = "rhmi"
+			},
+		},
+		{
+			name:   "another DeadmansSnitchIntegration selecting other clusterdeployments",
+			mutate: func(dmsi *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {},
+			others: func(other *deadmanssnitchv1alpha1.DeadmansSnitchIntegration) {
+				other.Spec.ClusterDeploymentSelector = metav1.LabelSelector{
+					MatchLabels: map[string]string{"api.openshift.com/managed": "false"},
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dmsi := testDeadMansSnitchIntegration()
+			test.mutate(dmsi)
+			// the DeadmansSnitchIntegration being updated doesn't overlap itself
+			others := []deadmanssnitchv1alpha1.DeadmansSnitchIntegration{*testDeadMansSnitchIntegration()}
+			if test.others != nil {
+				other := testDeadMansSnitchIntegration()
+				other.Name = "other-dmsi"
+				test.others(other)
+				others = append(others, *other)
+			}
+			fields := []string{}
+			for _, err := range ValidateDeadmansSnitchIntegration(dmsi, others) {
+				fields = append(fields, err.Field)
+			}
+			if test.expectedFields == nil {
+				test.expectedFields = []string{}
+			}
+			assert.Equal(t, test.expectedFields, fields)
+		})
+	}
+}
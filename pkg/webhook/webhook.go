@@ -0,0 +1,18 @@
+package webhook
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// AddToManagerFuncs is a list of functions to add all admission webhooks to the Manager
+var AddToManagerFuncs []func(manager.Manager) error
+
+// AddToManager registers all admission webhooks with the webhook server of the Manager
+func AddToManager(m manager.Manager) error {
+	for _, f := range AddToManagerFuncs {
+		if err := f(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}